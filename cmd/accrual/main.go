@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/config"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"github.com/ramabmtr/billing-engine/internal/service"
+)
+
+// Accrues daily interest for every loan over a date range, yesterday by default.
+// Days that were already accrued are skipped, so a range can be re-run after a failure.
+//
+//	go run cmd/accrual/main.go -from 2025-01-01 -to 2025-01-31
+func main() {
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	fromFlag := flag.String("from", yesterday, "first date to accrue (YYYY-MM-DD)")
+	toFlag := flag.String("to", "", "last date to accrue (YYYY-MM-DD), defaults to -from")
+	flag.Parse()
+
+	if *toFlag == "" {
+		*toFlag = *fromFlag
+	}
+	from, err := time.Parse(time.DateOnly, *fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid -from date: %s\n", err.Error())
+	}
+	to, err := time.Parse(time.DateOnly, *toFlag)
+	if err != nil {
+		log.Fatalf("Invalid -to date: %s\n", err.Error())
+	}
+
+	config.InitEnv()
+	config.InitDB()
+
+	accrualSvc := service.NewAccrualService(
+		repository.NewLoanRepo(config.GetDB()),
+		repository.NewLoanPaymentRepo(config.GetDB()),
+		repository.NewInterestAccrualRepo(config.GetDB()),
+		repository.NewLedgerRepo(config.GetDB()),
+		repository.NewTxManager(config.GetDB()),
+	)
+
+	log.Printf("Accruing interest from %s to %s...\n", from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	result, err := accrualSvc.AccrueInterest(context.Background(), from, to)
+	if err != nil {
+		log.Fatalf("Failed to accrue interest: %s\n", err.Error())
+	}
+
+	log.Printf("Interest accrual completed: %d loans, %d days accrued, %d days already accrued\n",
+		result.Loans, result.Accrued, result.Skipped)
+	for currency, amount := range result.Amounts {
+		log.Printf("Interest accrued in %s: %s\n", currency, amount.Amount.StringFixed(4))
+	}
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/ramabmtr/billing-engine/config"
 	_ "github.com/ramabmtr/billing-engine/docs"
+	"github.com/ramabmtr/billing-engine/internal/constant"
 	"github.com/ramabmtr/billing-engine/internal/handler"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
 	"github.com/ramabmtr/billing-engine/internal/repository"
 	"github.com/ramabmtr/billing-engine/internal/service"
 	"github.com/swaggo/echo-swagger"
@@ -37,26 +42,88 @@ func main() {
 	borrowerRepo := repository.NewBorrowerRepo(config.GetDB())
 	loanRepo := repository.NewLoanRepo(config.GetDB())
 	loanPaymentRepo := repository.NewLoanPaymentRepo(config.GetDB())
+	ledgerRepo := repository.NewLedgerRepo(config.GetDB())
+	interestAccrualRepo := repository.NewInterestAccrualRepo(config.GetDB())
+	holidayRepo := repository.NewHolidayRepo(config.GetDB())
+	outboxRepo := repository.NewOutboxRepo(config.GetDB())
+	jobRunRepo := repository.NewJobRunRepo(config.GetDB())
+	jobLocker := repository.NewJobLocker(config.GetDB())
+	simulationClockRepo := repository.NewSimulationClockRepo(config.GetDB())
+	txManager := repository.NewTxManager(config.GetDB())
 
 	// Initialize services
-	borrowerSvc := service.NewBorrowerService(borrowerRepo)
-	loanSvc := service.NewLoanService(loanRepo, loanPaymentRepo)
+	var clock lib.Clock = lib.NewSystemClock()
+	var simulatedClock *lib.FakeClock
+	if config.GetEnv().Simulation.Enabled {
+		log.Println("Warning: simulation mode is enabled, the clock only moves through /api/simulation/advance")
+		// the stored simulated time replaces this once the server holds the simulation lock
+		simulatedClock = lib.NewFakeClock(time.Now())
+		clock = simulatedClock
+	}
+	borrowerSvc := service.NewBorrowerService(borrowerRepo, loanRepo, loanPaymentRepo, clock)
+	loanEnv := config.GetEnv().Loan
+	if _, err := lib.LookupCurrency(loanEnv.Currency); err != nil {
+		log.Fatalf("Invalid LOAN_CURRENCY: %s\n", err.Error())
+	}
+	if _, err := lib.LoadLocation(loanEnv.DefaultTimezone); err != nil {
+		log.Fatalf("Invalid LOAN_DEFAULT_TIMEZONE: %s\n", err.Error())
+	}
+	calendarCfg := service.CalendarConfig{
+		CountryCode: config.GetEnv().Calendar.CountryCode,
+		Adjustment:  constant.DateAdjustment(config.GetEnv().Calendar.DateAdjustment),
+	}
+	originationFee := model.OriginationFeePolicy{
+		Type:  constant.OriginationFeeType(loanEnv.OriginationFeeType),
+		Value: loanEnv.OriginationFeeValue,
+		Mode:  constant.OriginationFeeMode(loanEnv.OriginationFeeMode),
+	}
+	if err := originationFee.Validate(); err != nil {
+		log.Fatalf("Invalid LOAN_ORIGINATION_FEE_*: %s\n", err.Error())
+	}
+	loanSvc := service.NewLoanService(loanRepo, loanPaymentRepo, borrowerRepo, ledgerRepo, holidayRepo, txManager, clock, service.LoanConfig{
+		Currency:        loanEnv.Currency,
+		OriginationFee:  originationFee,
+		Calendar:        calendarCfg,
+		DefaultTimezone: loanEnv.DefaultTimezone,
+	})
+	ledgerSvc := service.NewLedgerService(ledgerRepo, loanRepo, loanPaymentRepo, interestAccrualRepo, txManager, clock)
+	billingEnv := config.GetEnv().Billing
+	billingCfg := service.BillingConfig{
+		LateFee:            lib.NewMoney(billingEnv.LateFeeAmount, billingEnv.LateFeeCurrency),
+		LateFeeGraceDays:   billingEnv.LateFeeGraceDays,
+		ReminderDaysBefore: billingEnv.ReminderDaysBefore,
+		Calendar:           calendarCfg,
+	}
+	if err := billingCfg.Validate(); err != nil {
+		log.Fatalf("Invalid BILLING_LATE_FEE_*: %s\n", err.Error())
+	}
+	billingSvc := service.NewBillingService(loanRepo, loanPaymentRepo, ledgerRepo, holidayRepo, outboxRepo, jobRunRepo, jobLocker, txManager, clock, billingCfg)
+	calendarSvc := service.NewCalendarService(holidayRepo)
 
 	// Initialize handlers
 	borrowerHandler := handler.NewBorrowerHandler(borrowerSvc)
 	loanHandler := handler.NewLoanHandler(loanSvc)
 	paymentHandler := handler.NewPaymentHandler(loanSvc)
+	ledgerHandler := handler.NewLedgerHandler(ledgerSvc)
+	jobHandler := handler.NewJobHandler(billingSvc)
+	holidayHandler := handler.NewHolidayHandler(calendarSvc)
 
 	// Initialize Echo
 	e := echo.New()
 	e.Validator = config.NewValidator()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
 
 	// Middleware
 	e.Pre(middleware.RemoveTrailingSlash())
 	e.Use(middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
 		KeyLookup: "header:X-API-KEY",
 		Validator: func(key string, c echo.Context) (bool, error) {
-			return key == config.GetEnv().Server.ApiKey, nil
+			serverEnv := config.GetEnv().Server
+			if serverEnv.AdminApiKey != "" && key == serverEnv.AdminApiKey {
+				handler.MarkAdmin(c)
+				return true, nil
+			}
+			return key == serverEnv.ApiKey, nil
 		},
 		Skipper: func(c echo.Context) bool {
 			skippedPaths := []string{"/api/ping", "/docs"}
@@ -83,7 +150,10 @@ func main() {
 	}))
 	e.Use(middleware.RequestID())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		// lets browser clients read the loan version for If-Match
+		ExposeHeaders: []string{"ETag"},
+	}))
 
 	// API routes
 	e.GET("/docs/*", echoSwagger.WrapHandler)
@@ -101,9 +171,34 @@ func main() {
 	borrowerHandler.RegisterRoutes(apiGroup)
 	loanHandler.RegisterRoutes(apiGroup)
 	paymentHandler.RegisterRoutes(apiGroup)
+	ledgerHandler.RegisterRoutes(apiGroup)
+	jobHandler.RegisterRoutes(apiGroup)
+	holidayHandler.RegisterRoutes(apiGroup)
+	var simulationSvc *service.SimulationService
+	if simulatedClock != nil {
+		runAt, err := time.Parse("15:04", config.GetEnv().Billing.DailyRunAt)
+		if err != nil {
+			log.Fatalf("Invalid BILLING_DAILY_RUN_AT: %s\n", err.Error())
+		}
+		simulationSvc = service.NewSimulationService(simulatedClock, simulationClockRepo, jobLocker, billingSvc, service.SimulationConfig{
+			DailyRunHour:   runAt.Hour(),
+			DailyRunMinute: runAt.Minute(),
+		})
+		handler.NewSimulationHandler(simulationSvc).RegisterRoutes(apiGroup)
+	}
 
 	// Start server
 	serverAddr := fmt.Sprintf(":%d", config.GetEnv().Server.Port)
+	if simulationSvc != nil {
+		err := simulationSvc.Serve(context.Background(), func(ctx context.Context) error {
+			log.Printf("Simulated clock is at %s\n", simulationSvc.Now().Format(time.RFC3339))
+			return e.Start(serverAddr)
+		})
+		if err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+		return
+	}
 	if err := e.Start(serverAddr); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
@@ -1,28 +1,159 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ramabmtr/billing-engine/config"
-	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"github.com/ramabmtr/billing-engine/internal/service"
+	"github.com/ramabmtr/billing-engine/migrations"
 )
 
+// Manages the database schema with the versioned SQL migrations in the migrations directory, which are embedded in
+// this command. Applied migrations are recorded in the schema_migrations table. Without a command, pending
+// migrations are applied.
+//
+//	go run cmd/migration/main.go up
+//	go run cmd/migration/main.go down -steps 1
+//	go run cmd/migration/main.go status
+//	go run cmd/migration/main.go create add_loan_version
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: migration [up | down [-steps n] | status | create [-dir path] name]")
+	}
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command == "" {
+		command = "up"
+	}
+	args := flag.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+
+	switch command {
+	case "up":
+		up()
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert, newest first")
+		_ = fs.Parse(args)
+		down(*steps)
+	case "status":
+		status()
+	case "create":
+		fs := flag.NewFlagSet("create", flag.ExitOnError)
+		dir := fs.String("dir", "migrations", "directory holding the migration files")
+		_ = fs.Parse(args)
+		if fs.NArg() != 1 {
+			log.Fatalln("Usage: migration create [-dir path] name")
+		}
+		create(*dir, fs.Arg(0))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func newMigrationService() *service.MigrationService {
+	ms, err := lib.LoadMigrations(migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %s\n", err.Error())
+	}
+
 	config.InitEnv()
 	config.InitDB()
 
+	return service.NewMigrationService(
+		ms,
+		repository.NewMigrationRepo(config.GetDB()),
+		repository.NewTxManager(config.GetDB()),
+		repository.NewJobLocker(config.GetDB()),
+		lib.NewSystemClock(),
+	)
+}
+
+func up() {
 	log.Println("Running database migrations...")
 
-	// Auto migrate the schema
-	err := config.GetDB().AutoMigrate(
-		&model.Borrower{},
-		&model.Loan{},
-		&model.LoanPayment{},
-	)
+	applied, err := newMigrationService().Up(context.Background())
+	for _, m := range applied {
+		log.Printf("Applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %s\n", err.Error())
+	}
 
+	log.Printf("Database migrations completed successfully: %d applied\n", len(applied))
+}
+
+func down(steps int) {
+	log.Printf("Reverting %d database migrations...\n", steps)
+
+	reverted, err := newMigrationService().Down(context.Background(), steps)
+	for _, m := range reverted {
+		log.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Failed to revert migrations: %s\n", err.Error())
+	}
+
+	log.Printf("Database migrations reverted successfully: %d reverted\n", len(reverted))
+}
+
+func status() {
+	statuses, err := newMigrationService().Status(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to read migration status: %s\n", err.Error())
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "MIGRATION\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if s.AppliedAt != nil {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.DateTime)
+		}
+		if s.Missing {
+			state = "missing"
+		}
+		_, _ = fmt.Fprintf(w, "%04d_%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	_ = w.Flush()
+}
+
+func create(dir, name string) {
+	ms, err := lib.LoadMigrations(os.DirFS(dir))
 	if err != nil {
-		log.Printf("Failed to migrate database: %s\n", err.Error())
+		log.Fatalf("Failed to load migrations: %s\n", err.Error())
+	}
+	upFile, downFile, err := lib.MigrationFileNames(ms, name)
+	if err != nil {
+		log.Fatalf("Invalid migration name: %s\n", err.Error())
 	}
 
-	log.Println("Database migrations completed successfully")
+	files := []struct {
+		name    string
+		content string
+	}{
+		{name: upFile, content: "-- SQL applying the migration\n"},
+		{name: downFile, content: "-- SQL reverting the migration\n"},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		err = os.WriteFile(path, []byte(f.content), 0o644)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %s\n", path, err.Error())
+		}
+		log.Printf("Created %s\n", path)
+	}
 }
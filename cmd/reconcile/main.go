@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/ramabmtr/billing-engine/config"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"github.com/ramabmtr/billing-engine/internal/service"
+)
+
+// Recomputes the outstanding and paid totals, next due date and overdue installment count stored on every loan from
+// its installments, reporting the loans that drifted and correcting them. Installments created before their principal
+// was recorded get the principal of their schedule first. With -dry-run nothing is stored.
+//
+//	go run cmd/reconcile/main.go
+//	go run cmd/reconcile/main.go -dry-run
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report drift without correcting it")
+	flag.Parse()
+
+	config.InitEnv()
+	config.InitDB()
+
+	reconciliationSvc := service.NewReconciliationService(
+		repository.NewLoanRepo(config.GetDB()),
+		repository.NewLoanPaymentRepo(config.GetDB()),
+		repository.NewTxManager(config.GetDB()),
+	)
+
+	log.Println("Reconciling loan balances...")
+
+	result, err := reconciliationSvc.ReconcileLoanBalances(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to reconcile loan balances: %s\n", err.Error())
+	}
+
+	for _, d := range result.Drifted {
+		log.Printf("Loan %s drifted: %s\n", d.LoanID, strings.Join(d.Fields, ", "))
+	}
+	log.Printf("Loan balance reconciliation completed: %d loans, %d drifted, %d corrected, %d installment principals recorded\n",
+		result.Loans, len(result.Drifted), result.Fixed, result.PrincipalRecorded)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/config"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"github.com/ramabmtr/billing-engine/internal/service"
+)
+
+// Runs the daily billing job once on start and then every day at BILLING_DAILY_RUN_AT (UTC).
+// Any number of workers can be started; a database lock makes sure only one of them runs the job at a time
+// and a run that already succeeded for the day is skipped.
+//
+//	go run cmd/worker/main.go
+func main() {
+	config.InitEnv()
+	if config.GetEnv().Simulation.Enabled {
+		log.Fatalln("The worker does not run in simulation mode, advance the simulated clock through the API instead")
+	}
+	config.InitDB()
+
+	billingEnv := config.GetEnv().Billing
+	runAt, err := time.Parse("15:04", billingEnv.DailyRunAt)
+	if err != nil {
+		log.Fatalf("Invalid BILLING_DAILY_RUN_AT: %s\n", err.Error())
+	}
+
+	billingCfg := service.BillingConfig{
+		LateFee:            lib.NewMoney(billingEnv.LateFeeAmount, billingEnv.LateFeeCurrency),
+		LateFeeGraceDays:   billingEnv.LateFeeGraceDays,
+		ReminderDaysBefore: billingEnv.ReminderDaysBefore,
+		Calendar: service.CalendarConfig{
+			CountryCode: config.GetEnv().Calendar.CountryCode,
+			Adjustment:  constant.DateAdjustment(config.GetEnv().Calendar.DateAdjustment),
+		},
+	}
+	if err := billingCfg.Validate(); err != nil {
+		log.Fatalf("Invalid BILLING_LATE_FEE_*: %s\n", err.Error())
+	}
+
+	billingSvc := service.NewBillingService(
+		repository.NewLoanRepo(config.GetDB()),
+		repository.NewLoanPaymentRepo(config.GetDB()),
+		repository.NewLedgerRepo(config.GetDB()),
+		repository.NewHolidayRepo(config.GetDB()),
+		repository.NewOutboxRepo(config.GetDB()),
+		repository.NewJobRunRepo(config.GetDB()),
+		repository.NewJobLocker(config.GetDB()),
+		repository.NewTxManager(config.GetDB()),
+		lib.NewSystemClock(),
+		billingCfg,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		runDailyBilling(ctx, billingSvc)
+
+		next := lib.NextDailyRun(time.Now(), runAt.Hour(), runAt.Minute())
+		log.Printf("Next daily billing run at %s\n", next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			log.Println("Worker stopped")
+			return
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+func runDailyBilling(ctx context.Context, billingSvc *service.BillingService) {
+	log.Println("Running daily billing...")
+
+	run, err := billingSvc.RunDailyBilling(ctx, constant.JobTriggerScheduled)
+	if lib.IsErrorKind(err, lib.ErrorKindConflict) {
+		log.Printf("Daily billing skipped: %s\n", err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Daily billing failed: %s\n", err.Error())
+		return
+	}
+
+	log.Printf("Daily billing completed: run %s, %s\n", run.ID, string(run.Result))
+}
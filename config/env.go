@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 )
 
 var (
@@ -16,13 +17,18 @@ var (
 )
 
 type Env struct {
-	Server   ServerEnv
-	Database DatabaseEnv
+	Server     ServerEnv
+	Database   DatabaseEnv
+	Loan       LoanEnv
+	Calendar   CalendarEnv
+	Billing    BillingEnv
+	Simulation SimulationEnv
 }
 
 type ServerEnv struct {
-	Port   int
-	ApiKey string
+	Port        int
+	ApiKey      string
+	AdminApiKey string
 }
 
 type DatabaseEnv struct {
@@ -34,6 +40,39 @@ type DatabaseEnv struct {
 	SSLMode  string
 }
 
+// LoanEnv configures the currency and origination fee of the loan product. The fee type is FLAT or PERCENTAGE and
+// the mode is DEDUCTED or FINANCED. DefaultTimezone is the IANA timezone due dates are set in for borrowers without one.
+type LoanEnv struct {
+	Currency            string
+	OriginationFeeType  string
+	OriginationFeeValue decimal.Decimal
+	OriginationFeeMode  string
+	DefaultTimezone     string
+}
+
+// CalendarEnv picks the holiday calendar by ISO country code and the convention adjusting due dates that fall on
+// weekends or holidays: NONE, FOLLOWING, MODIFIED_FOLLOWING or PRECEDING.
+type CalendarEnv struct {
+	CountryCode    string
+	DateAdjustment string
+}
+
+// BillingEnv configures the daily billing run. The late fee is charged in LateFeeCurrency, which defaults to the loan
+// currency, on installments in that currency only.
+type BillingEnv struct {
+	LateFeeAmount      decimal.Decimal
+	LateFeeCurrency    string
+	LateFeeGraceDays   int
+	ReminderDaysBefore int
+	DailyRunAt         string
+}
+
+// SimulationEnv switches the API to a simulated clock that only moves when advanced through the simulation endpoint.
+// It is meant for QA environments and must stay disabled in production.
+type SimulationEnv struct {
+	Enabled bool
+}
+
 func (c *DatabaseEnv) GetDSN() string {
 	return fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -48,10 +87,12 @@ func InitEnv() {
 			log.Println("Warning: .env file not found, using default environment variables")
 		}
 
+		loanCurrency := get("LOAN_CURRENCY", "IDR")
 		env = &Env{
 			Server: ServerEnv{
-				Port:   getAsInt("SERVER_PORT", 8080),
-				ApiKey: get("SERVER_API_KEY", ""),
+				Port:        getAsInt("SERVER_PORT", 8080),
+				ApiKey:      get("SERVER_API_KEY", ""),
+				AdminApiKey: get("SERVER_ADMIN_API_KEY", ""),
 			},
 			Database: DatabaseEnv{
 				Host:     get("DB_HOST", "localhost"),
@@ -61,6 +102,27 @@ func InitEnv() {
 				DBName:   get("DB_NAME", "billing_engine"),
 				SSLMode:  get("DB_SSLMODE", "disable"),
 			},
+			Loan: LoanEnv{
+				Currency:            loanCurrency,
+				OriginationFeeType:  get("LOAN_ORIGINATION_FEE_TYPE", "FLAT"),
+				OriginationFeeValue: getAsDecimal("LOAN_ORIGINATION_FEE_VALUE", decimal.Zero),
+				OriginationFeeMode:  get("LOAN_ORIGINATION_FEE_MODE", "DEDUCTED"),
+				DefaultTimezone:     get("LOAN_DEFAULT_TIMEZONE", "Asia/Jakarta"),
+			},
+			Calendar: CalendarEnv{
+				CountryCode:    get("CALENDAR_COUNTRY_CODE", "ID"),
+				DateAdjustment: get("CALENDAR_DATE_ADJUSTMENT", "NONE"),
+			},
+			Billing: BillingEnv{
+				LateFeeAmount:      getAsDecimal("BILLING_LATE_FEE_AMOUNT", decimal.Zero),
+				LateFeeCurrency:    get("BILLING_LATE_FEE_CURRENCY", loanCurrency),
+				LateFeeGraceDays:   getAsInt("BILLING_LATE_FEE_GRACE_DAYS", 3),
+				ReminderDaysBefore: getAsInt("BILLING_REMINDER_DAYS_BEFORE", 3),
+				DailyRunAt:         get("BILLING_DAILY_RUN_AT", "01:00"),
+			},
+			Simulation: SimulationEnv{
+				Enabled: getAsBool("SIMULATION_ENABLED", false),
+			},
 		}
 	})
 }
@@ -88,3 +150,21 @@ func getAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getAsDecimal(key string, defaultValue decimal.Decimal) decimal.Decimal {
+	if value, exists := os.LookupEnv(key); exists {
+		if decimalValue, err := decimal.NewFromString(value); err == nil {
+			return decimalValue
+		}
+	}
+	return defaultValue
+}
+
+func getAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"reflect"
+
 	"github.com/go-playground/validator/v10"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 )
 
 type Validator struct {
@@ -9,7 +12,15 @@ type Validator struct {
 }
 
 func NewValidator() *Validator {
-	return &Validator{validator: validator.New(validator.WithRequiredStructEnabled())}
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// money is validated by its amount, so required, gt and max work on it like on a number
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if m, ok := field.Interface().(lib.Money); ok {
+			return m.Amount.InexactFloat64()
+		}
+		return nil
+	}, lib.Money{})
+	return &Validator{validator: v}
 }
 
 func (cv *Validator) Validate(i interface{}) error {
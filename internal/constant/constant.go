@@ -7,9 +7,173 @@ const (
 	PeriodUnitMonth = "MONTH"
 )
 
+type InterestMethod string
+
+const (
+	InterestMethodFlat    = "FLAT"
+	InterestMethodAnnuity = "ANNUITY"
+)
+
+type OriginationFeeType string
+
+const (
+	OriginationFeeTypeFlat       = "FLAT"
+	OriginationFeeTypePercentage = "PERCENTAGE"
+)
+
+type OriginationFeeMode string
+
+const (
+	OriginationFeeModeDeducted = "DEDUCTED"
+	OriginationFeeModeFinanced = "FINANCED"
+)
+
+// RoundingMode is how amounts are rounded to the minor units of their currency
+type RoundingMode string
+
+const (
+	RoundingModeHalfUp   = "HALF_UP"
+	RoundingModeHalfEven = "HALF_EVEN"
+	RoundingModeDown     = "DOWN"
+)
+
+// DateAdjustment is the business day convention moving a due date that falls on a weekend or holiday
+type DateAdjustment string
+
+const (
+	DateAdjustmentNone              = "NONE"
+	DateAdjustmentFollowing         = "FOLLOWING"
+	DateAdjustmentModifiedFollowing = "MODIFIED_FOLLOWING"
+	DateAdjustmentPreceding         = "PRECEDING"
+)
+
+type BorrowerStatus string
+
+const (
+	BorrowerStatusActive      = "ACTIVE"
+	BorrowerStatusInactive    = "INACTIVE"
+	BorrowerStatusBlacklisted = "BLACKLISTED"
+)
+
+type LoanStatus string
+
+const (
+	LoanStatusActive    = "ACTIVE"
+	LoanStatusCompleted = "COMPLETED"
+)
+
 type LoanPaymentStatus string
 
 const (
-	LoanPaymentStatusUnpaid = "UNPAID"
-	LoanPaymentStatusPaid   = "PAID"
+	LoanPaymentStatusUnpaid    = "UNPAID"
+	LoanPaymentStatusOverdue   = "OVERDUE"
+	LoanPaymentStatusPaid      = "PAID"
+	LoanPaymentStatusWaived    = "WAIVED"
+	LoanPaymentStatusCancelled = "CANCELLED"
+)
+
+// LoanPaymentOutstandingStatuses are the statuses of installments that still have to be paid
+var LoanPaymentOutstandingStatuses = []string{LoanPaymentStatusUnpaid, LoanPaymentStatusOverdue}
+
+const (
+	ErrCodeInvalidRequest          = "INVALID_REQUEST"
+	ErrCodeInvalidCursor           = "INVALID_CURSOR"
+	ErrCodeInternal                = "INTERNAL_ERROR"
+	ErrCodeBorrowerNotFound        = "BORROWER_NOT_FOUND"
+	ErrCodeBorrowerNotActive       = "BORROWER_NOT_ACTIVE"
+	ErrCodeBorrowerBlacklisted     = "BORROWER_BLACKLISTED"
+	ErrCodeBorrowerStatusChanged   = "BORROWER_STATUS_CHANGED"
+	ErrCodeLoanNotFound            = "LOAN_NOT_FOUND"
+	ErrCodeOutstandingLoanExists   = "OUTSTANDING_LOAN_EXISTS"
+	ErrCodeLoanAlreadyPaid         = "LOAN_ALREADY_PAID"
+	ErrCodePaymentBelowMinimum     = "PAYMENT_BELOW_MINIMUM"
+	ErrCodePaymentNotInPlan        = "PAYMENT_NOT_IN_PLAN"
+	ErrCodeLoanWrittenOff          = "LOAN_WRITTEN_OFF"
+	ErrCodeNothingToWriteOff       = "NOTHING_TO_WRITE_OFF"
+	ErrCodeJournalEntryNotFound    = "JOURNAL_ENTRY_NOT_FOUND"
+	ErrCodeEntryAlreadyReversed    = "ENTRY_ALREADY_REVERSED"
+	ErrCodeReversalNotReversible   = "REVERSAL_NOT_REVERSIBLE"
+	ErrCodeEntryNotReversible      = "ENTRY_NOT_REVERSIBLE"
+	ErrCodeJobAlreadyRunning       = "JOB_ALREADY_RUNNING"
+	ErrCodeJobAlreadyCompleted     = "JOB_ALREADY_COMPLETED"
+	ErrCodeSimulationRunning       = "SIMULATION_RUNNING"
+	ErrCodeLoanPaymentNotFound     = "LOAN_PAYMENT_NOT_FOUND"
+	ErrCodeInvalidStatusTransition = "INVALID_STATUS_TRANSITION"
+	ErrCodeInstallmentNotOldest    = "INSTALLMENT_NOT_OLDEST"
+	ErrCodeFeeExceedsPrincipal     = "FEE_EXCEEDS_PRINCIPAL"
+	ErrCodeHolidayNotFound         = "HOLIDAY_NOT_FOUND"
+	ErrCodeUnsupportedCurrency     = "UNSUPPORTED_CURRENCY"
+	ErrCodeCurrencyMismatch        = "CURRENCY_MISMATCH"
+	ErrCodeMigrationNotFound       = "MIGRATION_NOT_FOUND"
+	ErrCodeLoanVersionMismatch     = "LOAN_VERSION_MISMATCH"
+)
+
+type DelinquencyBucket string
+
+const (
+	DelinquencyBucketCurrent = "CURRENT"
+	DelinquencyBucket1To30   = "DPD_1_30"
+	DelinquencyBucket31To60  = "DPD_31_60"
+	DelinquencyBucket61To90  = "DPD_61_90"
+	DelinquencyBucketOver90  = "DPD_90_PLUS"
+)
+
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeAsset     = "ASSET"
+	LedgerAccountTypeLiability = "LIABILITY"
+	LedgerAccountTypeIncome    = "INCOME"
+	LedgerAccountTypeExpense   = "EXPENSE"
+)
+
+const (
+	LedgerAccountLoanReceivable     = "LOAN_RECEIVABLE"
+	LedgerAccountInterestReceivable = "INTEREST_RECEIVABLE"
+	LedgerAccountInterestIncome     = "INTEREST_INCOME"
+	LedgerAccountFeeIncome          = "FEE_INCOME"
+	LedgerAccountCash               = "CASH"
+	LedgerAccountSuspense           = "SUSPENSE"
+	LedgerAccountLoanLossExpense    = "LOAN_LOSS_EXPENSE"
+)
+
+type JournalEntryType string
+
+const (
+	JournalEntryTypeDisbursement    = "DISBURSEMENT"
+	JournalEntryTypeRepayment       = "REPAYMENT"
+	JournalEntryTypeInterestAccrual = "INTEREST_ACCRUAL"
+	JournalEntryTypeFeeAccrual      = "FEE_ACCRUAL"
+	JournalEntryTypeReversal        = "REVERSAL"
+	JournalEntryTypeWriteOff        = "WRITE_OFF"
+	JournalEntryTypeWaiver          = "WAIVER"
+)
+
+const (
+	JobNameDailyBilling    = "DAILY_BILLING"
+	JobNameSchemaMigration = "SCHEMA_MIGRATION"
+	// JobNameSimulation is held by the server running simulation mode for as long as it runs
+	JobNameSimulation = "SIMULATION"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerScheduled = "SCHEDULED"
+	JobTriggerManual    = "MANUAL"
+	JobTriggerSimulated = "SIMULATED"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   = "RUNNING"
+	JobRunStatusSucceeded = "SUCCEEDED"
+	JobRunStatusFailed    = "FAILED"
+)
+
+type OutboxEventType string
+
+const (
+	OutboxEventPaymentDueReminder = "PAYMENT_DUE_REMINDER"
 )
@@ -2,12 +2,16 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
 	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/service"
 )
 
+const dateLayout = "2006-01-02"
+
 type BorrowerHandler struct {
 	borrowerSvc *service.BorrowerService
 }
@@ -20,54 +24,286 @@ func (h *BorrowerHandler) RegisterRoutes(g *echo.Group) {
 	rg := g.Group("/borrowers")
 	rg.POST("", h.Create)
 	rg.GET("", h.List)
+	rg.GET("/:id", h.Detail)
+	rg.GET("/:id/summary", h.Summary, AsOf)
+	rg.PATCH("/:id", h.Update)
+	rg.POST("/:id/deactivate", h.Deactivate, RequireAdmin)
+	rg.POST("/:id/blacklist", h.Blacklist, RequireAdmin)
 }
 
 type CreateBorrowerReqBody struct {
-	Name string `json:"name" validate:"required"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Phone       string `json:"phone" validate:"omitempty,max=20"`
+	Email       string `json:"email" validate:"omitempty,email,max=100"`
+	NationalID  string `json:"national_id" validate:"omitempty,max=32"`
+	Address     string `json:"address"`
+	DateOfBirth string `json:"date_of_birth" validate:"omitempty,datetime=2006-01-02" example:"1990-01-31"`
+	Timezone    string `json:"timezone" validate:"omitempty,timezone" example:"Asia/Jakarta"`
 }
 
 // Create godoc
 // @Summary Create a new borrower
-// @Description Create a new borrower with the provided name
+// @Description Create a new borrower with the provided profile
 // @Tags borrowers
 // @Accept json
 // @Produce json
 // @Param request body CreateBorrowerReqBody true "Borrower information"
 // @Success 200 {object} lib.Response "Successfully created borrower"
+// @Failure 400 {object} lib.Response "Invalid request"
 // @Failure 500 {object} lib.Response "Internal server error"
 // @Router /borrowers [post]
 // @Security ApiKeyAuth
 func (h *BorrowerHandler) Create(c echo.Context) error {
 	var req CreateBorrowerReqBody
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid request payload")
 	}
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
 	}
 
-	borrower, err := h.borrowerSvc.Create(req.Name)
+	profile := service.BorrowerProfile{
+		Name:       &req.Name,
+		Phone:      &req.Phone,
+		Email:      &req.Email,
+		NationalID: &req.NationalID,
+		Address:    &req.Address,
+		Timezone:   &req.Timezone,
+	}
+	if req.DateOfBirth != "" {
+		dob, _ := time.Parse(dateLayout, req.DateOfBirth)
+		profile.DateOfBirth = &dob
+	}
+
+	borrower, err := h.borrowerSvc.Create(c.Request().Context(), profile)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, lib.ResponseError(err))
+		return err
 	}
 
 	return c.JSON(http.StatusOK, lib.ResponseSuccess(borrower, "borrower"))
 }
 
+type ListBorrowersQuery struct {
+	Name         string `query:"name"`
+	IsDelinquent *bool  `query:"is_delinquent"`
+	CreatedFrom  string `query:"created_from" validate:"omitempty,datetime=2006-01-02"`
+	CreatedTo    string `query:"created_to" validate:"omitempty,datetime=2006-01-02"`
+	Sort         string `query:"sort" validate:"omitempty,oneof=created_at -created_at name -name"`
+	Cursor       string `query:"cursor"`
+	Limit        int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
 // List godoc
-// @Summary List all borrowers
-// @Description Get a list of all borrowers
+// @Summary List borrowers
+// @Description Get a page of borrowers with their delinquency status. Use next_cursor from the response to fetch the next page.
 // @Tags borrowers
 // @Produce json
+// @Param name query string false "Case-insensitive search on borrower name"
+// @Param is_delinquent query bool false "Only delinquent (true) or non-delinquent (false) borrowers"
+// @Param created_from query string false "Created on or after this date (YYYY-MM-DD)"
+// @Param created_to query string false "Created on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "Sort order" Enums(created_at, -created_at, name, -name)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
 // @Success 200 {object} lib.Response "Successfully retrieved borrowers list"
+// @Failure 400 {object} lib.Response "Invalid request"
 // @Failure 500 {object} lib.Response "Internal server error"
 // @Router /borrowers [get]
 // @Security ApiKeyAuth
 func (h *BorrowerHandler) List(c echo.Context) error {
-	borrowers, err := h.borrowerSvc.List()
+	var req ListBorrowersQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	f := service.BorrowerListFilter{
+		Name:         req.Name,
+		IsDelinquent: req.IsDelinquent,
+		Sort:         req.Sort,
+		Cursor:       req.Cursor,
+		Limit:        req.Limit,
+	}
+	if req.CreatedFrom != "" {
+		from, _ := time.Parse(dateLayout, req.CreatedFrom)
+		f.CreatedFrom = &from
+	}
+	if req.CreatedTo != "" {
+		to, _ := time.Parse(dateLayout, req.CreatedTo)
+		to = to.AddDate(0, 0, 1)
+		f.CreatedTo = &to
+	}
+
+	borrowers, nextCursor, err := h.borrowerSvc.List(c.Request().Context(), f)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponsePage(borrowers, nextCursor, "borrowers"))
+}
+
+// Detail godoc
+// @Summary Get borrower profile
+// @Description Get the profile of a specific borrower
+// @Tags borrowers
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Success 200 {object} lib.Response{data=model.Borrower} "Successfully retrieved borrower"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 404 {object} lib.Response "Borrower not found"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /borrowers/{id} [get]
+// @Security ApiKeyAuth
+func (h *BorrowerHandler) Detail(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
+	borrower, err := h.borrowerSvc.Get(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(borrower, "borrower"))
+}
+
+// Summary godoc
+// @Summary Get borrower financial summary
+// @Description Get the aggregated exposure of a borrower across all loans: principal, repaid and outstanding amounts, next due installment, loan counts and delinquency. Admins can pass as_of to preview the summary at another date.
+// @Tags borrowers
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Param as_of query string false "Admin only. Compute the summary as of this date (YYYY-MM-DD)"
+// @Success 200 {object} lib.Response{data=model.BorrowerSummary} "Successfully retrieved borrower summary"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "as_of requires admin access"
+// @Failure 404 {object} lib.Response "Borrower not found"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /borrowers/{id}/summary [get]
+// @Security ApiKeyAuth
+func (h *BorrowerHandler) Summary(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
+	summary, err := h.borrowerSvc.GetSummary(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(summary, "summary"))
+}
+
+type UpdateBorrowerReqBody struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Phone       *string `json:"phone" validate:"omitempty,max=20"`
+	Email       *string `json:"email" validate:"omitempty,email,max=100"`
+	NationalID  *string `json:"national_id" validate:"omitempty,max=32"`
+	Address     *string `json:"address"`
+	DateOfBirth *string `json:"date_of_birth" validate:"omitempty,datetime=2006-01-02" example:"1990-01-31"`
+	Timezone    *string `json:"timezone" validate:"omitempty,timezone" example:"Asia/Jakarta"`
+}
+
+// Update godoc
+// @Summary Update borrower profile
+// @Description Partially update the profile of a specific borrower. Omitted fields are left unchanged.
+// @Tags borrowers
+// @Accept json
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Param request body UpdateBorrowerReqBody true "Borrower fields to update"
+// @Success 200 {object} lib.Response{data=model.Borrower} "Successfully updated borrower"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 404 {object} lib.Response "Borrower not found"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /borrowers/{id} [patch]
+// @Security ApiKeyAuth
+func (h *BorrowerHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
+	var req UpdateBorrowerReqBody
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	profile := service.BorrowerProfile{
+		Name:       req.Name,
+		Phone:      req.Phone,
+		Email:      req.Email,
+		NationalID: req.NationalID,
+		Address:    req.Address,
+		Timezone:   req.Timezone,
+	}
+	if req.DateOfBirth != nil {
+		dob, _ := time.Parse(dateLayout, *req.DateOfBirth)
+		profile.DateOfBirth = &dob
+	}
+
+	borrower, err := h.borrowerSvc.Update(c.Request().Context(), id, profile)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(borrower, "borrower"))
+}
+
+// Deactivate godoc
+// @Summary Deactivate a borrower
+// @Description Admin only. Deactivate a borrower so they can no longer take new loans. Existing loans are not affected.
+// @Tags borrowers
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Success 200 {object} lib.Response{data=model.Borrower} "Successfully deactivated borrower"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 404 {object} lib.Response "Borrower not found"
+// @Failure 409 {object} lib.Response "Borrower is blacklisted or its status changed meanwhile"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /borrowers/{id}/deactivate [post]
+// @Security ApiKeyAuth
+func (h *BorrowerHandler) Deactivate(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
+	borrower, err := h.borrowerSvc.Deactivate(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(borrower, "borrower"))
+}
+
+// Blacklist godoc
+// @Summary Blacklist a borrower
+// @Description Admin only. Blacklist a borrower so they can never take new loans. Existing loans are not affected.
+// @Tags borrowers
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Success 200 {object} lib.Response{data=model.Borrower} "Successfully blacklisted borrower"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 404 {object} lib.Response "Borrower not found"
+// @Failure 409 {object} lib.Response "Borrower status changed meanwhile"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /borrowers/{id}/blacklist [post]
+// @Security ApiKeyAuth
+func (h *BorrowerHandler) Blacklist(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
+	borrower, err := h.borrowerSvc.Blacklist(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, lib.ResponseError(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, lib.ResponseSuccess(borrowers, "borrowers"))
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(borrower, "borrower"))
 }
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+)
+
+// HTTPErrorHandler maps domain errors to their HTTP status and renders every error as a lib.Response
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	res := lib.Response{
+		Status:  "error",
+		Code:    constant.ErrCodeInternal,
+		Message: "internal server error",
+	}
+
+	if e, ok := lib.AsError(err); ok {
+		status = e.HTTPStatus()
+		res = lib.ResponseError(e)
+	} else if he, ok := err.(*echo.HTTPError); ok {
+		status = he.Code
+		res.Code = strings.ToUpper(strings.ReplaceAll(http.StatusText(he.Code), " ", "_"))
+		res.Message = fmt.Sprint(he.Message)
+	}
+
+	if status == http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+
+	var writeErr error
+	if c.Request().Method == http.MethodHead {
+		writeErr = c.NoContent(status)
+	} else {
+		writeErr = c.JSON(status, res)
+	}
+	if writeErr != nil {
+		c.Logger().Error(writeErr)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// setLoanETag tags the response with the version of the loan, which changes whenever the loan does
+func setLoanETag(c echo.Context, l *model.Loan) {
+	c.Response().Header().Set(headerETag, strconv.Quote(strconv.FormatInt(l.Version, 10)))
+}
+
+// ifMatchVersion reads the loan version the client worked its change out from in the If-Match header. Without the
+// header, or with *, the change applies to any version and zero is returned.
+func ifMatchVersion(c echo.Context) (int64, error) {
+	tag := strings.TrimSpace(c.Request().Header.Get(headerIfMatch))
+	if tag == "" || tag == "*" {
+		return 0, nil
+	}
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, lib.NewValidationError(constant.ErrCodeInvalidRequest, "If-Match must be the ETag of the loan").Wrap(err)
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version < 1 {
+		return 0, lib.NewValidationError(constant.ErrCodeInvalidRequest, "If-Match must be the ETag of the loan")
+	}
+	return version, nil
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/service"
+)
+
+type HolidayHandler struct {
+	calendarSvc *service.CalendarService
+}
+
+func NewHolidayHandler(calendarSvc *service.CalendarService) *HolidayHandler {
+	return &HolidayHandler{calendarSvc: calendarSvc}
+}
+
+func (h *HolidayHandler) RegisterRoutes(g *echo.Group) {
+	rg := g.Group("/holidays", RequireAdmin)
+	rg.GET("", h.List)
+	rg.POST("", h.Save)
+	rg.DELETE("/:id", h.Delete)
+}
+
+type ListHolidaysQuery struct {
+	CountryCode string `query:"country_code" validate:"required,len=2,alpha"`
+	Year        int    `query:"year" validate:"required,min=1900,max=9999"`
+}
+
+// List godoc
+// @Summary List holidays
+// @Description Admin only. Get the holidays of a country in a calendar year, by date.
+// @Tags holidays
+// @Produce json
+// @Param country_code query string true "ISO 3166-1 alpha-2 country code"
+// @Param year query int true "Calendar year"
+// @Success 200 {object} lib.Response "Successfully retrieved holidays"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /holidays [get]
+// @Security ApiKeyAuth
+func (h *HolidayHandler) List(c echo.Context) error {
+	var req ListHolidaysQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	hs, err := h.calendarSvc.ListHolidays(c.Request().Context(), strings.ToUpper(req.CountryCode), req.Year)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(hs, "holidays"))
+}
+
+type HolidayReqBody struct {
+	CountryCode string `json:"country_code" validate:"required,len=2,alpha"`
+	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	Name        string `json:"name" validate:"required,max=100"`
+}
+
+type SaveHolidaysReqBody struct {
+	Holidays []HolidayReqBody `json:"holidays" validate:"required,min=1,max=1000,dive"`
+}
+
+// Save godoc
+// @Summary Save holidays
+// @Description Admin only. Add holidays in bulk, for example from a JSON file. A holiday already stored for the same country and date is renamed. Schedules of existing loans are not moved.
+// @Tags holidays
+// @Accept json
+// @Produce json
+// @Param request body SaveHolidaysReqBody true "Holidays to save"
+// @Success 200 {object} lib.Response "Successfully saved holidays"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /holidays [post]
+// @Security ApiKeyAuth
+func (h *HolidayHandler) Save(c echo.Context) error {
+	var req SaveHolidaysReqBody
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	hs := make([]*model.Holiday, len(req.Holidays))
+	for i, r := range req.Holidays {
+		date, _ := time.Parse(dateLayout, r.Date)
+		hs[i] = &model.Holiday{
+			CountryCode: strings.ToUpper(r.CountryCode),
+			Date:        date,
+			Name:        r.Name,
+		}
+	}
+	err := h.calendarSvc.SaveHolidays(c.Request().Context(), hs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(hs, "holidays"))
+}
+
+// Delete godoc
+// @Summary Delete a holiday
+// @Description Admin only. Remove a holiday from the calendar. Schedules of existing loans are not moved.
+// @Tags holidays
+// @Produce json
+// @Param id path string true "Holiday ID"
+// @Success 200 {object} lib.Response "Successfully deleted the holiday"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 404 {object} lib.Response "Holiday not found"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /holidays/{id} [delete]
+// @Security ApiKeyAuth
+func (h *HolidayHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid holiday ID")
+	}
+	err := h.calendarSvc.DeleteHoliday(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(nil))
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/service"
+)
+
+type JobHandler struct {
+	billingSvc *service.BillingService
+}
+
+func NewJobHandler(billingSvc *service.BillingService) *JobHandler {
+	return &JobHandler{billingSvc: billingSvc}
+}
+
+func (h *JobHandler) RegisterRoutes(g *echo.Group) {
+	rg := g.Group("/jobs", RequireAdmin)
+	rg.POST("/daily-billing/run", h.RunDailyBilling)
+	rg.GET("/runs", h.ListRuns)
+}
+
+// RunDailyBilling godoc
+// @Summary Run daily billing now
+// @Description Admin only. Run the daily billing job immediately and wait for it to finish: mark overdue installments, apply late fees, update days past due and emit due reminders. Every step is idempotent, so the job can be re-run on a day it already succeeded.
+// @Tags jobs
+// @Produce json
+// @Success 200 {object} lib.Response{data=model.JobRun} "Daily billing completed"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 409 {object} lib.Response "Daily billing is already running"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /jobs/daily-billing/run [post]
+// @Security ApiKeyAuth
+func (h *JobHandler) RunDailyBilling(c echo.Context) error {
+	// a client disconnecting should not abort the run halfway
+	ctx := context.WithoutCancel(c.Request().Context())
+	run, err := h.billingSvc.RunDailyBilling(ctx, constant.JobTriggerManual)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(run, "run"))
+}
+
+type ListJobRunsQuery struct {
+	JobName string `query:"job_name"`
+	Status  string `query:"status" validate:"omitempty,oneof=RUNNING SUCCEEDED FAILED"`
+	Cursor  string `query:"cursor"`
+	Limit   int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// ListRuns godoc
+// @Summary List job runs
+// @Description Admin only. Get a page of background job runs, newest first. Use next_cursor from the response to fetch the next page.
+// @Tags jobs
+// @Produce json
+// @Param job_name query string false "Job name" Enums(DAILY_BILLING)
+// @Param status query string false "Run status" Enums(RUNNING, SUCCEEDED, FAILED)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} lib.Response "Successfully retrieved job runs"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /jobs/runs [get]
+// @Security ApiKeyAuth
+func (h *JobHandler) ListRuns(c echo.Context) error {
+	var req ListJobRunsQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	runs, nextCursor, err := h.billingSvc.ListJobRuns(c.Request().Context(), service.JobRunListFilter{
+		JobName: req.JobName,
+		Status:  constant.JobRunStatus(req.Status),
+		Cursor:  req.Cursor,
+		Limit:   req.Limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponsePage(runs, nextCursor, "runs"))
+}
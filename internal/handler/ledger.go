@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/service"
+)
+
+type LedgerHandler struct {
+	ledgerSvc *service.LedgerService
+}
+
+func NewLedgerHandler(ledgerSvc *service.LedgerService) *LedgerHandler {
+	return &LedgerHandler{ledgerSvc: ledgerSvc}
+}
+
+func (h *LedgerHandler) RegisterRoutes(g *echo.Group) {
+	rg := g.Group("/ledger", RequireAdmin)
+	rg.GET("/trial-balance", h.TrialBalance)
+	rg.GET("/entries", h.ListEntries)
+	rg.POST("/entries/:id/reverse", h.ReverseEntry)
+}
+
+type TrialBalanceQuery struct {
+	AsOf string `query:"as_of" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// TrialBalance godoc
+// @Summary Get the trial balance
+// @Description Admin only. Sum the debits and credits of every ledger account, optionally up to and including a date. is_balanced is false when total debits differ from total credits or any journal entry is unbalanced.
+// @Tags ledger
+// @Produce json
+// @Param as_of query string false "Include entries posted on or before this date (YYYY-MM-DD)"
+// @Success 200 {object} lib.Response{data=model.TrialBalance} "Successfully retrieved trial balance"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /ledger/trial-balance [get]
+// @Security ApiKeyAuth
+func (h *LedgerHandler) TrialBalance(c echo.Context) error {
+	var req TrialBalanceQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	var asOf *time.Time
+	if req.AsOf != "" {
+		t, _ := time.Parse(dateLayout, req.AsOf)
+		asOf = &t
+	}
+
+	tb, err := h.ledgerSvc.GetTrialBalance(c.Request().Context(), asOf)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(tb, "trial_balance"))
+}
+
+type ListJournalEntriesQuery struct {
+	LoanID string `query:"loan_id"`
+	Type   string `query:"type" validate:"omitempty,oneof=DISBURSEMENT REPAYMENT INTEREST_ACCRUAL FEE_ACCRUAL REVERSAL WRITE_OFF WAIVER"`
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// ListEntries godoc
+// @Summary List journal entries
+// @Description Admin only. Get a page of journal entries with their lines in posting order. Use next_cursor from the response to fetch the next page.
+// @Tags ledger
+// @Produce json
+// @Param loan_id query string false "Loan ID"
+// @Param type query string false "Entry type" Enums(DISBURSEMENT, REPAYMENT, INTEREST_ACCRUAL, FEE_ACCRUAL, REVERSAL, WRITE_OFF, WAIVER)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} lib.Response "Successfully retrieved journal entries"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /ledger/entries [get]
+// @Security ApiKeyAuth
+func (h *LedgerHandler) ListEntries(c echo.Context) error {
+	var req ListJournalEntriesQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	entries, nextCursor, err := h.ledgerSvc.ListEntries(c.Request().Context(), service.JournalEntryListFilter{
+		LoanID: req.LoanID,
+		Type:   constant.JournalEntryType(req.Type),
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponsePage(entries, nextCursor, "entries"))
+}
+
+// ReverseEntry godoc
+// @Summary Reverse a journal entry
+// @Description Admin only. Post a new entry that mirrors the given one, cancelling its effect on every account, and undo what it changed: the installments it settled or charged a late fee on, the interest accrual it booked, or the installments of the loan it disbursed, which are cancelled. An entry can only be reversed once, and a disbursement only after every other entry of its loan.
+// @Tags ledger
+// @Produce json
+// @Param id path string true "Journal entry ID"
+// @Success 200 {object} lib.Response{data=model.JournalEntry} "Successfully reversed journal entry"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 404 {object} lib.Response "Journal entry not found"
+// @Failure 409 {object} lib.Response "Journal entry already reversed"
+// @Failure 422 {object} lib.Response "Entry is a reversal, a disbursement with unreversed entries, or what it changed has changed since"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /ledger/entries/{id}/reverse [post]
+// @Security ApiKeyAuth
+func (h *LedgerHandler) ReverseEntry(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid journal entry ID")
+	}
+	entry, err := h.ledgerSvc.ReverseEntry(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(entry, "entry"))
+}
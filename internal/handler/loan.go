@@ -2,8 +2,10 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
 	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
 	"github.com/ramabmtr/billing-engine/internal/service"
@@ -19,6 +21,10 @@ func NewLoanHandler(loanSvc *service.LoanService) *LoanHandler {
 }
 
 func (h *LoanHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/loans", h.Search, RequireAdmin)
+	g.POST("/loans/simulate", h.Simulate)
+	g.POST("/loans/:id/write-off", h.WriteOff, RequireAdmin)
+
 	rg := g.Group("/borrowers/:borrowerID/loans")
 	rg.POST("", h.CreateLoanRequest)
 	rg.GET("", h.List)
@@ -32,43 +38,181 @@ func (h *LoanHandler) RegisterRoutes(g *echo.Group) {
 // @Produce json
 // @Param borrowerID path string true "Borrower ID"
 // @Success 200 {object} lib.Response "Successfully created loan request"
+// @Header 200 {string} ETag "Version of the loan, for the If-Match header of later changes"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 404 {object} lib.Response "Borrower not found"
+// @Failure 409 {object} lib.Response "Borrower has an outstanding loan"
+// @Failure 422 {object} lib.Response "Borrower is not active"
 // @Failure 500 {object} lib.Response "Internal server error"
 // @Router /borrowers/{borrowerID}/loans [post]
 // @Security ApiKeyAuth
 func (h *LoanHandler) CreateLoanRequest(c echo.Context) error {
 	borrowerID := c.Param("borrowerID")
 	if borrowerID == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid borrower ID")
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
 	}
-	loan, err := h.loanSvc.CreateLoanRequest(borrowerID)
+	loan, err := h.loanSvc.CreateLoanRequest(c.Request().Context(), borrowerID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, lib.ResponseError(err))
+		return err
 	}
 
+	setLoanETag(c, loan)
 	return c.JSON(http.StatusOK, lib.ResponseSuccess(loan, "loan"))
 }
 
+type SimulateLoanReqBody struct {
+	Currency           string    `json:"currency" validate:"omitempty,iso4217" example:"IDR"`
+	Principal          lib.Money `json:"principal" validate:"required,gt=0,max=1000000000000" example:"5000000" swaggertype:"number"`
+	AnnualInterestRate float64   `json:"annual_interest_rate" validate:"min=0,max=100"`
+	Period             int       `json:"period" validate:"required,min=1,max=520"`
+	PeriodUnit         string    `json:"period_unit" validate:"required,oneof=WEEK MONTH"`
+	InterestMethod     string    `json:"interest_method" validate:"omitempty,oneof=FLAT ANNUITY"`
+	Timezone           string    `json:"timezone" validate:"omitempty,timezone" example:"Asia/Jakarta"`
+}
+
+// Simulate godoc
+// @Summary Preview a repayment schedule
+// @Description Calculate the origination fee, net disbursement, total repayment, installment schedule with due dates and principal/interest split, APR and effective interest rate of a loan with the given terms, as if it were disbursed today. The loan is made in the given currency and due dates are local calendar dates in the given timezone, or the product defaults. The principal is the amount requested; a financed fee is added to it. Nothing is stored.
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Param request body SimulateLoanReqBody true "Loan terms; interest_method defaults to FLAT"
+// @Success 200 {object} lib.Response{data=model.LoanSimulation} "Successfully simulated the loan"
+// @Failure 400 {object} lib.Response "Invalid request or the origination fee exceeds the principal"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /loans/simulate [post]
+// @Security ApiKeyAuth
+func (h *LoanHandler) Simulate(c echo.Context) error {
+	var req SimulateLoanReqBody
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	simulation, err := h.loanSvc.SimulateLoan(c.Request().Context(), model.Loan{
+		Currency:           req.Currency,
+		Principal:          req.Principal.In(req.Currency),
+		AnnualInterestRate: decimal.NewFromFloat(req.AnnualInterestRate),
+		InterestMethod:     constant.InterestMethod(req.InterestMethod),
+		Period:             req.Period,
+		PeriodUnit:         constant.LoanPeriodUnit(req.PeriodUnit),
+		Timezone:           req.Timezone,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(simulation))
+}
+
+type ListLoansQuery struct {
+	Status      string `query:"status" validate:"omitempty,oneof=ACTIVE COMPLETED"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,datetime=2006-01-02"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,datetime=2006-01-02"`
+	Sort        string `query:"sort" validate:"omitempty,oneof=created_at -created_at"`
+	Cursor      string `query:"cursor"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+func (q ListLoansQuery) toFilter() service.LoanListFilter {
+	f := service.LoanListFilter{
+		Status: constant.LoanStatus(q.Status),
+		Desc:   q.Sort == "-created_at",
+		Cursor: q.Cursor,
+		Limit:  q.Limit,
+	}
+	if q.CreatedFrom != "" {
+		from, _ := time.Parse(dateLayout, q.CreatedFrom)
+		f.CreatedFrom = &from
+	}
+	if q.CreatedTo != "" {
+		to, _ := time.Parse(dateLayout, q.CreatedTo)
+		to = to.AddDate(0, 0, 1)
+		f.CreatedTo = &to
+	}
+	return f
+}
+
 // List godoc
 // @Summary List loans for a borrower
-// @Description Get a list of all loans for a specific borrower
+// @Description Get a page of loans for a specific borrower. Use next_cursor from the response to fetch the next page.
 // @Tags loans
 // @Produce json
 // @Param borrowerID path string true "Borrower ID"
+// @Param status query string false "Loan status" Enums(ACTIVE, COMPLETED)
+// @Param created_from query string false "Created on or after this date (YYYY-MM-DD)"
+// @Param created_to query string false "Created on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "Sort order" Enums(created_at, -created_at)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
 // @Success 200 {object} lib.Response "Successfully retrieved loans list"
+// @Failure 400 {object} lib.Response "Invalid request"
 // @Failure 500 {object} lib.Response "Internal server error"
 // @Router /borrowers/{borrowerID}/loans [get]
 // @Security ApiKeyAuth
 func (h *LoanHandler) List(c echo.Context) error {
 	borrowerID := c.Param("borrowerID")
 	if borrowerID == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid borrower ID")
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
+	var req ListLoansQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	loans, nextCursor, err := h.loanSvc.GetLoansByBorrowerID(c.Request().Context(), borrowerID, req.toFilter())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponsePage(loans, nextCursor, "loans"))
+}
+
+type SearchLoansQuery struct {
+	ListLoansQuery
+	BorrowerID string `query:"borrower_id"`
+}
+
+// Search godoc
+// @Summary Search loans across borrowers
+// @Description Admin only. Get a page of loans across all borrowers, filtered by status, borrower or creation date.
+// @Tags loans
+// @Produce json
+// @Param borrower_id query string false "Borrower ID"
+// @Param status query string false "Loan status" Enums(ACTIVE, COMPLETED)
+// @Param created_from query string false "Created on or after this date (YYYY-MM-DD)"
+// @Param created_to query string false "Created on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "Sort order" Enums(created_at, -created_at)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} lib.Response "Successfully retrieved loans list"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /loans [get]
+// @Security ApiKeyAuth
+func (h *LoanHandler) Search(c echo.Context) error {
+	var req SearchLoansQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
 	}
-	loans, err := h.loanSvc.GetLoansByBorrowerID(borrowerID)
+
+	f := req.toFilter()
+	f.BorrowerID = req.BorrowerID
+	loans, nextCursor, err := h.loanSvc.SearchLoans(c.Request().Context(), f)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, lib.ResponseError(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, lib.ResponseSuccess(loans, "loans"))
+	return c.JSON(http.StatusOK, lib.ResponsePage(loans, nextCursor, "loans"))
 }
 
 type GetLoanRes struct {
@@ -84,21 +228,64 @@ type GetLoanRes struct {
 // @Param borrowerID path string true "Borrower ID"
 // @Param id path string true "Loan ID"
 // @Success 200 {object} lib.Response{data=GetLoanRes} "Successfully retrieved loan details"
+// @Header 200 {string} ETag "Version of the loan, for the If-Match header of later changes"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 404 {object} lib.Response "Loan not found"
 // @Failure 500 {object} lib.Response "Internal server error"
 // @Router /borrowers/{borrowerID}/loans/{id} [get]
 // @Security ApiKeyAuth
 func (h *LoanHandler) Detail(c echo.Context) error {
+	borrowerID := c.Param("borrowerID")
+	if borrowerID == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
 	id := c.Param("id")
 	if id == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid loan ID")
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid loan ID")
 	}
-	loan, outstanding, err := h.loanSvc.GetLoanDetail(id)
+	loan, outstanding, err := h.loanSvc.GetLoanDetail(c.Request().Context(), borrowerID, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, lib.ResponseError(err))
+		return err
 	}
 
+	setLoanETag(c, loan)
 	return c.JSON(http.StatusOK, lib.ResponseSuccess(GetLoanRes{
 		OutstandingAmount: outstanding,
 		Loan:              loan,
 	}, "loan_detail"))
 }
+
+// WriteOff godoc
+// @Summary Write off a loan
+// @Description Admin only. Charge the remaining loan receivable to loan loss expense. Payments on the loan are refused until the write-off entry is reversed. With If-Match the loan is only written off while it is still at that version.
+// @Tags loans
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Param If-Match header string false "ETag of the loan the write-off was decided on"
+// @Success 200 {object} lib.Response{data=model.JournalEntry} "Successfully wrote off loan"
+// @Header 200 {string} ETag "New version of the loan"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 404 {object} lib.Response "Loan not found"
+// @Failure 409 {object} lib.Response "Loan already written off or changed since the ETag in If-Match"
+// @Failure 422 {object} lib.Response "Nothing left to write off"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /loans/{id}/write-off [post]
+// @Security ApiKeyAuth
+func (h *LoanHandler) WriteOff(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid loan ID")
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	entry, loan, err := h.loanSvc.WriteOffLoan(c.Request().Context(), id, version)
+	if err != nil {
+		return err
+	}
+
+	setLoanETag(c, loan)
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(entry, "entry"))
+}
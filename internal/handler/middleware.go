@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+)
+
+const contextKeyIsAdmin = "is_admin"
+
+// MarkAdmin flags the request as authenticated with the admin API key
+func MarkAdmin(c echo.Context) {
+	c.Set(contextKeyIsAdmin, true)
+}
+
+func IsAdmin(c echo.Context) bool {
+	isAdmin, _ := c.Get(contextKeyIsAdmin).(bool)
+	return isAdmin
+}
+
+// RequireAdmin rejects requests that were not authenticated with the admin API key
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !IsAdmin(c) {
+			return echo.NewHTTPError(http.StatusForbidden, "admin access required")
+		}
+		return next(c)
+	}
+}
+
+// AsOf lets admins preview a read-only view at another date with the as_of query parameter (YYYY-MM-DD, start of day UTC).
+// Other callers may not use it.
+func AsOf(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		asOfParam := c.QueryParam("as_of")
+		if asOfParam == "" {
+			return next(c)
+		}
+		if !IsAdmin(c) {
+			return echo.NewHTTPError(http.StatusForbidden, "as_of requires admin access")
+		}
+		asOf, err := time.Parse(dateLayout, asOfParam)
+		if err != nil {
+			return lib.NewValidationError(constant.ErrCodeInvalidRequest, "as_of must be a date in YYYY-MM-DD format").Wrap(err)
+		}
+
+		c.SetRequest(c.Request().WithContext(lib.WithAsOf(c.Request().Context(), asOf)))
+		return next(c)
+	}
+}
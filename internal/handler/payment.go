@@ -2,11 +2,12 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
 	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/service"
-	"github.com/shopspring/decimal"
 )
 
 type PaymentHandler struct {
@@ -18,68 +19,171 @@ func NewPaymentHandler(loanSvc *service.LoanService) *PaymentHandler {
 }
 
 func (h *PaymentHandler) RegisterRoutes(g *echo.Group) {
+	g.POST("/loans/:loanID/payments/:id/waive", h.Waive, RequireAdmin)
+
 	rg := g.Group("/borrowers/:borrowerID/loans/:loanID/payments")
 	rg.POST("", h.MakePayment)
 	rg.GET("", h.List)
 }
 
 type MakePaymentReqBody struct {
-	Amount float64 `json:"amount" validate:"required"`
+	Amount   lib.Money `json:"amount" validate:"required" example:"110000" swaggertype:"number"`
+	Currency string    `json:"currency" validate:"omitempty,iso4217" example:"IDR"`
 }
 
 // MakePayment godoc
 // @Summary Make a payment for a loan
-// @Description Process a payment for a specific loan
+// @Description Process a payment for a specific loan. The payment must be in the currency of the loan, which is assumed when currency is omitted. With If-Match the payment is only made while the loan is still at that version.
 // @Tags payments
 // @Accept json
 // @Produce json
 // @Param borrowerID path string true "Borrower ID"
 // @Param loanID path string true "Loan ID"
+// @Param If-Match header string false "ETag of the loan the payment was worked out from"
 // @Param request body MakePaymentReqBody true "Payment information"
 // @Success 200 {object} lib.Response "Successfully processed payment"
+// @Header 200 {string} ETag "New version of the loan"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 404 {object} lib.Response "Loan not found"
+// @Failure 409 {object} lib.Response "Loan changed since the ETag in If-Match or while the payment was made"
+// @Failure 422 {object} lib.Response "Payment violates the repayment plan, is in another currency or the loan is written off"
 // @Failure 500 {object} lib.Response "Internal server error"
 // @Router /borrowers/{borrowerID}/loans/{loanID}/payments [post]
 // @Security ApiKeyAuth
 func (h *PaymentHandler) MakePayment(c echo.Context) error {
+	borrowerID := c.Param("borrowerID")
+	if borrowerID == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
 	loanID := c.Param("loanID")
 	if loanID == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid loan ID")
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid loan ID")
 	}
 	var req MakePaymentReqBody
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid request payload")
 	}
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
-	err := h.loanSvc.MakePayment(loanID, decimal.NewFromFloat(req.Amount))
+	loan, err := h.loanSvc.MakePayment(c.Request().Context(), borrowerID, loanID, req.Amount.In(req.Currency), version)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, lib.ResponseError(err))
+		return err
 	}
 
+	setLoanETag(c, loan)
 	return c.JSON(http.StatusOK, lib.ResponseSuccess(nil))
 }
 
+type ListPaymentsQuery struct {
+	Status      string `query:"status" validate:"omitempty,oneof=PAID UNPAID OVERDUE WAIVED CANCELLED"`
+	DueFrom     string `query:"due_from" validate:"omitempty,datetime=2006-01-02"`
+	DueTo       string `query:"due_to" validate:"omitempty,datetime=2006-01-02"`
+	OverdueOnly bool   `query:"overdue_only"`
+	Cursor      string `query:"cursor"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
 // List godoc
 // @Summary List payments for a loan
-// @Description Get a list of all payments for a specific loan
+// @Description Get a page of the payment schedule of a specific loan in due date order. Use next_cursor from the response to fetch the next page.
 // @Tags payments
 // @Produce json
 // @Param borrowerID path string true "Borrower ID"
 // @Param loanID path string true "Loan ID"
+// @Param status query string false "Payment status" Enums(PAID, UNPAID, OVERDUE, WAIVED, CANCELLED)
+// @Param due_from query string false "Due on or after this date (YYYY-MM-DD)"
+// @Param due_to query string false "Due on or before this date (YYYY-MM-DD)"
+// @Param overdue_only query bool false "Only payments in OVERDUE status"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
 // @Success 200 {object} lib.Response "Successfully retrieved payments list"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 404 {object} lib.Response "Loan not found"
 // @Failure 500 {object} lib.Response "Internal server error"
 // @Router /borrowers/{borrowerID}/loans/{loanID}/payments [get]
 // @Security ApiKeyAuth
 func (h *PaymentHandler) List(c echo.Context) error {
+	borrowerID := c.Param("borrowerID")
+	if borrowerID == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid borrower ID")
+	}
+	loanID := c.Param("loanID")
+	if loanID == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid loan ID")
+	}
+	var req ListPaymentsQuery
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid query parameters")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	f := service.LoanPaymentListFilter{
+		Status:      constant.LoanPaymentStatus(req.Status),
+		OverdueOnly: req.OverdueOnly,
+		Cursor:      req.Cursor,
+		Limit:       req.Limit,
+	}
+	if req.DueFrom != "" {
+		from, _ := time.Parse(dateLayout, req.DueFrom)
+		f.DueFrom = &from
+	}
+	if req.DueTo != "" {
+		to, _ := time.Parse(dateLayout, req.DueTo)
+		to = to.AddDate(0, 0, 1)
+		f.DueTo = &to
+	}
+
+	payments, nextCursor, err := h.loanSvc.GetLoanPaymentsByLoanID(c.Request().Context(), borrowerID, loanID, f)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponsePage(payments, nextCursor, "payments"))
+}
+
+// Waive godoc
+// @Summary Waive an installment
+// @Description Admin only. Forgive the oldest outstanding installment of a loan, late fee included. The waived amount is charged to loan loss expense. With If-Match the installment is only waived while the loan is still at that version.
+// @Tags payments
+// @Produce json
+// @Param loanID path string true "Loan ID"
+// @Param id path string true "Loan payment ID"
+// @Param If-Match header string false "ETag of the loan the waiver was decided on"
+// @Success 200 {object} lib.Response{data=model.LoanPayment} "Successfully waived installment"
+// @Header 200 {string} ETag "New version of the loan"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 404 {object} lib.Response "Loan or loan payment not found"
+// @Failure 409 {object} lib.Response "Loan changed since the ETag in If-Match or while the installment was waived"
+// @Failure 422 {object} lib.Response "Installment is not outstanding, not the oldest one, or the loan is written off"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /loans/{loanID}/payments/{id}/waive [post]
+// @Security ApiKeyAuth
+func (h *PaymentHandler) Waive(c echo.Context) error {
 	loanID := c.Param("loanID")
 	if loanID == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid loan ID")
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid loan ID")
+	}
+	id := c.Param("id")
+	if id == "" {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid loan payment ID")
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
-	payments, err := h.loanSvc.GetLoanPaymentsByLoanID(loanID)
+	payment, loan, err := h.loanSvc.WaiveInstallment(c.Request().Context(), loanID, id, version)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, lib.ResponseError(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, lib.ResponseSuccess(payments, "payments"))
+	setLoanETag(c, loan)
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(payment, "payment"))
 }
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/service"
+)
+
+type SimulationHandler struct {
+	simulationSvc *service.SimulationService
+}
+
+func NewSimulationHandler(simulationSvc *service.SimulationService) *SimulationHandler {
+	return &SimulationHandler{simulationSvc: simulationSvc}
+}
+
+func (h *SimulationHandler) RegisterRoutes(g *echo.Group) {
+	rg := g.Group("/simulation", RequireAdmin)
+	rg.GET("/clock", h.Clock)
+	rg.POST("/advance", h.Advance)
+}
+
+// Clock godoc
+// @Summary Get the simulated date
+// @Description Admin only, available when simulation mode is enabled. Get the current time of the simulated clock.
+// @Tags simulation
+// @Produce json
+// @Success 200 {object} lib.Response "Successfully retrieved the simulated time"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Router /simulation/clock [get]
+// @Security ApiKeyAuth
+func (h *SimulationHandler) Clock(c echo.Context) error {
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(h.simulationSvc.Now(), "now"))
+}
+
+type AdvanceSimulationReqBody struct {
+	Days int `json:"days" validate:"required,min=1,max=730"`
+}
+
+// Advance godoc
+// @Summary Advance the simulated date
+// @Description Admin only, available when simulation mode is enabled. Move the simulated clock forward and run daily billing for every day passed, as the worker would have.
+// @Tags simulation
+// @Accept json
+// @Produce json
+// @Param request body AdvanceSimulationReqBody true "Number of days to advance"
+// @Success 200 {object} lib.Response{data=service.SimulationAdvanceResult} "Clock advanced"
+// @Failure 400 {object} lib.Response "Invalid request"
+// @Failure 403 {object} lib.Response "Admin access required"
+// @Failure 409 {object} lib.Response "Daily billing is already running"
+// @Failure 500 {object} lib.Response "Internal server error"
+// @Router /simulation/advance [post]
+// @Security ApiKeyAuth
+func (h *SimulationHandler) Advance(c echo.Context) error {
+	var req AdvanceSimulationReqBody
+	if err := c.Bind(&req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "%s", err.Error()).Wrap(err)
+	}
+
+	// a client disconnecting should not leave the clock halfway
+	ctx := context.WithoutCancel(c.Request().Context())
+	result, err := h.simulationSvc.Advance(ctx, req.Days)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lib.ResponseSuccess(result))
+}
@@ -0,0 +1,37 @@
+package lib
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const day = 24 * time.Hour
+
+// TruncateToDate drops the time of day, keeping the UTC calendar date
+func TruncateToDate(t time.Time) time.Time {
+	return t.UTC().Truncate(day)
+}
+
+// CalculateDailyInterest returns the interest earned on the given date. The interest of each installment
+// is earned evenly over its period, from the previous due date (or the loan start for the first one) up to
+// the day before its own due date. Each day's amount is the difference of the rounded running totals, so the
+// days of a period add up to the installment interest exactly. Dates outside the loan term earn nothing.
+func CalculateDailyInterest(start time.Time, dueDates []time.Time, installments []Installment, date time.Time) Money {
+	date = TruncateToDate(date)
+	from := TruncateToDate(start)
+	for i := 0; i < len(dueDates) && i < len(installments); i++ {
+		to := TruncateToDate(dueDates[i])
+		if !date.Before(from) && date.Before(to) {
+			days := decimal.NewFromInt(int64(to.Sub(from) / day))
+			elapsed := decimal.NewFromInt(int64(date.Sub(from) / day))
+			interest := installments[i].Interest
+			earned := interest.Mul(elapsed.Add(decimal.NewFromInt(1))).Div(days).RoundTo(4)
+			return earned.Sub(interest.Mul(elapsed).Div(days).RoundTo(4))
+		}
+		if to.After(from) {
+			from = to
+		}
+	}
+	return ZeroMoney("")
+}
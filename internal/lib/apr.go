@@ -0,0 +1,63 @@
+package lib
+
+import (
+	"math"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/shopspring/decimal"
+)
+
+// CalculatePeriodicRate returns the rate per period at which the installments, paid at the end of each period, are
+// worth amount at the start: amount = sum(installment_i / (1 + rate)^i). It is the internal rate of return of the
+// loan cashflows, found by bisection since there is no closed form.
+func CalculatePeriodicRate(amount decimal.Decimal, installments []decimal.Decimal) float64 {
+	pv := amount.InexactFloat64()
+	cashflows := make([]float64, len(installments))
+	for i, installment := range installments {
+		cashflows[i] = installment.InexactFloat64()
+	}
+	if pv <= 0 || len(cashflows) == 0 {
+		return 0
+	}
+
+	// the present value of the installments falls as the rate rises, so the root is bracketed by lo and hi
+	npv := func(rate float64) float64 {
+		v := 0.0
+		for i, cf := range cashflows {
+			v += cf / math.Pow(1+rate, float64(i+1))
+		}
+		return v - pv
+	}
+	lo, hi := -0.99, 10.0
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if npv(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// CalculateAPR returns the annual percentage rate and the effective interest rate of a loan of principal repaid by
+// the installments, one per period. Upfront fees are taken out of the amount the borrower actually receives, so they
+// raise both rates. The APR is the periodic rate times the periods in a year and the effective rate compounds it over
+// a year. Both are percentages rounded to 2 places.
+func CalculateAPR(
+	principal Money,
+	upfrontFees Money,
+	installments []Money,
+	periodUnit constant.LoanPeriodUnit,
+) (apr, eir decimal.Decimal) {
+	amounts := make([]decimal.Decimal, len(installments))
+	for i, installment := range installments {
+		amounts[i] = installment.Amount
+	}
+	rate := CalculatePeriodicRate(principal.Sub(upfrontFees).Amount, amounts)
+	periodsPerYear := periodToYears[periodUnit].InexactFloat64()
+
+	apr = decimal.NewFromFloat(rate * periodsPerYear * 100).Round(2)
+	eir = decimal.NewFromFloat((math.Pow(1+rate, periodsPerYear) - 1) * 100).Round(2)
+	return apr, eir
+}
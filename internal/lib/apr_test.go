@@ -0,0 +1,90 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateAPR(t *testing.T) {
+	tests := []struct {
+		name               string
+		principal          decimal.Decimal
+		annualInterestRate decimal.Decimal
+		period             int
+		periodUnit         constant.LoanPeriodUnit
+		method             constant.InterestMethod
+		upfrontFees        decimal.Decimal
+		expectedAPR        decimal.Decimal
+		expectedEIR        decimal.Decimal
+	}{
+		{
+			name:               "Annuity APR Equals The Nominal Rate",
+			principal:          decimal.NewFromInt(1_000_000),
+			annualInterestRate: decimal.NewFromInt(12),
+			period:             12,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodAnnuity,
+			expectedAPR:        decimal.NewFromInt(12),
+			expectedEIR:        decimal.RequireFromString("12.68"),
+		},
+		{
+			name:               "Flat Monthly",
+			principal:          decimal.NewFromInt(1_000_000),
+			annualInterestRate: decimal.NewFromInt(10),
+			period:             12,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodFlat,
+			expectedAPR:        decimal.RequireFromString("17.97"),
+			expectedEIR:        decimal.RequireFromString("19.53"),
+		},
+		{
+			name:               "Flat Weekly",
+			principal:          decimal.NewFromInt(5_000_000),
+			annualInterestRate: decimal.NewFromInt(10),
+			period:             50,
+			periodUnit:         constant.PeriodUnitWeek,
+			method:             constant.InterestMethodFlat,
+			expectedAPR:        decimal.RequireFromString("19.04"),
+			expectedEIR:        decimal.RequireFromString("20.93"),
+		},
+		{
+			name:               "Upfront Fees Raise The Rates",
+			principal:          decimal.NewFromInt(1_000_000),
+			annualInterestRate: decimal.NewFromInt(12),
+			period:             12,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodAnnuity,
+			upfrontFees:        decimal.NewFromInt(20_000),
+			expectedAPR:        decimal.RequireFromString("15.85"),
+			expectedEIR:        decimal.RequireFromString("17.06"),
+		},
+		{
+			name:               "Zero Interest Rate",
+			principal:          decimal.NewFromInt(1_000_000),
+			annualInterestRate: decimal.Zero,
+			period:             12,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodFlat,
+			expectedAPR:        decimal.Zero,
+			expectedEIR:        decimal.Zero,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := NewMoney(tt.principal, DefaultCurrency)
+			installments := CalculateInstallments(principal, tt.annualInterestRate, tt.period, tt.periodUnit, tt.method)
+			amounts := make([]Money, len(installments))
+			for i, installment := range installments {
+				amounts[i] = installment.Amount()
+			}
+
+			apr, eir := CalculateAPR(principal, NewMoney(tt.upfrontFees, DefaultCurrency), amounts, tt.periodUnit)
+			assert.True(t, tt.expectedAPR.Equal(apr), apr.String())
+			assert.True(t, tt.expectedEIR.Equal(eir), eir.String())
+		})
+	}
+}
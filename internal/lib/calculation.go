@@ -11,11 +11,11 @@ var periodToYears = map[constant.LoanPeriodUnit]decimal.Decimal{
 }
 
 func CalculateTotalRepayment(
-	principal decimal.Decimal,
+	principal Money,
 	annualInterestRate decimal.Decimal,
 	period int,
 	periodUnit constant.LoanPeriodUnit,
-) decimal.Decimal {
+) Money {
 	// Convert period to years
 	py := periodToYears[periodUnit]
 	years := decimal.NewFromInt(int64(period)).Div(py)
@@ -25,3 +25,99 @@ func CalculateTotalRepayment(
 
 	return principal.Add(interest)
 }
+
+// Installment is the principal and interest due on one installment of a loan
+type Installment struct {
+	Principal Money
+	Interest  Money
+}
+
+func (i Installment) Amount() Money {
+	return i.Principal.Add(i.Interest)
+}
+
+// CalculateInstallments splits a loan into its installments according to the interest method.
+//
+// FLAT charges interest on the original principal for the whole term, so every installment carries
+// an equal share of the principal and of the total interest, the total interest being rounded to the minor
+// units of the principal's currency like the loan's total repayment. ANNUITY charges interest on the outstanding principal each period
+// and keeps the installment amount constant, so the interest part declines as the principal is repaid.
+// Amounts are rounded to the minor units of the principal's currency and the final installment absorbs the rounding
+// difference, so every installment can be paid exactly.
+func CalculateInstallments(
+	principal Money,
+	annualInterestRate decimal.Decimal,
+	period int,
+	periodUnit constant.LoanPeriodUnit,
+	method constant.InterestMethod,
+) []Installment {
+	if period <= 0 {
+		return []Installment{}
+	}
+	if method == constant.InterestMethodAnnuity && annualInterestRate.IsPositive() {
+		return calculateAnnuityInstallments(principal, annualInterestRate, period, periodUnit)
+	}
+
+	interest := CalculateTotalRepayment(principal, annualInterestRate, period, periodUnit).Round().Sub(principal)
+	principalShares := splitEvenly(principal, period)
+	interestShares := splitEvenly(interest, period)
+
+	installments := make([]Installment, period)
+	for i := range installments {
+		installments[i] = Installment{
+			Principal: principalShares[i],
+			Interest:  interestShares[i],
+		}
+	}
+	return installments
+}
+
+func calculateAnnuityInstallments(
+	principal Money,
+	annualInterestRate decimal.Decimal,
+	period int,
+	periodUnit constant.LoanPeriodUnit,
+) []Installment {
+	one := decimal.NewFromInt(1)
+	rate := annualInterestRate.Div(decimal.NewFromInt(100)).Div(periodToYears[periodUnit])
+	// amount = principal * rate / (1 - (1 + rate)^-period)
+	discount := one.Sub(one.Div(one.Add(rate).Pow(decimal.NewFromInt(int64(period)))))
+	amount := principal.Mul(rate).Div(discount).Round()
+
+	installments := make([]Installment, period)
+	balance := principal
+	for i := range installments {
+		interest := balance.Mul(rate).Round()
+		p := amount.Sub(interest)
+		if i == period-1 {
+			p = balance
+		}
+		installments[i] = Installment{
+			Principal: p,
+			Interest:  interest,
+		}
+		balance = balance.Sub(p)
+	}
+	return installments
+}
+
+// splitEvenly divides total into n shares rounded to the minor units of its currency, the last share taking the
+// remainder
+func splitEvenly(total Money, n int) []Money {
+	share := total.Div(decimal.NewFromInt(int64(n))).Round()
+	shares := make([]Money, n)
+	for i := 0; i < n-1; i++ {
+		shares[i] = share
+	}
+	shares[n-1] = total.Sub(share.Mul(decimal.NewFromInt(int64(n - 1))))
+	return shares
+}
+
+// SumInstallments returns the total repayment of the installments
+func SumInstallments(installments []Installment) Money {
+	total := ZeroMoney("")
+	for _, i := range installments {
+		total = total.Add(i.Amount())
+	}
+	return total
+}
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ramabmtr/billing-engine/internal/constant"
 	"github.com/shopspring/decimal"
@@ -70,13 +71,177 @@ func TestCalculateTotalRepayment(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result := CalculateTotalRepayment(
-				tt.principal,
+				NewMoney(tt.principal, DefaultCurrency),
 				tt.annualInterestRate,
 				tt.period,
 				tt.periodUnit,
 			)
-			assert.True(t, tt.expected.Equal(result),
+			assert.True(t, tt.expected.Equal(result.Amount),
 				"Expected %s but got %s", tt.expected.String(), result.String())
 		})
 	}
 }
+
+func TestCalculateInstallments(t *testing.T) {
+	tests := []struct {
+		name               string
+		principal          decimal.Decimal
+		annualInterestRate decimal.Decimal
+		period             int
+		periodUnit         constant.LoanPeriodUnit
+		method             constant.InterestMethod
+		currency           string
+		expectedFirst      Installment
+		expectedLast       Installment
+		expectedTotal      decimal.Decimal
+	}{
+		{
+			name:               "Flat - Equal Installments",
+			principal:          decimal.NewFromInt(1_040_000),
+			annualInterestRate: decimal.NewFromInt(10),
+			period:             52,
+			periodUnit:         constant.PeriodUnitWeek,
+			method:             constant.InterestMethodFlat,
+			expectedFirst:      Installment{Principal: Money{Amount: decimal.NewFromInt(20_000)}, Interest: Money{Amount: decimal.NewFromInt(2_000)}},
+			expectedLast:       Installment{Principal: Money{Amount: decimal.NewFromInt(20_000)}, Interest: Money{Amount: decimal.NewFromInt(2_000)}},
+			expectedTotal:      decimal.NewFromInt(1_144_000),
+		},
+		{
+			name:               "Flat - Last Installment Absorbs Rounding",
+			principal:          decimal.NewFromInt(1_000_000),
+			annualInterestRate: decimal.NewFromInt(12),
+			period:             3,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodFlat,
+			expectedFirst:      Installment{Principal: Money{Amount: decimal.NewFromInt(333_333)}, Interest: Money{Amount: decimal.NewFromInt(10_000)}},
+			expectedLast:       Installment{Principal: Money{Amount: decimal.NewFromInt(333_334)}, Interest: Money{Amount: decimal.NewFromInt(10_000)}},
+			expectedTotal:      decimal.NewFromInt(1_030_000),
+		},
+		{
+			name:               "Flat - Interest Rounded To Currency Minor Units",
+			principal:          decimal.NewFromInt(1_000),
+			annualInterestRate: decimal.RequireFromString("10.33"),
+			period:             3,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodFlat,
+			currency:           "USD",
+			expectedFirst:      Installment{Principal: Money{Amount: decimal.RequireFromString("333.33")}, Interest: Money{Amount: decimal.RequireFromString("8.61")}},
+			expectedLast:       Installment{Principal: Money{Amount: decimal.RequireFromString("333.34")}, Interest: Money{Amount: decimal.RequireFromString("8.61")}},
+			expectedTotal:      decimal.RequireFromString("1025.83"),
+		},
+		{
+			name:               "Annuity - Declining Interest",
+			principal:          decimal.NewFromInt(1_000_000),
+			annualInterestRate: decimal.NewFromInt(12),
+			period:             3,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodAnnuity,
+			expectedFirst:      Installment{Principal: Money{Amount: decimal.NewFromInt(330_022)}, Interest: Money{Amount: decimal.NewFromInt(10_000)}},
+			expectedLast:       Installment{Principal: Money{Amount: decimal.NewFromInt(336_656)}, Interest: Money{Amount: decimal.NewFromInt(3_367)}},
+			expectedTotal:      decimal.NewFromInt(1_020_067),
+		},
+		{
+			name:               "Annuity - Zero Interest Rate",
+			principal:          decimal.NewFromInt(1_000_000),
+			annualInterestRate: decimal.NewFromInt(0),
+			period:             4,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodAnnuity,
+			expectedFirst:      Installment{Principal: Money{Amount: decimal.NewFromInt(250_000)}, Interest: Money{Amount: decimal.Zero}},
+			expectedLast:       Installment{Principal: Money{Amount: decimal.NewFromInt(250_000)}, Interest: Money{Amount: decimal.Zero}},
+			expectedTotal:      decimal.NewFromInt(1_000_000),
+		},
+		{
+			name:               "Annuity - Rounded To Currency Minor Units",
+			principal:          decimal.NewFromInt(1_000),
+			annualInterestRate: decimal.NewFromInt(12),
+			period:             3,
+			periodUnit:         constant.PeriodUnitMonth,
+			method:             constant.InterestMethodAnnuity,
+			currency:           "USD",
+			expectedFirst:      Installment{Principal: Money{Amount: decimal.RequireFromString("330.02")}, Interest: Money{Amount: decimal.NewFromInt(10)}},
+			expectedLast:       Installment{Principal: Money{Amount: decimal.RequireFromString("336.66")}, Interest: Money{Amount: decimal.RequireFromString("3.37")}},
+			expectedTotal:      decimal.RequireFromString("1020.07"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currency := DefaultCurrency
+			if tt.currency != "" {
+				currency = tt.currency
+			}
+			result := CalculateInstallments(NewMoney(tt.principal, currency), tt.annualInterestRate, tt.period, tt.periodUnit, tt.method)
+
+			assert.Len(t, result, tt.period)
+			first, last := result[0], result[len(result)-1]
+			assert.True(t, tt.expectedFirst.Principal.Equal(first.Principal), "first principal %s", first.Principal)
+			assert.True(t, tt.expectedFirst.Interest.Equal(first.Interest), "first interest %s", first.Interest)
+			assert.True(t, tt.expectedLast.Principal.Equal(last.Principal), "last principal %s", last.Principal)
+			assert.True(t, tt.expectedLast.Interest.Equal(last.Interest), "last interest %s", last.Interest)
+			assert.True(t, tt.expectedTotal.Equal(SumInstallments(result).Amount), "total %s", SumInstallments(result))
+
+			principal := decimal.Zero
+			for _, i := range result {
+				principal = principal.Add(i.Principal.Amount)
+			}
+			assert.True(t, tt.principal.Equal(principal), "principal is repaid exactly")
+		})
+	}
+}
+
+func TestCalculateDailyInterest(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	dueDates := []time.Time{start.AddDate(0, 0, 7), start.AddDate(0, 0, 14)}
+	installments := []Installment{
+		{Principal: NewMoneyFromInt(500_000, DefaultCurrency), Interest: NewMoneyFromInt(1_000, DefaultCurrency)},
+		{Principal: NewMoneyFromInt(500_000, DefaultCurrency), Interest: NewMoneyFromInt(700, DefaultCurrency)},
+	}
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		expected decimal.Decimal
+	}{
+		{
+			name:     "Before Loan Start",
+			date:     time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			expected: decimal.Zero,
+		},
+		{
+			name:     "Disbursement Day",
+			date:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: decimal.RequireFromString("142.8571"),
+		},
+		{
+			name:     "Second Day",
+			date:     time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			expected: decimal.RequireFromString("142.8572"),
+		},
+		{
+			name:     "Second Period",
+			date:     time.Date(2025, 1, 8, 23, 0, 0, 0, time.UTC),
+			expected: decimal.NewFromInt(100),
+		},
+		{
+			name:     "Last Due Date",
+			date:     time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+			expected: decimal.Zero,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateDailyInterest(start, dueDates, installments, tt.date)
+			assert.True(t, tt.expected.Equal(result.Amount), "Expected %s but got %s", tt.expected, result)
+		})
+	}
+
+	t.Run("Days Add Up To Installment Interest", func(t *testing.T) {
+		total := decimal.Zero
+		for d := start; d.Before(dueDates[1]); d = d.AddDate(0, 0, 1) {
+			total = total.Add(CalculateDailyInterest(start, dueDates, installments, d).Amount)
+		}
+		assert.True(t, decimal.NewFromInt(1_700).Equal(total), "Expected 1700 but got %s", total)
+	})
+}
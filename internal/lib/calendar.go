@@ -0,0 +1,71 @@
+package lib
+
+import (
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+)
+
+// BusinessCalendar tells business days from weekends and public holidays. Saturdays and Sundays are never business
+// days; days are compared by their UTC date, so local dates are passed as LocalDate returns them.
+type BusinessCalendar struct {
+	holidays map[time.Time]bool
+}
+
+func NewBusinessCalendar(holidays []time.Time) *BusinessCalendar {
+	c := &BusinessCalendar{
+		holidays: make(map[time.Time]bool, len(holidays)),
+	}
+	for _, h := range holidays {
+		c.holidays[TruncateToDate(h)] = true
+	}
+	return c
+}
+
+func (c *BusinessCalendar) IsBusinessDay(t time.Time) bool {
+	switch t.UTC().Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return !c.holidays[TruncateToDate(t)]
+}
+
+// Adjust moves t off weekends and holidays according to the convention, keeping its time of day:
+//   - FOLLOWING moves it to the next business day
+//   - MODIFIED_FOLLOWING moves it to the next business day, unless that falls in the next month, then to the previous one
+//   - PRECEDING moves it to the previous business day
+//
+// NONE and unknown conventions leave t as it is.
+func (c *BusinessCalendar) Adjust(t time.Time, rule constant.DateAdjustment) time.Time {
+	switch rule {
+	case constant.DateAdjustmentFollowing:
+		return c.roll(t, 1)
+	case constant.DateAdjustmentModifiedFollowing:
+		following := c.roll(t, 1)
+		if following.Month() != t.Month() {
+			return c.roll(t, -1)
+		}
+		return following
+	case constant.DateAdjustmentPreceding:
+		return c.roll(t, -1)
+	}
+	return t
+}
+
+func (c *BusinessCalendar) roll(t time.Time, step int) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, step)
+	}
+	return t
+}
+
+// OverdueCutoff returns the local due date installments must fall due before to be overdue on the local date today.
+// Payments due on a weekend or holiday can still be made on the next business day, so the run of non-business days
+// leading up to today does not count yet.
+func (c *BusinessCalendar) OverdueCutoff(today time.Time) time.Time {
+	cutoff := TruncateToDate(today)
+	for d := cutoff.AddDate(0, 0, -1); !c.IsBusinessDay(d); d = d.AddDate(0, 0, -1) {
+		cutoff = d
+	}
+	return cutoff
+}
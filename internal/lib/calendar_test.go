@@ -0,0 +1,122 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusinessCalendar_Adjust(t *testing.T) {
+	// 2025-05-29 (Thursday) is a holiday, 2025-05-31 and 2025-06-01 are a weekend
+	calendar := NewBusinessCalendar([]time.Time{
+		time.Date(2025, 5, 29, 0, 0, 0, 0, time.UTC),
+	})
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		rule     constant.DateAdjustment
+		expected time.Time
+	}{
+		{
+			name:     "Business Day Is Kept",
+			date:     time.Date(2025, 5, 28, 12, 0, 0, 0, time.UTC),
+			rule:     constant.DateAdjustmentFollowing,
+			expected: time.Date(2025, 5, 28, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Following Skips The Holiday",
+			date:     time.Date(2025, 5, 29, 12, 0, 0, 0, time.UTC),
+			rule:     constant.DateAdjustmentFollowing,
+			expected: time.Date(2025, 5, 30, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Following Crosses Into The Next Month",
+			date:     time.Date(2025, 5, 31, 12, 0, 0, 0, time.UTC),
+			rule:     constant.DateAdjustmentFollowing,
+			expected: time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Modified Following Stays In The Month",
+			date:     time.Date(2025, 5, 31, 12, 0, 0, 0, time.UTC),
+			rule:     constant.DateAdjustmentModifiedFollowing,
+			expected: time.Date(2025, 5, 30, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Modified Following Within The Month",
+			date:     time.Date(2025, 5, 29, 12, 0, 0, 0, time.UTC),
+			rule:     constant.DateAdjustmentModifiedFollowing,
+			expected: time.Date(2025, 5, 30, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Preceding Skips The Weekend And The Holiday",
+			date:     time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			rule:     constant.DateAdjustmentPreceding,
+			expected: time.Date(2025, 5, 30, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "None Keeps The Date",
+			date:     time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			rule:     constant.DateAdjustmentNone,
+			expected: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, calendar.Adjust(tt.date, tt.rule))
+		})
+	}
+}
+
+func TestBusinessCalendar_OverdueCutoff(t *testing.T) {
+	// 2025-06-02 (Monday) is a holiday
+	calendar := NewBusinessCalendar([]time.Time{
+		time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+	})
+
+	tests := []struct {
+		name     string
+		today    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "After A Business Day",
+			today:    time.Date(2025, 5, 30, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 5, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Saturday After A Business Day",
+			today:    time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "During The Weekend",
+			today:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "On A Holiday After The Weekend",
+			today:    time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "First Business Day After The Holiday",
+			today:    time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Second Business Day After The Holiday",
+			today:    time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, calendar.OverdueCutoff(tt.today))
+		})
+	}
+}
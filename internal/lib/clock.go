@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. Services take one instead of calling time.Now so time-dependent rules can be tested
+// and previewed at other points in time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+// NewSystemClock returns a Clock reading the system time in UTC
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// FakeClock is a Clock that only moves when told to
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now.UTC()}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now.UTC()
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type asOfContextKey struct{}
+
+// WithAsOf returns a context asking read-only views to be computed as if it were asOf
+func WithAsOf(ctx context.Context, asOf time.Time) context.Context {
+	return context.WithValue(ctx, asOfContextKey{}, asOf.UTC())
+}
+
+// ClockFromContext returns a clock frozen at the as-of time carried by ctx, or fallback when there is none
+func ClockFromContext(ctx context.Context, fallback Clock) Clock {
+	if asOf, ok := ctx.Value(asOfContextKey{}).(time.Time); ok {
+		return NewFakeClock(asOf)
+	}
+	return fallback
+}
@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemClock(t *testing.T) {
+	now := NewSystemClock().Now()
+	assert.Equal(t, time.UTC, now.Location())
+	assert.WithinDuration(t, time.Now(), now, time.Second)
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	assert.Equal(t, start, clock.Now())
+
+	clock.Advance(36 * time.Hour)
+	assert.Equal(t, time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), clock.Now())
+
+	clock.Set(time.Date(2025, 3, 15, 19, 0, 0, 0, time.FixedZone("WIB", 7*60*60)))
+	assert.Equal(t, time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC), clock.Now())
+}
+
+func TestClockFromContext(t *testing.T) {
+	fallback := NewFakeClock(time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC))
+	asOf := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected time.Time
+	}{
+		{
+			name:     "Without As Of",
+			ctx:      context.Background(),
+			expected: fallback.Now(),
+		},
+		{
+			name:     "With As Of",
+			ctx:      WithAsOf(context.Background(), asOf),
+			expected: asOf,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClockFromContext(tt.ctx, fallback).Now())
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package lib
+
+import (
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCurrency is the currency of loans that do not name one
+const DefaultCurrency = "IDR"
+
+// Currency is an ISO 4217 currency with the number of decimal places its amounts are settled in and how amounts are
+// rounded to them
+type Currency struct {
+	Code       string                `json:"code"`
+	MinorUnits int32                 `json:"minor_units"`
+	Rounding   constant.RoundingMode `json:"rounding"`
+}
+
+// currencies is the registry of the currencies loans can be made in
+var currencies = map[string]Currency{
+	"IDR": {Code: "IDR", MinorUnits: 0, Rounding: constant.RoundingModeHalfUp},
+	"JPY": {Code: "JPY", MinorUnits: 0, Rounding: constant.RoundingModeHalfUp},
+	"SGD": {Code: "SGD", MinorUnits: 2, Rounding: constant.RoundingModeHalfUp},
+	"MYR": {Code: "MYR", MinorUnits: 2, Rounding: constant.RoundingModeHalfUp},
+	"USD": {Code: "USD", MinorUnits: 2, Rounding: constant.RoundingModeHalfUp},
+	"EUR": {Code: "EUR", MinorUnits: 2, Rounding: constant.RoundingModeHalfEven},
+}
+
+// LookupCurrency returns the registered currency with the given code
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[code]
+	if !ok {
+		return Currency{}, NewValidationError(constant.ErrCodeUnsupportedCurrency, "currency %q is not supported", code)
+	}
+	return c, nil
+}
+
+// Round rounds an amount to the minor units of the currency with its rounding mode
+func (c Currency) Round(amount decimal.Decimal) decimal.Decimal {
+	switch c.Rounding {
+	case constant.RoundingModeHalfEven:
+		return amount.RoundBank(c.MinorUnits)
+	case constant.RoundingModeDown:
+		return amount.RoundDown(c.MinorUnits)
+	}
+	return amount.Round(c.MinorUnits)
+}
@@ -0,0 +1,60 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	c, err := LookupCurrency("IDR")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), c.MinorUnits)
+
+	_, err = LookupCurrency("XXX")
+	assert.Error(t, err)
+	assert.True(t, IsErrorKind(err, ErrorKindValidation))
+}
+
+func TestCurrency_Round(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency Currency
+		amount   string
+		expected string
+	}{
+		{
+			name:     "Zero Decimal Half Up",
+			currency: Currency{Code: "IDR", MinorUnits: 0, Rounding: constant.RoundingModeHalfUp},
+			amount:   "10500.5",
+			expected: "10501",
+		},
+		{
+			name:     "Two Decimals Half Up",
+			currency: Currency{Code: "USD", MinorUnits: 2, Rounding: constant.RoundingModeHalfUp},
+			amount:   "10.125",
+			expected: "10.13",
+		},
+		{
+			name:     "Two Decimals Half Even",
+			currency: Currency{Code: "EUR", MinorUnits: 2, Rounding: constant.RoundingModeHalfEven},
+			amount:   "10.125",
+			expected: "10.12",
+		},
+		{
+			name:     "Two Decimals Down",
+			currency: Currency{Code: "USD", MinorUnits: 2, Rounding: constant.RoundingModeDown},
+			amount:   "10.129",
+			expected: "10.12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.currency.Round(decimal.RequireFromString(tt.amount))
+			assert.True(t, decimal.RequireFromString(tt.expected).Equal(result), result.String())
+		})
+	}
+}
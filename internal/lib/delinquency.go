@@ -0,0 +1,32 @@
+package lib
+
+import (
+	"math"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+)
+
+// DaysPastDue counts the days, started ones included, elapsed since the oldest unpaid installment fell due, so the
+// first day after the due day is 1 day past due. Nil means nothing is overdue.
+func DaysPastDue(oldestUnpaidDueDate *time.Time, now time.Time) int {
+	if oldestUnpaidDueDate == nil || !oldestUnpaidDueDate.Before(now) {
+		return 0
+	}
+	return int(math.Ceil(now.Sub(*oldestUnpaidDueDate).Hours() / 24))
+}
+
+func GetDelinquencyBucket(dpd int) constant.DelinquencyBucket {
+	switch {
+	case dpd <= 0:
+		return constant.DelinquencyBucketCurrent
+	case dpd <= 30:
+		return constant.DelinquencyBucket1To30
+	case dpd <= 60:
+		return constant.DelinquencyBucket31To60
+	case dpd <= 90:
+		return constant.DelinquencyBucket61To90
+	default:
+		return constant.DelinquencyBucketOver90
+	}
+}
@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaysPastDue(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	date := func(d time.Time) *time.Time { return &d }
+
+	tests := []struct {
+		name     string
+		oldest   *time.Time
+		expected int
+	}{
+		{
+			name:     "Nothing overdue",
+			oldest:   nil,
+			expected: 0,
+		},
+		{
+			name:     "Due in the future",
+			oldest:   date(now.AddDate(0, 0, 3)),
+			expected: 0,
+		},
+		{
+			name:     "Overdue by less than a day",
+			oldest:   date(now.Add(-2 * time.Hour)),
+			expected: 1,
+		},
+		{
+			name:     "Overdue by exactly one day",
+			oldest:   date(now.AddDate(0, 0, -1)),
+			expected: 1,
+		},
+		{
+			name:     "Overdue by two weeks and some hours",
+			oldest:   date(now.AddDate(0, 0, -14).Add(-5 * time.Hour)),
+			expected: 15,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DaysPastDue(tt.oldest, now))
+		})
+	}
+}
+
+func TestGetDelinquencyBucket(t *testing.T) {
+	tests := []struct {
+		dpd      int
+		expected constant.DelinquencyBucket
+	}{
+		{dpd: 0, expected: constant.DelinquencyBucketCurrent},
+		{dpd: 1, expected: constant.DelinquencyBucket1To30},
+		{dpd: 30, expected: constant.DelinquencyBucket1To30},
+		{dpd: 31, expected: constant.DelinquencyBucket31To60},
+		{dpd: 60, expected: constant.DelinquencyBucket31To60},
+		{dpd: 61, expected: constant.DelinquencyBucket61To90},
+		{dpd: 90, expected: constant.DelinquencyBucket61To90},
+		{dpd: 91, expected: constant.DelinquencyBucketOver90},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, GetDelinquencyBucket(tt.dpd), "dpd %d", tt.dpd)
+	}
+}
@@ -0,0 +1,91 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type ErrorKind string
+
+const (
+	ErrorKindNotFound     ErrorKind = "NOT_FOUND"
+	ErrorKindConflict     ErrorKind = "CONFLICT"
+	ErrorKindBusinessRule ErrorKind = "BUSINESS_RULE"
+	ErrorKindValidation   ErrorKind = "VALIDATION"
+)
+
+var errorKindToHTTPStatus = map[ErrorKind]int{
+	ErrorKindNotFound:     http.StatusNotFound,
+	ErrorKindConflict:     http.StatusConflict,
+	ErrorKindBusinessRule: http.StatusUnprocessableEntity,
+	ErrorKindValidation:   http.StatusBadRequest,
+}
+
+// Error is a domain error carrying a kind, used to pick the HTTP status, and a stable machine-readable code
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap attaches the underlying cause to the error without changing its message
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func (e *Error) HTTPStatus() int {
+	if status, ok := errorKindToHTTPStatus[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func newError(kind ErrorKind, code string, format string, args ...any) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func NewNotFoundError(code string, format string, args ...any) *Error {
+	return newError(ErrorKindNotFound, code, format, args...)
+}
+
+func NewConflictError(code string, format string, args ...any) *Error {
+	return newError(ErrorKindConflict, code, format, args...)
+}
+
+func NewBusinessRuleError(code string, format string, args ...any) *Error {
+	return newError(ErrorKindBusinessRule, code, format, args...)
+}
+
+func NewValidationError(code string, format string, args ...any) *Error {
+	return newError(ErrorKindValidation, code, format, args...)
+}
+
+// AsError returns the domain error in err's chain, if any
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// IsErrorKind reports whether err's chain contains a domain error of the given kind
+func IsErrorKind(err error, kind ErrorKind) bool {
+	e, ok := AsError(err)
+	return ok && e.Kind == kind
+}
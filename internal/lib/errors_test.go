@@ -0,0 +1,85 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *Error
+		expected int
+	}{
+		{
+			name:     "Not Found",
+			err:      NewNotFoundError("LOAN_NOT_FOUND", "loan not found"),
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Conflict",
+			err:      NewConflictError("OUTSTANDING_LOAN_EXISTS", "there is an outstanding loan"),
+			expected: http.StatusConflict,
+		},
+		{
+			name:     "Business Rule",
+			err:      NewBusinessRuleError("PAYMENT_NOT_IN_PLAN", "payment is not in plan"),
+			expected: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Validation",
+			err:      NewValidationError("INVALID_REQUEST", "invalid request"),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Unknown Kind",
+			err:      &Error{Kind: "UNKNOWN", Code: "UNKNOWN", Message: "unknown"},
+			expected: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.err.HTTPStatus())
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := NewBusinessRuleError("PAYMENT_BELOW_MINIMUM", "you must make payment equal to %s at minimum", "110000")
+	assert.Equal(t, "you must make payment equal to 110000 at minimum", err.Error())
+	assert.Equal(t, "PAYMENT_BELOW_MINIMUM", err.Code)
+	assert.Equal(t, ErrorKindBusinessRule, err.Kind)
+}
+
+func TestErrorWrap(t *testing.T) {
+	cause := errors.New("record not found")
+	err := NewNotFoundError("LOAN_NOT_FOUND", "loan not found").Wrap(cause)
+
+	assert.Equal(t, "loan not found", err.Error())
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestAsError(t *testing.T) {
+	domainErr := NewConflictError("OUTSTANDING_LOAN_EXISTS", "there is an outstanding loan")
+
+	e, ok := AsError(fmt.Errorf("create loan: %w", domainErr))
+	assert.True(t, ok)
+	assert.Same(t, domainErr, e)
+
+	e, ok = AsError(errors.New("database error"))
+	assert.False(t, ok)
+	assert.Nil(t, e)
+}
+
+func TestIsErrorKind(t *testing.T) {
+	err := fmt.Errorf("get loan: %w", NewNotFoundError("LOAN_NOT_FOUND", "loan not found"))
+
+	assert.True(t, IsErrorKind(err, ErrorKindNotFound))
+	assert.False(t, IsErrorKind(err, ErrorKindConflict))
+	assert.False(t, IsErrorKind(errors.New("database error"), ErrorKindNotFound))
+}
@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Migration is a versioned change to the database schema, with the SQL that applies it and the SQL that reverts it
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+var (
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// LoadMigrations reads the migrations in the root of fsys, ordered by version. Files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql, and every migration needs both.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", e.Name(), err)
+		}
+		sql, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(sql)
+		} else {
+			m.Down = string(sql)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// MigrationFileNames returns the names of the up and down files of the migration following migrations
+func MigrationFileNames(migrations []Migration, name string) (up, down string, err error) {
+	if !migrationNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("migration name %q may only have lowercase letters, digits and underscores", name)
+	}
+	version := int64(1)
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+	prefix := fmt.Sprintf("%04d_%s", version, name)
+	return prefix + ".up.sql", prefix + ".down.sql", nil
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name             string
+		fsys             fstest.MapFS
+		expectedError    bool
+		expectedVersions []int64
+	}{
+		{
+			name: "Ordered By Version",
+			fsys: fstest.MapFS{
+				"0010_add_index.up.sql":   {Data: []byte("create index")},
+				"0010_add_index.down.sql": {Data: []byte("drop index")},
+				"0002_seed.up.sql":        {Data: []byte("insert")},
+				"0002_seed.down.sql":      {Data: []byte("delete")},
+				"migrations.go":           {Data: []byte("package migrations")},
+			},
+			expectedVersions: []int64{2, 10},
+		},
+		{
+			name: "Missing Down File",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql": {Data: []byte("create table")},
+			},
+			expectedError: true,
+		},
+		{
+			name: "Version With Two Names",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":     {Data: []byte("create table")},
+				"0001_create.down.sql": {Data: []byte("drop table")},
+			},
+			expectedError: true,
+		},
+		{
+			name:             "Empty",
+			fsys:             fstest.MapFS{},
+			expectedVersions: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := LoadMigrations(tt.fsys)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			versions := make([]int64, len(migrations))
+			for i, m := range migrations {
+				versions[i] = m.Version
+				assert.NotEmpty(t, m.Up)
+				assert.NotEmpty(t, m.Down)
+			}
+			assert.Equal(t, tt.expectedVersions, versions)
+		})
+	}
+}
+
+func TestMigrationFileNames(t *testing.T) {
+	up, down, err := MigrationFileNames(nil, "init")
+	assert.NoError(t, err)
+	assert.Equal(t, "0001_init.up.sql", up)
+	assert.Equal(t, "0001_init.down.sql", down)
+
+	up, down, err = MigrationFileNames([]Migration{{Version: 1}, {Version: 7}}, "add_loan_version")
+	assert.NoError(t, err)
+	assert.Equal(t, "0008_add_loan_version.up.sql", up)
+	assert.Equal(t, "0008_add_loan_version.down.sql", down)
+
+	_, _, err = MigrationFileNames(nil, "Add Loan Version")
+	assert.Error(t, err)
+}
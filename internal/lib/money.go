@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/shopspring/decimal"
+)
+
+// Money is an amount in a currency. It is marshalled to JSON as the amount in a string, and stored as the amount
+// alone in a decimal column, the currency being kept once per row by the model that owns the amounts.
+//
+// Amounts of different currencies cannot be combined. Amounts coming from a request, the configuration or another row
+// are checked with CheckCurrency where they come in, so a mismatch reaching Add, Sub or Cmp is a programming error and
+// panics. An empty currency stands for an amount whose currency is not known yet, such as one just read from the
+// database or a request, and takes the currency of the other operand.
+type Money struct {
+	Amount   decimal.Decimal
+	Currency string
+}
+
+func NewMoney(amount decimal.Decimal, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func NewMoneyFromInt(amount int64, currency string) Money {
+	return Money{Amount: decimal.NewFromInt(amount), Currency: currency}
+}
+
+// ZeroMoney returns no money in the currency
+func ZeroMoney(currency string) Money {
+	return Money{Amount: decimal.Zero, Currency: currency}
+}
+
+// ParseMoney reads an amount written as a decimal string, without going through a float
+func ParseMoney(amount, currency string) (Money, error) {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: d, Currency: currency}, nil
+}
+
+// In returns the same amount in the currency, for amounts whose currency was not known when they were made
+func (m Money) In(currency string) Money {
+	return Money{Amount: m.Amount, Currency: currency}
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount.Add(o.Amount), Currency: m.currencyWith(o)}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount.Sub(o.Amount), Currency: m.currencyWith(o)}
+}
+
+// Mul multiplies the amount by a factor such as a rate or a count
+func (m Money) Mul(factor decimal.Decimal) Money {
+	return Money{Amount: m.Amount.Mul(factor), Currency: m.Currency}
+}
+
+// Div divides the amount by a divisor such as a count of installments
+func (m Money) Div(divisor decimal.Decimal) Money {
+	return Money{Amount: m.Amount.Div(divisor), Currency: m.Currency}
+}
+
+// RoundTo rounds the amount to places decimal places half up, for intermediate results kept more precise than the
+// currency
+func (m Money) RoundTo(places int32) Money {
+	return Money{Amount: m.Amount.Round(places), Currency: m.Currency}
+}
+
+// Round rounds the amount to the minor units of its currency with the currency's rounding mode. Amounts in an
+// unknown currency are rounded like the default currency.
+func (m Money) Round() Money {
+	currency, err := LookupCurrency(m.Currency)
+	if err != nil {
+		currency, _ = LookupCurrency(DefaultCurrency)
+	}
+	return Money{Amount: currency.Round(m.Amount), Currency: m.Currency}
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: m.Amount.Neg(), Currency: m.Currency}
+}
+
+func (m Money) Cmp(o Money) int {
+	m.currencyWith(o)
+	return m.Amount.Cmp(o.Amount)
+}
+
+func (m Money) Equal(o Money) bool {
+	return m.Cmp(o) == 0
+}
+
+func (m Money) LessThan(o Money) bool {
+	return m.Cmp(o) < 0
+}
+
+func (m Money) GreaterThan(o Money) bool {
+	return m.Cmp(o) > 0
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount.IsPositive()
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount.IsNegative()
+}
+
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount.String()
+	}
+	return m.Currency + " " + m.Amount.String()
+}
+
+// CheckCurrency returns a CURRENCY_MISMATCH error when the amount is in a currency other than the given one
+func (m Money) CheckCurrency(currency string) error {
+	if m.Currency != "" && m.Currency != currency {
+		return NewBusinessRuleError(constant.ErrCodeCurrencyMismatch, "amount is in %s, not %s", m.Currency, currency)
+	}
+	return nil
+}
+
+func (m Money) currencyWith(o Money) string {
+	switch {
+	case m.Currency == "":
+		return o.Currency
+	case o.Currency == "" || o.Currency == m.Currency:
+		return m.Currency
+	}
+	panic(fmt.Sprintf("cannot combine %s with %s", m.Currency, o.Currency))
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.Amount.String() + `"`), nil
+}
+
+// UnmarshalJSON reads the amount from a JSON string or number and rejects anything else. Numbers are parsed from their
+// literal, so large amounts keep every digit. The currency is left as it was.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var literal string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &literal); err != nil {
+			return fmt.Errorf("invalid amount %s: %w", data, err)
+		}
+	} else {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("invalid amount %s: %w", data, err)
+		}
+		literal = number.String()
+	}
+	amount, err := decimal.NewFromString(literal)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s: %w", data, err)
+	}
+	m.Amount = amount
+	return nil
+}
+
+// Scan reads the amount from a decimal column
+func (m *Money) Scan(value any) error {
+	return m.Amount.Scan(value)
+}
+
+// Value stores the amount in a decimal column
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount.Value()
+}
@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(decimal.RequireFromString("5000000.25"), "IDR"))
+	assert.NoError(t, err)
+	assert.Equal(t, `"5000000.25"`, string(data))
+
+	tests := []struct {
+		name          string
+		data          string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "String",
+			data:     `"110000.5"`,
+			expected: "110000.5",
+		},
+		{
+			name:     "Number Keeps Every Digit",
+			data:     `123456789012345678.99`,
+			expected: "123456789012345678.99",
+		},
+		{
+			name:     "Null",
+			data:     `null`,
+			expected: "0",
+		},
+		{
+			name:          "Not A Number",
+			data:          `"ten"`,
+			expectedError: true,
+		},
+		{
+			name:          "Unterminated String",
+			data:          `"12`,
+			expectedError: true,
+		},
+		{
+			name:          "Doubled Quotes",
+			data:          `""5""`,
+			expectedError: true,
+		},
+		{
+			name:          "Boolean",
+			data:          `true`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := m.UnmarshalJSON([]byte(tt.data))
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.expected).Equal(m.Amount), m.String())
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := NewMoneyFromInt(100, "USD")
+	b := NewMoney(decimal.RequireFromString("0.125"), "USD")
+
+	assert.Equal(t, "USD 100.125", a.Add(b).String())
+	assert.Equal(t, "USD 99.875", a.Sub(b).String())
+	assert.Equal(t, "USD 0.13", b.Round().String())
+	assert.Equal(t, "USD 33.3333", a.Div(decimal.NewFromInt(3)).RoundTo(4).String())
+	assert.True(t, b.LessThan(a))
+
+	// an amount without a currency takes the currency of the other one
+	assert.Equal(t, "USD 101", a.Add(NewMoneyFromInt(1, "")).String())
+
+	assert.Panics(t, func() { a.Add(NewMoneyFromInt(1, "IDR")) })
+}
+
+func TestMoney_CheckCurrency(t *testing.T) {
+	assert.NoError(t, NewMoneyFromInt(100, "USD").CheckCurrency("USD"))
+	assert.NoError(t, NewMoneyFromInt(100, "").CheckCurrency("USD"))
+
+	err := NewMoneyFromInt(100, "IDR").CheckCurrency("USD")
+	assert.True(t, IsErrorKind(err, ErrorKindBusinessRule))
+}
+
+func TestMoney_ScanValue(t *testing.T) {
+	var m Money
+	assert.NoError(t, m.Scan("5480769.0000"))
+	assert.True(t, decimal.NewFromInt(5_480_769).Equal(m.Amount))
+
+	v, err := NewMoneyFromInt(110_000, "IDR").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "110000", v)
+}
@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Cursor points at the last row of a page. ID breaks ties between rows sharing the same sort value.
+type Cursor struct {
+	ID    string `json:"id"`
+	Value string `json:"v,omitempty"`
+}
+
+func EncodeCursor(c *Cursor) string {
+	if c == nil {
+		return ""
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if c.ID == "" {
+		return nil, errors.New("cursor has no id")
+	}
+	return &c, nil
+}
+
+// NormalizePageLimit falls back to the default limit when none is given and caps it at the maximum
+func NormalizePageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
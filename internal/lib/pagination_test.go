@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor *Cursor
+	}{
+		{
+			name:   "ID only",
+			cursor: &Cursor{ID: "0196b5a1-7c1e-7d4a-9f0e-3b2f1c4d5e6f"},
+		},
+		{
+			name:   "ID and value",
+			cursor: &Cursor{ID: "0196b5a1-7c1e-7d4a-9f0e-3b2f1c4d5e6f", Value: "John Doe"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := EncodeCursor(tt.cursor)
+			assert.NotEmpty(t, encoded)
+
+			decoded, err := DecodeCursor(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.cursor, decoded)
+		})
+	}
+}
+
+func TestEncodeCursorNil(t *testing.T) {
+	assert.Equal(t, "", EncodeCursor(nil))
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectNil   bool
+		expectError bool
+	}{
+		{
+			name:      "Empty cursor",
+			input:     "",
+			expectNil: true,
+		},
+		{
+			name:        "Not base64",
+			input:       "not a cursor!",
+			expectError: true,
+		},
+		{
+			name:        "Not JSON",
+			input:       "bm90LWpzb24",
+			expectError: true,
+		},
+		{
+			name:        "Missing ID",
+			input:       EncodeCursor(&Cursor{Value: "John Doe"}),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := DecodeCursor(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.expectNil {
+				assert.Nil(t, c)
+			}
+		})
+	}
+}
+
+func TestNormalizePageLimit(t *testing.T) {
+	assert.Equal(t, DefaultPageLimit, NormalizePageLimit(0))
+	assert.Equal(t, DefaultPageLimit, NormalizePageLimit(-5))
+	assert.Equal(t, 10, NormalizePageLimit(10))
+	assert.Equal(t, MaxPageLimit, NormalizePageLimit(MaxPageLimit+1))
+}
@@ -1,9 +1,11 @@
 package lib
 
 type Response struct {
-	Status  string `json:"status"`
-	Message string `json:"message,omitempty"`
-	Data    any    `json:"data,omitempty"`
+	Status     string `json:"status"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Data       any    `json:"data,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func ResponseSuccess(data interface{}, envelopes ...string) Response {
@@ -19,9 +21,20 @@ func ResponseSuccess(data interface{}, envelopes ...string) Response {
 	}
 }
 
+// ResponsePage is ResponseSuccess for a paginated list. An empty nextCursor means there are no more pages.
+func ResponsePage(data interface{}, nextCursor string, envelopes ...string) Response {
+	res := ResponseSuccess(data, envelopes...)
+	res.NextCursor = nextCursor
+	return res
+}
+
 func ResponseError(err error) Response {
-	return Response{
+	res := Response{
 		Status:  "error",
 		Message: err.Error(),
 	}
+	if e, ok := AsError(err); ok {
+		res.Code = e.Code
+	}
+	return res
 }
@@ -87,6 +87,16 @@ func TestResponseSuccess(t *testing.T) {
 	}
 }
 
+func TestResponsePage(t *testing.T) {
+	result := ResponsePage([]string{"item1", "item2"}, "next-page", "items")
+	assert.Equal(t, "success", result.Status)
+	assert.Equal(t, "next-page", result.NextCursor)
+	assert.Equal(t, map[string]any{"items": []string{"item1", "item2"}}, result.Data)
+
+	result = ResponsePage([]string{}, "", "items")
+	assert.Empty(t, result.NextCursor)
+}
+
 func TestResponseError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -101,6 +111,15 @@ func TestResponseError(t *testing.T) {
 				Message: "test error",
 			},
 		},
+		{
+			name: "Domain error with code",
+			err:  NewNotFoundError("LOAN_NOT_FOUND", "loan not found"),
+			expected: Response{
+				Status:  "error",
+				Code:    "LOAN_NOT_FOUND",
+				Message: "loan not found",
+			},
+		},
 		{
 			name: "Error with empty message",
 			err:  errors.New(""),
@@ -115,6 +134,7 @@ func TestResponseError(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			result := ResponseError(tt.err)
 			assert.Equal(t, tt.expected.Status, result.Status)
+			assert.Equal(t, tt.expected.Code, result.Code)
 			assert.Equal(t, tt.expected.Message, result.Message)
 			assert.Nil(t, result.Data)
 		})
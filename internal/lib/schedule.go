@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+)
+
+// NextDailyRun returns the first moment strictly after now at which a job scheduled daily at hour:minute UTC is due
+func NextDailyRun(now time.Time, hour, minute int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// CalculateDueDates returns the due date of each installment of a loan starting at start: every 7 days for weekly
+// loans, and on the same day of each following month for monthly loans, falling back to the last day of months that
+// are too short.
+func CalculateDueDates(start time.Time, period int, periodUnit constant.LoanPeriodUnit) []time.Time {
+	dueDates := make([]time.Time, 0, max(period, 0))
+	for i := 1; i <= period; i++ {
+		if periodUnit == constant.PeriodUnitMonth {
+			dueDates = append(dueDates, addMonthsClamped(start, i))
+			continue
+		}
+		dueDates = append(dueDates, start.AddDate(0, 0, 7*i))
+	}
+	return dueDates
+}
+
+// addMonthsClamped adds months to t without overflowing into the month after, as time.AddDate does on the 31st
+func addMonthsClamped(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
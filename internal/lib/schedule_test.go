@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDailyRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Later today",
+			now:      time.Date(2025, 3, 15, 0, 30, 0, 0, time.UTC),
+			expected: time.Date(2025, 3, 15, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Exactly at the run time",
+			now:      time.Date(2025, 3, 15, 1, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 3, 16, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Already passed today",
+			now:      time.Date(2025, 3, 15, 13, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 3, 16, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Across the end of the month",
+			now:      time.Date(2025, 3, 31, 23, 59, 0, 0, time.UTC),
+			expected: time.Date(2025, 4, 1, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Non UTC input is compared in UTC",
+			now:      time.Date(2025, 3, 15, 7, 30, 0, 0, time.FixedZone("WIB", 7*60*60)),
+			expected: time.Date(2025, 3, 15, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NextDailyRun(tt.now, 1, 0))
+		})
+	}
+}
+
+func TestCalculateDueDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      time.Time
+		period     int
+		periodUnit constant.LoanPeriodUnit
+		expected   []time.Time
+	}{
+		{
+			name:       "Weekly",
+			start:      time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+			period:     3,
+			periodUnit: constant.PeriodUnitWeek,
+			expected: []time.Time{
+				time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC),
+				time.Date(2025, 3, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2025, 4, 5, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Monthly",
+			start:      time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+			period:     2,
+			periodUnit: constant.PeriodUnitMonth,
+			expected: []time.Time{
+				time.Date(2025, 4, 15, 12, 0, 0, 0, time.UTC),
+				time.Date(2025, 5, 15, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Monthly From The End Of A Month",
+			start:      time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			period:     3,
+			periodUnit: constant.PeriodUnitMonth,
+			expected: []time.Time{
+				time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "No Period",
+			start:      time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+			period:     0,
+			periodUnit: constant.PeriodUnitWeek,
+			expected:   []time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CalculateDueDates(tt.start, tt.period, tt.periodUnit))
+		})
+	}
+}
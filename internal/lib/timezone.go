@@ -0,0 +1,26 @@
+package lib
+
+import (
+	"time"
+	_ "time/tzdata" // timezone names resolve even where the host has no zoneinfo database
+)
+
+// LoadLocation resolves an IANA timezone name, treating an empty name as UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// LocalDate returns the calendar date of t in loc, as midnight UTC so it compares and stores like any other date
+func LocalDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// EndOfLocalDay returns the moment the calendar date ends in loc, that is midnight of the following day there, in UTC
+func EndOfLocalDay(date time.Time, loc *time.Location) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, loc).UTC()
+}
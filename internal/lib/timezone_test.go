@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = LoadLocation("Asia/Jakarta")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Jakarta", loc.String())
+
+	_, err = LoadLocation("Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
+
+func TestLocalDate(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			name:     "Same date in UTC",
+			t:        time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+			loc:      time.UTC,
+			expected: time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Late evening UTC is already tomorrow in Jakarta",
+			t:        time.Date(2025, 3, 15, 18, 0, 0, 0, time.UTC),
+			loc:      jakarta,
+			expected: time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Early morning UTC is still yesterday in New York",
+			t:        time.Date(2025, 3, 15, 2, 0, 0, 0, time.UTC),
+			loc:      newYork,
+			expected: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, LocalDate(tt.t, tt.loc))
+		})
+	}
+}
+
+func TestEndOfLocalDay(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			name:     "UTC",
+			date:     time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC),
+			loc:      time.UTC,
+			expected: time.Date(2025, 3, 23, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Ahead of UTC",
+			date:     time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC),
+			loc:      jakarta,
+			expected: time.Date(2025, 3, 22, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Behind UTC on the day daylight saving starts",
+			date:     time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+			loc:      newYork,
+			expected: time.Date(2025, 3, 10, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "End of the month",
+			date:     time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+			loc:      time.UTC,
+			expected: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, EndOfLocalDay(tt.date, tt.loc))
+		})
+	}
+}
@@ -4,23 +4,64 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"gorm.io/gorm"
 )
 
 type Borrower struct {
-	ID        string    `json:"id" gorm:"type:char(36);primary_key"`
-	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+	ID          string                  `json:"id" gorm:"type:char(36);primary_key"`
+	Name        string                  `json:"name" gorm:"type:varchar(100);not null"`
+	Phone       string                  `json:"phone" gorm:"type:varchar(20);not null;default:''"`
+	Email       string                  `json:"email" gorm:"type:varchar(100);not null;default:''"`
+	NationalID  string                  `json:"national_id" gorm:"type:varchar(32);not null;default:''"`
+	Address     string                  `json:"address" gorm:"type:text;not null;default:''"`
+	DateOfBirth *time.Time              `json:"date_of_birth" gorm:"type:date;default:null"`
+	Timezone    string                  `json:"timezone" gorm:"type:varchar(64);not null;default:''"`
+	Status      constant.BorrowerStatus `json:"status" gorm:"type:varchar(12);not null;default:'ACTIVE'"`
+	CreatedAt   time.Time               `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+	UpdatedAt   time.Time               `json:"updated_at" gorm:"type:timestamp;default:now();not null"`
 }
 
 func (c *Borrower) BeforeCreate(tx *gorm.DB) error {
 	if c.ID == "" {
 		c.ID = uuid.Must(uuid.NewV7()).String()
 	}
+	if c.Status == "" {
+		c.Status = constant.BorrowerStatusActive
+	}
 	return nil
 }
 
+func (c *Borrower) IsActive() bool {
+	return c.Status == constant.BorrowerStatusActive
+}
+
 type BorrowerWithDelinquentStatus struct {
 	Borrower
 	IsDelinquent bool `json:"is_delinquent"`
 }
+
+// BorrowerSummary is the financial position of a borrower. Amounts are only totalled within a currency, so a borrower
+// with loans in several currencies gets one entry per currency.
+type BorrowerSummary struct {
+	BorrowerID         string                     `json:"borrower_id"`
+	Currencies         []*BorrowerCurrencySummary `json:"currencies"`
+	LoanCount          int                        `json:"loan_count"`
+	ActiveLoanCount    int                        `json:"active_loan_count"`
+	CompletedLoanCount int                        `json:"completed_loan_count"`
+	DaysPastDue        int                        `json:"days_past_due"`
+	DelinquencyBucket  constant.DelinquencyBucket `json:"delinquency_bucket"`
+	AsOf               time.Time                  `json:"as_of"`
+}
+
+// BorrowerCurrencySummary is the financial position of a borrower in one currency
+type BorrowerCurrencySummary struct {
+	Currency         string     `json:"currency"`
+	TotalPrincipal   lib.Money  `json:"total_principal" swaggertype:"string"`
+	TotalRepaid      lib.Money  `json:"total_repaid" swaggertype:"string"`
+	TotalOutstanding lib.Money  `json:"total_outstanding" swaggertype:"string"`
+	OverdueAmount    lib.Money  `json:"overdue_amount" swaggertype:"string"`
+	NextDueDate      *time.Time `json:"next_due_date"`
+	NextDueAmount    lib.Money  `json:"next_due_amount" swaggertype:"string"`
+}
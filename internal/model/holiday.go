@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Holiday is a public holiday of one country, on which no installment should fall due
+type Holiday struct {
+	ID          string    `json:"id" gorm:"type:char(36);primary_key"`
+	CountryCode string    `json:"country_code" gorm:"type:char(2);not null;uniqueIndex:idx_holidays_country_date"`
+	Date        time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_holidays_country_date"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+}
+
+func (c *Holiday) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.Must(uuid.NewV7()).String()
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// InterestAccrual is the interest a loan earned on one day. There is at most one accrual per loan and day,
+// which is what makes re-running the accrual job for the same dates safe.
+type InterestAccrual struct {
+	ID             string          `json:"id" gorm:"type:char(36);primary_key"`
+	LoanID         string          `json:"loan_id" gorm:"type:char(36);not null;uniqueIndex:idx_interest_accruals_loan_date"`
+	Loan           *Loan           `json:"loan,omitempty" gorm:"foreignKey:LoanID;references:ID"`
+	AccrualDate    time.Time       `json:"accrual_date" gorm:"type:date;not null;uniqueIndex:idx_interest_accruals_loan_date"`
+	Amount         decimal.Decimal `json:"amount" gorm:"type:decimal(16,4);not null"`
+	JournalEntryID *string         `json:"journal_entry_id" gorm:"type:char(36)"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+}
+
+func (c *InterestAccrual) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.Must(uuid.NewV7()).String()
+	}
+	return nil
+}
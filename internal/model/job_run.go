@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"gorm.io/gorm"
+)
+
+// JobRun is one execution of a background job, kept as run history
+type JobRun struct {
+	ID           string                `json:"id" gorm:"type:char(36);primary_key"`
+	JobName      string                `json:"job_name" gorm:"type:varchar(50);not null;index:idx_job_runs_name_date"`
+	BusinessDate time.Time             `json:"business_date" gorm:"type:date;not null;index:idx_job_runs_name_date"`
+	Trigger      constant.JobTrigger   `json:"trigger" gorm:"type:varchar(10);not null"`
+	Status       constant.JobRunStatus `json:"status" gorm:"type:varchar(10);not null"`
+	Result       json.RawMessage       `json:"result" gorm:"type:jsonb" swaggertype:"object"`
+	Error        string                `json:"error" gorm:"type:text;not null;default:''"`
+	StartedAt    time.Time             `json:"started_at" gorm:"type:timestamp;not null"`
+	FinishedAt   *time.Time            `json:"finished_at" gorm:"type:timestamp;default:null"`
+}
+
+func (c *JobRun) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.Must(uuid.NewV7()).String()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type LedgerAccount struct {
+	Code      string                     `json:"code" gorm:"type:varchar(30);primary_key"`
+	Name      string                     `json:"name" gorm:"type:varchar(100);not null"`
+	Type      constant.LedgerAccountType `json:"type" gorm:"type:varchar(10);not null"`
+	CreatedAt time.Time                  `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+}
+
+// IsDebitNormal reports whether the account balance grows on the debit side
+func (a LedgerAccount) IsDebitNormal() bool {
+	return a.Type == constant.LedgerAccountTypeAsset || a.Type == constant.LedgerAccountTypeExpense
+}
+
+// JournalEntry is one balanced money movement. Entries are never updated, mistakes are corrected by posting a reversal.
+type JournalEntry struct {
+	ID           string                    `json:"id" gorm:"type:char(36);primary_key"`
+	Type         constant.JournalEntryType `json:"type" gorm:"type:varchar(20);not null"`
+	LoanID       string                    `json:"loan_id" gorm:"type:char(36);not null;index"`
+	Loan         *Loan                     `json:"loan,omitempty" gorm:"foreignKey:LoanID;references:ID"`
+	ReversalOfID *string                   `json:"reversal_of_id" gorm:"type:char(36);uniqueIndex"`
+	Description  string                    `json:"description" gorm:"type:varchar(255);not null;default:''"`
+	PostedAt     time.Time                 `json:"posted_at" gorm:"type:timestamp;not null"`
+	Lines        []*JournalLine            `json:"lines" gorm:"foreignKey:JournalEntryID;references:ID"`
+	CreatedAt    time.Time                 `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+}
+
+func (c *JournalEntry) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.Must(uuid.NewV7()).String()
+	}
+	return c.Validate()
+}
+
+// Validate checks the double-entry invariant: at least two lines in one currency, each one strictly on
+// the debit or the credit side, and total debits equal to total credits.
+func (c *JournalEntry) Validate() error {
+	if len(c.Lines) < 2 {
+		return fmt.Errorf("journal entry %s must have at least two lines", c.ID)
+	}
+	debit, credit := decimal.Zero, decimal.Zero
+	for _, l := range c.Lines {
+		if l.Currency != c.Lines[0].Currency {
+			return fmt.Errorf("journal entry %s mixes %s and %s", c.ID, c.Lines[0].Currency, l.Currency)
+		}
+		if l.Debit.IsNegative() || l.Credit.IsNegative() || l.Debit.IsZero() == l.Credit.IsZero() {
+			return fmt.Errorf("journal entry %s has a line on %s that is not a single positive debit or credit", c.ID, l.AccountCode)
+		}
+		debit = debit.Add(l.Debit)
+		credit = credit.Add(l.Credit)
+	}
+	if !debit.Equal(credit) {
+		return fmt.Errorf("journal entry %s is unbalanced: debit %s, credit %s", c.ID, debit, credit)
+	}
+	return nil
+}
+
+// Reverse builds the entry that cancels c out by swapping the side of every line
+func (c *JournalEntry) Reverse(postedAt time.Time) *JournalEntry {
+	lines := make([]*JournalLine, len(c.Lines))
+	for i, l := range c.Lines {
+		lines[i] = &JournalLine{
+			AccountCode: l.AccountCode,
+			Currency:    l.Currency,
+			Debit:       l.Credit,
+			Credit:      l.Debit,
+		}
+	}
+	return &JournalEntry{
+		Type:         constant.JournalEntryTypeReversal,
+		LoanID:       c.LoanID,
+		ReversalOfID: &c.ID,
+		Description:  fmt.Sprintf("reversal of %s", c.ID),
+		PostedAt:     postedAt,
+		Lines:        lines,
+	}
+}
+
+type JournalLine struct {
+	ID             string          `json:"id" gorm:"type:char(36);primary_key"`
+	JournalEntryID string          `json:"journal_entry_id" gorm:"type:char(36);not null;index"`
+	AccountCode    string          `json:"account_code" gorm:"type:varchar(30);not null;index"`
+	Account        *LedgerAccount  `json:"account,omitempty" gorm:"foreignKey:AccountCode;references:Code"`
+	Currency       string          `json:"currency" gorm:"type:char(3);not null;default:'IDR'"`
+	Debit          decimal.Decimal `json:"debit" gorm:"type:decimal(16,4);not null;default:0"`
+	Credit         decimal.Decimal `json:"credit" gorm:"type:decimal(16,4);not null;default:0"`
+}
+
+func (c *JournalLine) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.Must(uuid.NewV7()).String()
+	}
+	return nil
+}
+
+// TrialBalanceLine is the debit and credit turnover of one account in one currency
+type TrialBalanceLine struct {
+	Currency    string                     `json:"currency"`
+	AccountCode string                     `json:"account_code"`
+	AccountName string                     `json:"account_name"`
+	AccountType constant.LedgerAccountType `json:"account_type"`
+	Debit       decimal.Decimal            `json:"debit"`
+	Credit      decimal.Decimal            `json:"credit"`
+	Balance     decimal.Decimal            `json:"balance"`
+}
+
+// CurrencyTrialBalance is the trial balance of the amounts posted in one currency, which are never added to others
+type CurrencyTrialBalance struct {
+	Currency    string              `json:"currency"`
+	Accounts    []*TrialBalanceLine `json:"accounts"`
+	TotalDebit  decimal.Decimal     `json:"total_debit"`
+	TotalCredit decimal.Decimal     `json:"total_credit"`
+	IsBalanced  bool                `json:"is_balanced"`
+}
+
+type TrialBalance struct {
+	AsOf               *time.Time              `json:"as_of"`
+	Currencies         []*CurrencyTrialBalance `json:"currencies"`
+	IsBalanced         bool                    `json:"is_balanced"`
+	UnbalancedEntryIDs []string                `json:"unbalanced_entry_ids"`
+}
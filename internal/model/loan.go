@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,23 +13,283 @@ import (
 )
 
 type Loan struct {
-	ID                 string                  `json:"id" gorm:"type:char(36);primary_key"`
-	BorrowerID         string                  `json:"borrower_id" gorm:"type:char(36);not null"`
-	Borrower           *Borrower               `json:"borrower,omitempty" gorm:"foreignKey:BorrowerID;references:ID"`
-	Principal          decimal.Decimal         `json:"principal" gorm:"type:decimal(16,4);not null"`
-	AnnualInterestRate decimal.Decimal         `json:"annual_interest_rate" gorm:"type:decimal(5,2);not null"`
-	TotalRepayment     decimal.Decimal         `json:"total_repayment" gorm:"type:decimal(16,4);not null"`
-	Period             int                     `json:"period" gorm:"type:integer;not null"`
-	PeriodUnit         constant.LoanPeriodUnit `json:"period_unit" gorm:"type:varchar(5);not null"`
-	CreatedAt          time.Time               `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+	ID                   string                      `json:"id" gorm:"type:char(36);primary_key"`
+	BorrowerID           string                      `json:"borrower_id" gorm:"type:char(36);not null"`
+	Borrower             *Borrower                   `json:"borrower,omitempty" gorm:"foreignKey:BorrowerID;references:ID"`
+	Currency             string                      `json:"currency" gorm:"type:char(3);not null;default:'IDR'"`
+	Principal            lib.Money                   `json:"principal" gorm:"type:decimal(16,4);not null" swaggertype:"string"`
+	OriginationFee       lib.Money                   `json:"origination_fee" gorm:"type:decimal(16,4);not null;default:0" swaggertype:"string"`
+	OriginationFeeMode   constant.OriginationFeeMode `json:"origination_fee_mode" gorm:"type:varchar(10);not null;default:'DEDUCTED'"`
+	NetDisbursement      lib.Money                   `json:"net_disbursement" gorm:"type:decimal(16,4);not null;default:0" swaggertype:"string"`
+	AnnualInterestRate   decimal.Decimal             `json:"annual_interest_rate" gorm:"type:decimal(5,2);not null"`
+	InterestMethod       constant.InterestMethod     `json:"interest_method" gorm:"type:varchar(10);not null;default:'FLAT'"`
+	TotalRepayment       lib.Money                   `json:"total_repayment" gorm:"type:decimal(16,4);not null" swaggertype:"string"`
+	APR                  decimal.Decimal             `json:"apr" gorm:"type:decimal(7,2);not null;default:0"`
+	EffectiveRate        decimal.Decimal             `json:"effective_rate" gorm:"type:decimal(7,2);not null;default:0"`
+	Period               int                         `json:"period" gorm:"type:integer;not null"`
+	PeriodUnit           constant.LoanPeriodUnit     `json:"period_unit" gorm:"type:varchar(5);not null"`
+	DaysPastDue          int                         `json:"days_past_due" gorm:"type:integer;not null;default:0"`
+	OutstandingPrincipal lib.Money                   `json:"outstanding_principal" gorm:"type:decimal(16,4);not null;default:0" swaggertype:"string"`
+	OutstandingTotal     lib.Money                   `json:"outstanding_total" gorm:"type:decimal(16,4);not null;default:0" swaggertype:"string"`
+	PaidTotal            lib.Money                   `json:"paid_total" gorm:"type:decimal(16,4);not null;default:0" swaggertype:"string"`
+	NextDueDate          *time.Time                  `json:"next_due_date" gorm:"type:timestamp;default:null"`
+	InstallmentsOverdue  int                         `json:"installments_overdue" gorm:"type:integer;not null;default:0"`
+	Timezone             string                      `json:"timezone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	Version              int64                       `json:"version" gorm:"type:bigint;not null;default:1"`
+	CreatedAt            time.Time                   `json:"created_at" gorm:"type:timestamp;default:now();not null"`
 }
 
 func (c *Loan) BeforeCreate(tx *gorm.DB) error {
 	if c.ID == "" {
 		c.ID = uuid.Must(uuid.NewV7()).String()
 	}
-	if c.TotalRepayment.IsZero() {
-		c.TotalRepayment = lib.CalculateTotalRepayment(c.Principal, c.AnnualInterestRate, c.Period, c.PeriodUnit).Round(0)
+	if c.InterestMethod == "" {
+		c.InterestMethod = constant.InterestMethodFlat
+	}
+	if c.Version == 0 {
+		c.Version = 1
+	}
+	if c.Currency == "" {
+		c.Currency = lib.DefaultCurrency
+	}
+	c.setCurrency()
+	if c.OriginationFeeMode == "" {
+		c.OriginationFeeMode = constant.OriginationFeeModeDeducted
+	}
+	return nil
+}
+
+func (c *Loan) AfterFind(tx *gorm.DB) error {
+	c.setCurrency()
+	return nil
+}
+
+// setCurrency puts the amounts of the loan in its currency, which is stored once rather than with every amount
+func (c *Loan) setCurrency() {
+	c.Principal = c.Principal.In(c.Currency)
+	c.OriginationFee = c.OriginationFee.In(c.Currency)
+	c.NetDisbursement = c.NetDisbursement.In(c.Currency)
+	c.TotalRepayment = c.TotalRepayment.In(c.Currency)
+	c.OutstandingPrincipal = c.OutstandingPrincipal.In(c.Currency)
+	c.OutstandingTotal = c.OutstandingTotal.In(c.Currency)
+	c.PaidTotal = c.PaidTotal.In(c.Currency)
+}
+
+// CheckInstallmentCurrency returns a CURRENCY_MISMATCH error when an installment is in another currency than the loan,
+// before its amounts are combined with those of the loan
+func (c *Loan) CheckInstallmentCurrency(lps []*LoanPayment) error {
+	currency := c.CurrencyUnit().Code
+	for _, lp := range lps {
+		if lp.Currency != "" && lp.Currency != currency {
+			return lib.NewBusinessRuleError(constant.ErrCodeCurrencyMismatch, "installment %s is in %s but the loan is in %s", lp.ID, lp.Currency, currency)
+		}
+	}
+	return nil
+}
+
+// SetBalances works the running totals of the loan out from all of its installments. The principal outstanding adds
+// up the principal recorded on the outstanding installments, which counts none for an installment without one until
+// FillInstallmentPrincipal has recorded it.
+func (c *Loan) SetBalances(lps []*LoanPayment) error {
+	if err := c.CheckInstallmentCurrency(lps); err != nil {
+		return err
+	}
+	currency := c.CurrencyUnit().Code
+	lps = sortByDueDate(lps)
+
+	c.OutstandingPrincipal = lib.ZeroMoney(currency)
+	c.OutstandingTotal = lib.ZeroMoney(currency)
+	c.PaidTotal = lib.ZeroMoney(currency)
+	c.NextDueDate = nil
+	c.InstallmentsOverdue = 0
+	for _, lp := range lps {
+		switch lp.Status {
+		case constant.LoanPaymentStatusUnpaid, constant.LoanPaymentStatusOverdue:
+			if lp.Principal != nil {
+				c.OutstandingPrincipal = c.OutstandingPrincipal.Add(lp.Principal.In(currency))
+			}
+			c.OutstandingTotal = c.OutstandingTotal.Add(lp.AmountDue().In(currency))
+		case constant.LoanPaymentStatusPaid:
+			c.PaidTotal = c.PaidTotal.Add(lp.AmountDue().In(currency))
+		}
+		if lp.Status == constant.LoanPaymentStatusOverdue {
+			c.InstallmentsOverdue++
+		}
+		if lp.Status == constant.LoanPaymentStatusUnpaid && c.NextDueDate == nil {
+			dueDate := lp.DueDate
+			c.NextDueDate = &dueDate
+		}
+	}
+	return nil
+}
+
+// FillInstallmentPrincipal records the principal of the schedule on the installments that were created before their
+// principal was, matching them to the schedule by due date, and returns the installments it filled in
+func (c *Loan) FillInstallmentPrincipal(lps []*LoanPayment) ([]*LoanPayment, error) {
+	if err := c.CheckInstallmentCurrency(lps); err != nil {
+		return nil, err
+	}
+	lps = sortByDueDate(lps)
+	installments := c.Installments()
+
+	filled := make([]*LoanPayment, 0)
+	for i, lp := range lps {
+		if lp.Principal != nil || i >= len(installments) {
+			continue
+		}
+		principal := installments[i].Principal
+		lp.Principal = &principal
+		filled = append(filled, lp)
+	}
+	return filled, nil
+}
+
+// sortByDueDate returns the installments in the order they fall due, leaving the given slice as it is
+func sortByDueDate(lps []*LoanPayment) []*LoanPayment {
+	lps = slices.Clone(lps)
+	slices.SortStableFunc(lps, func(a, b *LoanPayment) int {
+		return a.DueDate.Compare(b.DueDate)
+	})
+	return lps
+}
+
+// BalanceDrift lists the running totals of the loan that differ from those of other, formatted as "field: stored -> actual"
+func (c *Loan) BalanceDrift(other Loan) []string {
+	drift := make([]string, 0)
+	money := []struct {
+		field         string
+		stored, other lib.Money
+	}{
+		{field: "outstanding_principal", stored: c.OutstandingPrincipal, other: other.OutstandingPrincipal},
+		{field: "outstanding_total", stored: c.OutstandingTotal, other: other.OutstandingTotal},
+		{field: "paid_total", stored: c.PaidTotal, other: other.PaidTotal},
+	}
+	for _, m := range money {
+		if !m.stored.Amount.Equal(m.other.Amount) {
+			drift = append(drift, fmt.Sprintf("%s: %s -> %s", m.field, m.stored, m.other))
+		}
+	}
+	if !equalTimes(c.NextDueDate, other.NextDueDate) {
+		drift = append(drift, fmt.Sprintf("next_due_date: %s -> %s", formatTime(c.NextDueDate), formatTime(other.NextDueDate)))
+	}
+	if c.InstallmentsOverdue != other.InstallmentsOverdue {
+		drift = append(drift, fmt.Sprintf("installments_overdue: %d -> %d", c.InstallmentsOverdue, other.InstallmentsOverdue))
+	}
+	return drift
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "null"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// CurrencyUnit returns the registered currency the loan is made in, falling back to the default currency when the
+// loan does not name one
+func (c *Loan) CurrencyUnit() lib.Currency {
+	code := c.Currency
+	if code == "" {
+		code = lib.DefaultCurrency
+	}
+	currency, err := lib.LookupCurrency(code)
+	if err != nil {
+		currency, _ = lib.LookupCurrency(lib.DefaultCurrency)
+	}
+	return currency
+}
+
+// Installments splits the loan into the principal and interest of each installment
+func (c *Loan) Installments() []lib.Installment {
+	return lib.CalculateInstallments(c.Principal.In(c.CurrencyUnit().Code), c.AnnualInterestRate, c.Period, c.PeriodUnit, c.InterestMethod)
+}
+
+// CostOfCredit returns the APR and effective interest rate of the loan, worked out from its actual installment
+// schedule and origination fee rather than the nominal rate
+func (c *Loan) CostOfCredit() (apr, eir decimal.Decimal) {
+	installments := c.Installments()
+	amounts := make([]lib.Money, len(installments))
+	for i, installment := range installments {
+		amounts[i] = installment.Amount()
+	}
+	return lib.CalculateAPR(c.Principal, c.OriginationFee, amounts, c.PeriodUnit)
+}
+
+// Location returns the timezone the due dates of the loan are set in, falling back to UTC when it is unknown
+func (c *Loan) Location() *time.Location {
+	loc, err := lib.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// DueDates returns the local calendar date each installment falls due on, counted from the local date of the
+// disbursement
+func (c *Loan) DueDates() []time.Time {
+	return lib.CalculateDueDates(lib.LocalDate(c.CreatedAt, c.Location()), c.Period, c.PeriodUnit)
+}
+
+// OriginationFeePolicy is how a loan product charges its origination fee: a flat amount or a percentage of the
+// requested amount, either deducted from the money disbursed or financed by adding it to the principal
+type OriginationFeePolicy struct {
+	Type  constant.OriginationFeeType
+	Value decimal.Decimal
+	Mode  constant.OriginationFeeMode
+}
+
+// Validate rejects a policy with an unknown type or mode or a negative value, which would otherwise issue loans
+// without the fee
+func (p OriginationFeePolicy) Validate() error {
+	switch p.Type {
+	case constant.OriginationFeeTypeFlat, constant.OriginationFeeTypePercentage:
+	default:
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "origination fee type %q is not FLAT or PERCENTAGE", p.Type)
+	}
+	switch p.Mode {
+	case constant.OriginationFeeModeDeducted, constant.OriginationFeeModeFinanced:
+	default:
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "origination fee mode %q is not DEDUCTED or FINANCED", p.Mode)
+	}
+	if p.Value.IsNegative() {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "origination fee value %s is negative", p.Value)
+	}
+	return nil
+}
+
+// Apply charges the fee on a loan whose principal is the amount requested. A financed fee is added to the principal,
+// so the schedule and interest cover it, while the borrower receives the requested amount. A deducted fee leaves
+// the principal alone and is taken out of the disbursement.
+func (p OriginationFeePolicy) Apply(l *Loan) error {
+	currency := l.CurrencyUnit().Code
+	l.Principal = l.Principal.In(currency)
+	fee := lib.ZeroMoney(currency)
+	switch p.Type {
+	case constant.OriginationFeeTypeFlat:
+		fee = lib.NewMoney(p.Value, currency)
+	case constant.OriginationFeeTypePercentage:
+		fee = l.Principal.Mul(p.Value).Div(decimal.NewFromInt(100)).Round()
+	}
+	if fee.IsNegative() {
+		fee = lib.ZeroMoney(currency)
+	}
+
+	l.OriginationFee = fee
+	l.OriginationFeeMode = constant.OriginationFeeModeDeducted
+	if p.Mode == constant.OriginationFeeModeFinanced {
+		l.OriginationFeeMode = constant.OriginationFeeModeFinanced
+		l.Principal = l.Principal.Add(fee)
+	}
+	l.NetDisbursement = l.Principal.Sub(fee)
+	if !l.NetDisbursement.IsPositive() {
+		return lib.NewValidationError(constant.ErrCodeFeeExceedsPrincipal, "origination fee %s leaves nothing to disburse", fee)
 	}
 	return nil
 }
@@ -36,3 +298,47 @@ type LoanWithCompleteStatus struct {
 	Loan
 	IsCompleted bool `json:"is_completed"`
 }
+
+// LoanStats aggregates the loans of a borrower. Amounts are only totalled within a currency.
+type LoanStats struct {
+	LoanCount       int                  `json:"loan_count"`
+	ActiveLoanCount int                  `json:"active_loan_count"`
+	Currencies      []*LoanCurrencyStats `json:"currencies"`
+}
+
+// LoanCurrencyStats aggregates the loans of a borrower in one currency
+type LoanCurrencyStats struct {
+	Currency         string    `json:"currency"`
+	LoanCount        int       `json:"loan_count"`
+	ActiveLoanCount  int       `json:"active_loan_count"`
+	TotalPrincipal   lib.Money `json:"total_principal" swaggertype:"string"`
+	TotalOutstanding lib.Money `json:"total_outstanding" swaggertype:"string"`
+}
+
+// LoanSimulation is the repayment schedule and cost of a loan that has not been taken
+type LoanSimulation struct {
+	Currency           string                      `json:"currency"`
+	Principal          lib.Money                   `json:"principal" swaggertype:"string"`
+	OriginationFee     lib.Money                   `json:"origination_fee" swaggertype:"string"`
+	OriginationFeeMode constant.OriginationFeeMode `json:"origination_fee_mode"`
+	NetDisbursement    lib.Money                   `json:"net_disbursement" swaggertype:"string"`
+	AnnualInterestRate decimal.Decimal             `json:"annual_interest_rate"`
+	InterestMethod     constant.InterestMethod     `json:"interest_method"`
+	Period             int                         `json:"period"`
+	PeriodUnit         constant.LoanPeriodUnit     `json:"period_unit"`
+	Timezone           string                      `json:"timezone"`
+	TotalRepayment     lib.Money                   `json:"total_repayment" swaggertype:"string"`
+	TotalInterest      lib.Money                   `json:"total_interest" swaggertype:"string"`
+	APR                decimal.Decimal             `json:"apr"`
+	EffectiveRate      decimal.Decimal             `json:"effective_rate"`
+	Installments       []*SimulatedInstallment     `json:"installments"`
+}
+
+type SimulatedInstallment struct {
+	Number       int       `json:"number"`
+	LocalDueDate time.Time `json:"local_due_date"`
+	DueDate      time.Time `json:"due_date"`
+	Principal    lib.Money `json:"principal" swaggertype:"string"`
+	Interest     lib.Money `json:"interest" swaggertype:"string"`
+	Amount       lib.Money `json:"amount" swaggertype:"string"`
+}
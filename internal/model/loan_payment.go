@@ -1,25 +1,38 @@
 package model
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ramabmtr/billing-engine/internal/constant"
-	"github.com/shopspring/decimal"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"gorm.io/gorm"
 )
 
+// LoanPayment is one installment of a loan. Principal is the part of Amount that repays principal, recorded when the
+// loan is created and null for installments created before it was. LocalDueDate is the calendar date it falls due on in
+// the loan's timezone, and DueDate the moment that day ends there, after which the installment is late. SettledByEntryID is the repayment or
+// waiver entry that settled the installment and LateFeeEntryID the fee accrual entry of its late fee, so reversing either
+// entry can restore the installment.
 type LoanPayment struct {
-	ID         string                     `json:"id" gorm:"type:char(36);primary_key"`
-	LoanID     string                     `json:"loan_id" gorm:"type:char(36);not null"`
-	Loan       *Loan                      `json:"loan,omitempty" gorm:"foreignKey:LoanID;references:ID"`
-	BorrowerID string                     `json:"borrower_id" gorm:"type:char(36);not null"`
-	Borrower   *Borrower                  `json:"borrower,omitempty" gorm:"foreignKey:BorrowerID;references:ID"`
-	Amount     decimal.Decimal            `json:"amount" gorm:"type:decimal(16,4);not null"`
-	DueDate    time.Time                  `json:"due_date" gorm:"type:timestamp;not null"`
-	Status     constant.LoanPaymentStatus `json:"status" gorm:"type:varchar(10);not null"`
-	PaidAt     *time.Time                 `json:"paid_at" gorm:"type:timestamp;default:null"`
-	CreatedAt  time.Time                  `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+	ID               string                     `json:"id" gorm:"type:char(36);primary_key"`
+	LoanID           string                     `json:"loan_id" gorm:"type:char(36);not null"`
+	Loan             *Loan                      `json:"loan,omitempty" gorm:"foreignKey:LoanID;references:ID"`
+	BorrowerID       string                     `json:"borrower_id" gorm:"type:char(36);not null"`
+	Borrower         *Borrower                  `json:"borrower,omitempty" gorm:"foreignKey:BorrowerID;references:ID"`
+	Currency         string                     `json:"currency" gorm:"type:char(3);not null;default:'IDR'"`
+	Amount           lib.Money                  `json:"amount" gorm:"type:decimal(16,4);not null" swaggertype:"string"`
+	Principal        *lib.Money                 `json:"principal" gorm:"type:decimal(16,4);default:null" swaggertype:"string"`
+	LateFee          lib.Money                  `json:"late_fee" gorm:"type:decimal(16,4);not null;default:0" swaggertype:"string"`
+	LocalDueDate     time.Time                  `json:"local_due_date" gorm:"type:date"`
+	DueDate          time.Time                  `json:"due_date" gorm:"type:timestamp;not null"`
+	Status           constant.LoanPaymentStatus `json:"status" gorm:"type:varchar(10);not null"`
+	PaidAt           *time.Time                 `json:"paid_at" gorm:"type:timestamp;default:null"`
+	SettledByEntryID *string                    `json:"settled_by_entry_id" gorm:"type:char(36);default:null;index"`
+	LateFeeEntryID   *string                    `json:"late_fee_entry_id" gorm:"type:char(36);default:null;index"`
+	CreatedAt        time.Time                  `json:"created_at" gorm:"type:timestamp;default:now();not null"`
 }
 
 func (c *LoanPayment) BeforeCreate(tx *gorm.DB) error {
@@ -28,3 +41,108 @@ func (c *LoanPayment) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (c *LoanPayment) AfterFind(tx *gorm.DB) error {
+	c.Amount = c.Amount.In(c.Currency)
+	c.LateFee = c.LateFee.In(c.Currency)
+	if c.Principal != nil {
+		principal := c.Principal.In(c.Currency)
+		c.Principal = &principal
+	}
+	return nil
+}
+
+// AmountDue is the installment amount plus any late fee charged on it
+func (c *LoanPayment) AmountDue() lib.Money {
+	return c.Amount.Add(c.LateFee)
+}
+
+// loanPaymentTransitions lists the statuses an installment may move to from each status. PAID, WAIVED and CANCELLED are final.
+var loanPaymentTransitions = map[constant.LoanPaymentStatus][]constant.LoanPaymentStatus{
+	constant.LoanPaymentStatusUnpaid: {
+		constant.LoanPaymentStatusOverdue,
+		constant.LoanPaymentStatusPaid,
+		constant.LoanPaymentStatusWaived,
+		constant.LoanPaymentStatusCancelled,
+	},
+	constant.LoanPaymentStatusOverdue: {
+		constant.LoanPaymentStatusPaid,
+		constant.LoanPaymentStatusWaived,
+		constant.LoanPaymentStatusCancelled,
+	},
+}
+
+// CanTransitionLoanPayment reports whether an installment in status from may move to status to
+func CanTransitionLoanPayment(from, to constant.LoanPaymentStatus) bool {
+	return slices.Contains(loanPaymentTransitions[from], to)
+}
+
+// LoanPaymentStatusesBefore returns every status an installment may move to the given status from.
+// Bulk status updates use it as their guard so rows that may not make the transition are left alone.
+func LoanPaymentStatusesBefore(to constant.LoanPaymentStatus) []string {
+	statuses := make([]string, 0)
+	for from := range loanPaymentTransitions {
+		if CanTransitionLoanPayment(from, to) {
+			statuses = append(statuses, string(from))
+		}
+	}
+	slices.Sort(statuses)
+	return statuses
+}
+
+// TransitionTo moves the installment to the given status, stamping PaidAt when it is paid
+func (c *LoanPayment) TransitionTo(to constant.LoanPaymentStatus, at time.Time) error {
+	if !CanTransitionLoanPayment(c.Status, to) {
+		return fmt.Errorf("loan payment %s cannot move from %s to %s", c.ID, c.Status, to)
+	}
+	c.Status = to
+	if to == constant.LoanPaymentStatusPaid {
+		c.PaidAt = &at
+	}
+	return nil
+}
+
+// IsSettled reports whether the installment was paid or waived
+func (c *LoanPayment) IsSettled() bool {
+	return c.Status == constant.LoanPaymentStatusPaid || c.Status == constant.LoanPaymentStatusWaived
+}
+
+// IsSettledBy reports whether the installment was settled by the given journal entry
+func (c *LoanPayment) IsSettledBy(entryID string) bool {
+	return c.IsSettled() && c.SettledByEntryID != nil && *c.SettledByEntryID == entryID
+}
+
+// Reopen puts a settled installment back to outstanding once the entry that settled it is reversed. This is the only
+// way out of PAID or WAIVED. The installment is OVERDUE again when its due date has passed, UNPAID otherwise.
+func (c *LoanPayment) Reopen(at time.Time) error {
+	if !c.IsSettled() {
+		return fmt.Errorf("loan payment %s is %s and cannot be reopened", c.ID, c.Status)
+	}
+	c.Status = constant.LoanPaymentStatusUnpaid
+	if c.DueDate.Before(at) {
+		c.Status = constant.LoanPaymentStatusOverdue
+	}
+	c.PaidAt = nil
+	c.SettledByEntryID = nil
+	return nil
+}
+
+// LoanPaymentStats aggregates the installments of a borrower as of a point in time. Amounts are only totalled within
+// a currency.
+type LoanPaymentStats struct {
+	OverdueCount         int                         `json:"overdue_count"`
+	OldestOverdueDueDate *time.Time                  `json:"oldest_overdue_due_date"`
+	Currencies           []*LoanPaymentCurrencyStats `json:"currencies"`
+}
+
+// LoanPaymentCurrencyStats aggregates the installments of a borrower in one currency
+type LoanPaymentCurrencyStats struct {
+	Currency             string     `json:"currency"`
+	TotalRepaid          lib.Money  `json:"total_repaid" swaggertype:"string"`
+	TotalOutstanding     lib.Money  `json:"total_outstanding" swaggertype:"string"`
+	OverdueAmount        lib.Money  `json:"overdue_amount" swaggertype:"string"`
+	OverdueCount         int        `json:"overdue_count"`
+	OldestOverdueDueDate *time.Time `json:"oldest_overdue_due_date"`
+	NextDueDate          *time.Time `json:"next_due_date"`
+	NextDueAmount        lib.Money  `json:"next_due_amount" swaggertype:"string"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is an event waiting to be delivered to other systems. DedupKey makes emitting the same event twice a no-op.
+type OutboxEvent struct {
+	ID          string                   `json:"id" gorm:"type:char(36);primary_key"`
+	Type        constant.OutboxEventType `json:"type" gorm:"type:varchar(50);not null"`
+	AggregateID string                   `json:"aggregate_id" gorm:"type:char(36);not null;index"`
+	DedupKey    string                   `json:"dedup_key" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Payload     string                   `json:"payload" gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time                `json:"created_at" gorm:"type:timestamp;default:now();not null"`
+	PublishedAt *time.Time               `json:"published_at" gorm:"type:timestamp;default:null;index"`
+}
+
+func (c *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.Must(uuid.NewV7()).String()
+	}
+	return nil
+}
+
+// PaymentDueReminder is the payload of a PAYMENT_DUE_REMINDER event
+type PaymentDueReminder struct {
+	LoanPaymentID string    `json:"loan_payment_id"`
+	LoanID        string    `json:"loan_id"`
+	BorrowerID    string    `json:"borrower_id"`
+	Currency      string    `json:"currency"`
+	AmountDue     lib.Money `json:"amount_due" swaggertype:"string"`
+	LocalDueDate  time.Time `json:"local_due_date"`
+	DueDate       time.Time `json:"due_date"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// SchemaMigration records a migration applied to the database
+type SchemaMigration struct {
+	Version   int64     `json:"version" gorm:"type:bigint;primary_key;autoIncrement:false"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	AppliedAt time.Time `json:"applied_at" gorm:"type:timestamp;not null"`
+}
+
+// MigrationStatus tells whether and when a migration was applied
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+	// Missing is set on applied migrations whose files are not in this build
+	Missing bool `json:"missing"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// SimulationClockID is the id of the only simulated clock
+const SimulationClockID = 1
+
+// SimulationClock is the time the simulated clock of simulation mode was last moved to
+type SimulationClock struct {
+	ID          int       `json:"-" gorm:"primary_key"`
+	SimulatedAt time.Time `json:"simulated_at" gorm:"type:timestamp;not null"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"type:timestamp;default:now();not null"`
+}
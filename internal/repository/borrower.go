@@ -1,15 +1,41 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
 	"gorm.io/gorm"
 )
 
 type BorrowerRepo interface {
 	WithTx(tx *gorm.DB) BorrowerRepo
-	Create(b *model.Borrower) error
-	List() ([]*model.BorrowerWithDelinquentStatus, error)
+	Create(ctx context.Context, b *model.Borrower) error
+	Get(ctx context.Context, b *model.Borrower) error
+	Update(ctx context.Context, b *model.Borrower) error
+	UpdateStatus(ctx context.Context, b *model.Borrower, from constant.BorrowerStatus) (bool, error)
+	List(ctx context.Context, f BorrowerFilter) ([]*model.BorrowerWithDelinquentStatus, *lib.Cursor, error)
+}
+
+const (
+	BorrowerSortCreatedAt = "created_at"
+	BorrowerSortName      = "name"
+)
+
+// BorrowerFilter narrows down and pages the borrower list. CreatedTo is exclusive.
+// Sort is one of the BorrowerSort* keys, prefixed with "-" for descending order.
+type BorrowerFilter struct {
+	Name         string
+	IsDelinquent *bool
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Sort         string
+	After        *lib.Cursor
+	Limit        int
 }
 
 type borrowerRepo struct {
@@ -24,24 +50,98 @@ func (r *borrowerRepo) WithTx(tx *gorm.DB) BorrowerRepo {
 	return &borrowerRepo{db: tx}
 }
 
-func (r *borrowerRepo) Create(b *model.Borrower) error {
-	return r.db.Create(b).Error
+func (r *borrowerRepo) Create(ctx context.Context, b *model.Borrower) error {
+	return r.db.WithContext(ctx).Create(b).Error
 }
 
-func (r *borrowerRepo) List() ([]*model.BorrowerWithDelinquentStatus, error) {
+func (r *borrowerRepo) Get(ctx context.Context, b *model.Borrower) error {
+	return r.db.WithContext(ctx).First(b).Error
+}
+
+// Update stores the profile of the borrower. The status is left alone, so a profile update cannot undo a status change
+// made since the borrower was read.
+func (r *borrowerRepo) Update(ctx context.Context, b *model.Borrower) error {
+	return r.db.WithContext(ctx).Model(b).
+		Select("name", "phone", "email", "national_id", "address", "date_of_birth", "timezone", "updated_at").
+		Updates(b).Error
+}
+
+// UpdateStatus stores the status the borrower was changed to, provided it is still in status from.
+// It reports false when the borrower changed status in the meantime.
+func (r *borrowerRepo) UpdateStatus(ctx context.Context, b *model.Borrower, from constant.BorrowerStatus) (bool, error) {
+	res := r.db.WithContext(ctx).Model(b).
+		Where("status = ?", from).
+		Update("status", b.Status)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *borrowerRepo) List(ctx context.Context, f BorrowerFilter) ([]*model.BorrowerWithDelinquentStatus, *lib.Cursor, error) {
+	overdueCount := r.db.
+		Table("loans l").
+		Select("coalesce(sum(l.installments_overdue), 0)").
+		Where("l.borrower_id = b.id")
+
+	q := r.db.WithContext(ctx).
+		Select("b.*, (?) > 1 as is_delinquent", overdueCount).
+		Table("borrowers b")
+
+	if f.Name != "" {
+		q = q.Where(`b.name ilike ? escape '\'`, containsPattern(f.Name))
+	}
+	if f.IsDelinquent != nil {
+		if *f.IsDelinquent {
+			q = q.Where("(?) > 1", overdueCount)
+		} else {
+			q = q.Where("(?) <= 1", overdueCount)
+		}
+	}
+	if f.CreatedFrom != nil {
+		q = q.Where("b.created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		q = q.Where("b.created_at < ?", *f.CreatedTo)
+	}
+
+	// UUIDv7 ids are time-ordered, so id doubles as the created_at sort key and as the tiebreaker
+	desc := strings.HasPrefix(f.Sort, "-")
+	op, dir := ">", "asc"
+	if desc {
+		op, dir = "<", "desc"
+	}
+	switch strings.TrimPrefix(f.Sort, "-") {
+	case BorrowerSortName:
+		if f.After != nil {
+			q = q.Where(fmt.Sprintf("(b.name, b.id) %s (?, ?)", op), f.After.Value, f.After.ID)
+		}
+		q = q.Order(fmt.Sprintf("b.name %s, b.id %s", dir, dir))
+	default:
+		if f.After != nil {
+			q = q.Where(fmt.Sprintf("b.id %s ?", op), f.After.ID)
+		}
+		q = q.Order(fmt.Sprintf("b.id %s", dir))
+	}
+
 	var borrowers = make([]*model.BorrowerWithDelinquentStatus, 0)
-	err := r.db.
-		Select(
-			"b.*",
-			`case
-	when count(lp.id) > 1
-		then true
-		else false
-		end as is_delinquent`,
-		).
-		Table("borrowers b").
-		Joins("left join loan_payments lp ON lp.borrower_id = b.id and lp.status = ? and lp.due_date < ?", constant.LoanPaymentStatusUnpaid, r.db.NowFunc()).
-		Group("b.id").
-		Scan(&borrowers).Error
-	return borrowers, err
+	err := q.Limit(f.Limit + 1).Scan(&borrowers).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(borrowers) <= f.Limit {
+		return borrowers, nil, nil
+	}
+	borrowers = borrowers[:f.Limit]
+	last := borrowers[len(borrowers)-1]
+	next := &lib.Cursor{ID: last.ID}
+	if strings.TrimPrefix(f.Sort, "-") == BorrowerSortName {
+		next.Value = last.Name
+	}
+	return borrowers, next, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// containsPattern is a like pattern matching any text containing s, the wildcards in s being matched literally
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
 }
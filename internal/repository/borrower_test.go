@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestBorrowerRepo_List_NameSearch(t *testing.T) {
+	tests := []struct {
+		name            string
+		search          string
+		expectedPattern string
+	}{
+		{
+			name:            "Plain Text",
+			search:          "john",
+			expectedPattern: "'%john%'",
+		},
+		{
+			name:            "Literal Percent Sign",
+			search:          "100%",
+			expectedPattern: `'%100\%%'`,
+		},
+		{
+			name:            "Literal Underscore",
+			search:          "j_doe",
+			expectedPattern: `'%j\_doe%'`,
+		},
+		{
+			name:            "Literal Backslash",
+			search:          `a\b`,
+			expectedPattern: `'%a\\b%'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the statement is only built, so no database is needed
+			recorder := &statementRecorder{Interface: logger.Discard}
+			db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+				DryRun:               true,
+				DisableAutomaticPing: true,
+				Logger:               recorder,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// scanning fails without a database, after the statement has been built and logged
+			_, _, _ = NewBorrowerRepo(db).List(context.Background(), BorrowerFilter{Name: tt.search, Limit: 10})
+
+			statements := recorder.take()
+			if assert.Len(t, statements, 1) {
+				assert.Contains(t, statements[0], `b.name ilike `+tt.expectedPattern+` escape '\'`)
+			}
+		})
+	}
+}
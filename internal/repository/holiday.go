@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HolidayRepo interface {
+	Save(ctx context.Context, hs []*model.Holiday) error
+	Delete(ctx context.Context, id string) (bool, error)
+	List(ctx context.Context, countryCode string, from, to time.Time) ([]*model.Holiday, error)
+}
+
+type holidayRepo struct {
+	db *gorm.DB
+}
+
+func NewHolidayRepo(db *gorm.DB) HolidayRepo {
+	return &holidayRepo{db: db}
+}
+
+// Save inserts the holidays, renaming those already stored for the same country and date
+func (r *holidayRepo) Save(ctx context.Context, hs []*model.Holiday) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "country_code"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name"}),
+		}).
+		Create(hs).Error
+}
+
+// Delete removes the holiday, reporting whether it existed
+func (r *holidayRepo) Delete(ctx context.Context, id string) (bool, error) {
+	res := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Holiday{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// List returns the holidays of the country from from (inclusive) to to (exclusive), by date
+func (r *holidayRepo) List(ctx context.Context, countryCode string, from, to time.Time) ([]*model.Holiday, error) {
+	var hs = make([]*model.Holiday, 0)
+	err := r.db.WithContext(ctx).
+		Where("country_code = ? and date >= ? and date < ?", countryCode, from, to).
+		Order("date").
+		Find(&hs).Error
+	return hs, err
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/migrations"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// statementRecorder is a gorm logger keeping the SQL of every statement run, with its arguments filled in
+type statementRecorder struct {
+	logger.Interface
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *statementRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, sql)
+}
+
+func (r *statementRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := r.statements
+	r.statements = nil
+	return statements
+}
+
+// seedQueryPlans fills the tables the hot queries read with 1000 borrowers, each with a weekly loan of 50 installments
+// in one of three timezones, the first of them due before the billing run of TestQueryPlans
+const seedQueryPlans = `
+insert into borrowers (id, name, timezone, created_at)
+select md5('borrower' || n)::uuid, 'Borrower ' || n, 'UTC', timestamp '2025-01-01' + n * interval '1 minute'
+from generate_series(1, 1000) n;
+
+insert into loans (id, borrower_id, principal, net_disbursement, annual_interest_rate, total_repayment, period,
+                   period_unit, timezone, created_at)
+select md5('loan' || n)::uuid, md5('borrower' || n)::uuid, 5000000, 5000000, 10, 5500000, 50, 'WEEK',
+       (array ['UTC', 'Asia/Jakarta', 'America/New_York'])[n % 3 + 1], timestamp '2025-03-03'
+from generate_series(1, 1000) n;
+
+insert into loan_payments (id, loan_id, borrower_id, amount, principal, local_due_date, due_date, status)
+select md5('installment' || n || '-' || k)::uuid, md5('loan' || n)::uuid, md5('borrower' || n)::uuid, 110000, 100000,
+       date '2025-03-10' + (k - 1) * 7, date '2025-03-10' + (k - 1) * 7 + 1, 'UNPAID'
+from generate_series(1, 1000) n
+         cross join generate_series(1, 50) k;
+
+analyze borrowers, loans, loan_payments;
+`
+
+// TestQueryPlans checks that the hot queries are served by the indexes of the migrations. It needs an empty Postgres
+// database in TEST_DATABASE_DSN; the migrations, the seed rows and the queries run in a transaction that is rolled back.
+// The repository has no CI workflow, so the test only runs where TEST_DATABASE_DSN is set by hand.
+func TestQueryPlans(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	recorder := &statementRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true, Logger: recorder})
+	if !assert.NoError(t, err) {
+		return
+	}
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	ms, err := lib.LoadMigrations(migrations.FS)
+	assert.NoError(t, err)
+	for _, m := range ms {
+		if !assert.NoError(t, tx.Exec(m.Up).Error, "migration %d_%s", m.Version, m.Name) {
+			return
+		}
+	}
+	// with statistics on enough rows the planner picks the indexes it would pick in production
+	if !assert.NoError(t, tx.Exec(seedQueryPlans).Error) {
+		return
+	}
+
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		query         func(ctx context.Context) error
+		expectedIndex string
+	}{
+		{
+			name: "Outstanding Installments Of A Loan",
+			query: func(ctx context.Context) error {
+				_, err := NewLoanPaymentRepo(tx).FindOutstanding(ctx, "loan-id-1")
+				return err
+			},
+			expectedIndex: "idx_loan_payments_loan_status_due_date",
+		},
+		{
+			name: "Installment List Of A Loan",
+			query: func(ctx context.Context) error {
+				_, _, err := NewLoanPaymentRepo(tx).List(ctx, LoanPaymentFilter{LoanID: "loan-id-1", Limit: 10})
+				return err
+			},
+			expectedIndex: "idx_loan_payments_loan_status_due_date",
+		},
+		{
+			name: "Installment Stats Of A Borrower",
+			query: func(ctx context.Context) error {
+				_, err := NewLoanPaymentRepo(tx).GetStatsByBorrowerID(ctx, "borrower-id-1", now)
+				return err
+			},
+			expectedIndex: "idx_loan_payments_borrower_status_due_date",
+		},
+		{
+			name: "Mark Overdue",
+			query: func(ctx context.Context) error {
+				_, err := NewLoanPaymentRepo(tx).MarkOverdue(ctx, "UTC", lib.LocalDate(now, time.UTC))
+				return err
+			},
+			expectedIndex: "idx_loan_payments_status_local_due_date",
+		},
+		{
+			name: "Loans Of A Borrower",
+			query: func(ctx context.Context) error {
+				_, _, err := NewLoanRepo(tx).List(ctx, LoanFilter{BorrowerID: "borrower-id-1", Limit: 10})
+				return err
+			},
+			expectedIndex: "idx_loans_borrower_id",
+		},
+		{
+			name: "Loan Stats Of A Borrower",
+			query: func(ctx context.Context) error {
+				_, err := NewLoanRepo(tx).GetStatsByBorrowerID(ctx, "borrower-id-1")
+				return err
+			},
+			expectedIndex: "idx_loans_borrower_id",
+		},
+		{
+			name: "Borrowers By Name",
+			query: func(ctx context.Context) error {
+				_, _, err := NewBorrowerRepo(tx).List(ctx, BorrowerFilter{Sort: BorrowerSortName, Limit: 10})
+				return err
+			},
+			expectedIndex: "idx_borrowers_name_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.take()
+			if !assert.NoError(t, tt.query(context.Background())) {
+				return
+			}
+
+			statements := recorder.take()
+			assert.NotEmpty(t, statements)
+			for _, statement := range statements {
+				var plan []string
+				err := tx.Raw("explain " + statement).Scan(&plan).Error
+				assert.NoError(t, err)
+				assert.Contains(t, strings.Join(plan, "\n"), tt.expectedIndex, statement)
+			}
+		})
+	}
+}
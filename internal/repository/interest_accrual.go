@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InterestAccrualRepo interface {
+	WithTx(tx *gorm.DB) InterestAccrualRepo
+	Create(ctx context.Context, a *model.InterestAccrual) (bool, error)
+	DeleteByJournalEntryID(ctx context.Context, entryID string) (bool, error)
+}
+
+type interestAccrualRepo struct {
+	db *gorm.DB
+}
+
+func NewInterestAccrualRepo(db *gorm.DB) InterestAccrualRepo {
+	return &interestAccrualRepo{db: db}
+}
+
+func (r *interestAccrualRepo) WithTx(tx *gorm.DB) InterestAccrualRepo {
+	return &interestAccrualRepo{db: tx}
+}
+
+// Create inserts the accrual unless the loan already has one for that date, reporting whether it was inserted
+func (r *interestAccrualRepo) Create(ctx context.Context, a *model.InterestAccrual) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "loan_id"}, {Name: "accrual_date"}},
+			DoNothing: true,
+		}).
+		Create(a)
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteByJournalEntryID removes the accrual booked by the journal entry, so its date can be accrued again once the
+// entry is reversed. It reports whether there was one.
+func (r *interestAccrualRepo) DeleteByJournalEntryID(ctx context.Context, entryID string) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Where("journal_entry_id = ?", entryID).
+		Delete(&model.InterestAccrual{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"hash/fnv"
+
+	"gorm.io/gorm"
+)
+
+// JobLocker makes sure a job runs on one replica at a time
+type JobLocker interface {
+	// RunExclusive runs fn while holding the lock for name. It reports false without running fn when another
+	// process holds the lock.
+	RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error)
+}
+
+type jobLocker struct {
+	db *gorm.DB
+}
+
+// NewJobLocker returns a JobLocker backed by Postgres session advisory locks, so the lock is released
+// when the holder's connection goes away.
+func NewJobLocker(db *gorm.DB) JobLocker {
+	return &jobLocker{db: db}
+}
+
+func (l *jobLocker) RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	key := int64(h.Sum64())
+
+	acquired := false
+	// advisory locks belong to a session, so lock and unlock have to happen on the same connection
+	err := l.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		err := conn.Raw("select pg_try_advisory_lock(?)", key).Scan(&acquired).Error
+		if err != nil || !acquired {
+			return err
+		}
+		defer conn.WithContext(context.WithoutCancel(ctx)).Exec("select pg_advisory_unlock(?)", key)
+
+		return fn(ctx)
+	})
+	return acquired, err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"gorm.io/gorm"
+)
+
+type JobRunRepo interface {
+	Create(ctx context.Context, run *model.JobRun) error
+	Update(ctx context.Context, run *model.JobRun) error
+	HasSucceeded(ctx context.Context, jobName string, businessDate time.Time) (bool, error)
+	List(ctx context.Context, f JobRunFilter) ([]*model.JobRun, *lib.Cursor, error)
+}
+
+// JobRunFilter narrows down and pages job runs, newest first
+type JobRunFilter struct {
+	JobName string
+	Status  constant.JobRunStatus
+	After   *lib.Cursor
+	Limit   int
+}
+
+type jobRunRepo struct {
+	db *gorm.DB
+}
+
+func NewJobRunRepo(db *gorm.DB) JobRunRepo {
+	return &jobRunRepo{db: db}
+}
+
+func (r *jobRunRepo) Create(ctx context.Context, run *model.JobRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *jobRunRepo) Update(ctx context.Context, run *model.JobRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+// HasSucceeded reports whether the job already completed successfully for the business date
+func (r *jobRunRepo) HasSucceeded(ctx context.Context, jobName string, businessDate time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.JobRun{}).
+		Where("job_name = ? and business_date = ? and status = ?", jobName, businessDate, constant.JobRunStatusSucceeded).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *jobRunRepo) List(ctx context.Context, f JobRunFilter) ([]*model.JobRun, *lib.Cursor, error) {
+	q := r.db.WithContext(ctx).Model(&model.JobRun{})
+
+	if f.JobName != "" {
+		q = q.Where("job_name = ?", f.JobName)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.After != nil {
+		q = q.Where("id < ?", f.After.ID)
+	}
+
+	var runs = make([]*model.JobRun, 0)
+	err := q.Order("id desc").Limit(f.Limit + 1).Find(&runs).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(runs) <= f.Limit {
+		return runs, nil, nil
+	}
+	runs = runs[:f.Limit]
+	return runs, &lib.Cursor{ID: runs[len(runs)-1].ID}, nil
+}
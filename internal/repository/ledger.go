@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type LedgerRepo interface {
+	WithTx(tx *gorm.DB) LedgerRepo
+	CreateEntry(ctx context.Context, e *model.JournalEntry) error
+	GetEntry(ctx context.Context, e *model.JournalEntry) error
+	IsReversed(ctx context.Context, entryID string) (bool, error)
+	ListEntries(ctx context.Context, f JournalEntryFilter) ([]*model.JournalEntry, *lib.Cursor, error)
+	GetLoanAccountBalance(ctx context.Context, loanID, accountCode string) (decimal.Decimal, error)
+	IsLoanWrittenOff(ctx context.Context, loanID string) (bool, error)
+	HasUnreversedEntries(ctx context.Context, loanID, exceptID string) (bool, error)
+	GetTrialBalance(ctx context.Context, postedBefore *time.Time) ([]*model.TrialBalanceLine, error)
+	FindUnbalancedEntryIDs(ctx context.Context) ([]string, error)
+}
+
+// JournalEntryFilter narrows down and pages journal entries in posting order
+type JournalEntryFilter struct {
+	LoanID string
+	Type   constant.JournalEntryType
+	After  *lib.Cursor
+	Limit  int
+}
+
+type ledgerRepo struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepo(db *gorm.DB) LedgerRepo {
+	return &ledgerRepo{db: db}
+}
+
+func (r *ledgerRepo) WithTx(tx *gorm.DB) LedgerRepo {
+	return &ledgerRepo{db: tx}
+}
+
+// CreateEntry inserts the entry together with its lines
+func (r *ledgerRepo) CreateEntry(ctx context.Context, e *model.JournalEntry) error {
+	return r.db.WithContext(ctx).Create(e).Error
+}
+
+func (r *ledgerRepo) GetEntry(ctx context.Context, e *model.JournalEntry) error {
+	return r.db.WithContext(ctx).Preload("Lines").First(e).Error
+}
+
+func (r *ledgerRepo) IsReversed(ctx context.Context, entryID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.JournalEntry{}).
+		Where("reversal_of_id = ?", entryID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ledgerRepo) ListEntries(ctx context.Context, f JournalEntryFilter) ([]*model.JournalEntry, *lib.Cursor, error) {
+	q := r.db.WithContext(ctx).
+		Model(&model.JournalEntry{}).
+		Preload("Lines")
+
+	if f.LoanID != "" {
+		q = q.Where("loan_id = ?", f.LoanID)
+	}
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+	if f.After != nil {
+		q = q.Where("id > ?", f.After.ID)
+	}
+
+	var entries = make([]*model.JournalEntry, 0)
+	err := q.Order("id asc").Limit(f.Limit + 1).Find(&entries).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(entries) <= f.Limit {
+		return entries, nil, nil
+	}
+	entries = entries[:f.Limit]
+	return entries, &lib.Cursor{ID: entries[len(entries)-1].ID}, nil
+}
+
+// GetLoanAccountBalance returns debits minus credits posted to the account for the loan
+func (r *ledgerRepo) GetLoanAccountBalance(ctx context.Context, loanID, accountCode string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := r.db.WithContext(ctx).
+		Table("journal_lines jl").
+		Joins("join journal_entries je on je.id = jl.journal_entry_id").
+		Where("je.loan_id = ? and jl.account_code = ?", loanID, accountCode).
+		Select("coalesce(sum(jl.debit - jl.credit), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
+
+// IsLoanWrittenOff reports whether the loan has a write-off entry that has not been reversed
+func (r *ledgerRepo) IsLoanWrittenOff(ctx context.Context, loanID string) (bool, error) {
+	reversal := r.db.
+		Table("journal_entries r").
+		Select("1").
+		Where("r.reversal_of_id = je.id")
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("journal_entries je").
+		Where("je.loan_id = ? and je.type = ?", loanID, constant.JournalEntryTypeWriteOff).
+		Where("not exists (?)", reversal).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// HasUnreversedEntries reports whether the loan has an entry other than exceptID that is neither a reversal nor reversed
+func (r *ledgerRepo) HasUnreversedEntries(ctx context.Context, loanID, exceptID string) (bool, error) {
+	reversal := r.db.
+		Table("journal_entries r").
+		Select("1").
+		Where("r.reversal_of_id = je.id")
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("journal_entries je").
+		Where("je.loan_id = ? and je.id <> ? and je.type <> ?", loanID, exceptID, constant.JournalEntryTypeReversal).
+		Where("not exists (?)", reversal).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetTrialBalance sums the lines of every account per currency, optionally only for entries posted before the given
+// time. Every account is listed in each currency posted, with zero turnover where it has none.
+func (r *ledgerRepo) GetTrialBalance(ctx context.Context, postedBefore *time.Time) ([]*model.TrialBalanceLine, error) {
+	entries := r.db.Model(&model.JournalEntry{}).Select("id")
+	if postedBefore != nil {
+		entries = entries.Where("posted_at < ?", *postedBefore)
+	}
+	currencies := r.db.Model(&model.JournalLine{}).Distinct("currency").Where("journal_entry_id in (?)", entries)
+
+	var lines = make([]*model.TrialBalanceLine, 0)
+	err := r.db.WithContext(ctx).
+		Table("ledger_accounts la").
+		Select(`c.currency, la.code as account_code, la.name as account_name, la.type as account_type,
+			coalesce(sum(jl.debit), 0) as debit,
+			coalesce(sum(jl.credit), 0) as credit`).
+		Joins("cross join (?) c", currencies).
+		Joins("left join journal_lines jl on jl.account_code = la.code and jl.currency = c.currency and jl.journal_entry_id in (?)", entries).
+		Group("c.currency, la.code, la.name, la.type").
+		Order("c.currency asc, la.code asc").
+		Scan(&lines).Error
+	return lines, err
+}
+
+// FindUnbalancedEntryIDs lists entries whose stored lines violate the debit equals credit invariant or mix currencies
+func (r *ledgerRepo) FindUnbalancedEntryIDs(ctx context.Context) ([]string, error) {
+	var ids = make([]string, 0)
+	err := r.db.WithContext(ctx).
+		Model(&model.JournalLine{}).
+		Select("journal_entry_id").
+		Group("journal_entry_id").
+		Having("sum(debit) <> sum(credit) or count(distinct currency) > 1").
+		Order("journal_entry_id asc").
+		Scan(&ids).Error
+	return ids, err
+}
@@ -1,16 +1,43 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LoanRepo interface {
 	WithTx(tx *gorm.DB) LoanRepo
-	Create(l *model.Loan) error
-	Get(l *model.Loan) error
-	FindByBorrowerID(borrowerID string) ([]*model.LoanWithCompleteStatus, error)
+	Create(ctx context.Context, l *model.Loan) error
+	Get(ctx context.Context, l *model.Loan) error
+	GetForUpdate(ctx context.Context, l *model.Loan) error
+	List(ctx context.Context, f LoanFilter) ([]*model.LoanWithCompleteStatus, *lib.Cursor, error)
+	GetStatsByBorrowerID(ctx context.Context, borrowerID string) (model.LoanStats, error)
+	FindAccruing(ctx context.Context, from, to time.Time, afterID string, limit int) ([]*model.Loan, error)
+	FindUnpaidTimezones(ctx context.Context) ([]string, error)
+	UpdateDaysPastDue(ctx context.Context, now time.Time) (int64, error)
+	UpdateBalances(ctx context.Context, l *model.Loan) (bool, error)
+	BumpVersion(ctx context.Context, l *model.Loan) (bool, error)
+	UpdateOverdueBalances(ctx context.Context) (int64, error)
+	FindAfter(ctx context.Context, afterID string, limit int) ([]*model.Loan, error)
+}
+
+// LoanFilter narrows down and pages loans. An empty BorrowerID searches across all borrowers.
+// CreatedTo is exclusive and Desc sorts newest first.
+type LoanFilter struct {
+	BorrowerID  string
+	Status      constant.LoanStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Desc        bool
+	After       *lib.Cursor
+	Limit       int
 }
 
 type loanRepo struct {
@@ -25,29 +52,209 @@ func (r *loanRepo) WithTx(tx *gorm.DB) LoanRepo {
 	return &loanRepo{db: tx}
 }
 
-func (r *loanRepo) Create(l *model.Loan) error {
-	return r.db.Create(l).Error
+func (r *loanRepo) Create(ctx context.Context, l *model.Loan) error {
+	return r.db.WithContext(ctx).Create(l).Error
+}
+
+func (r *loanRepo) Get(ctx context.Context, l *model.Loan) error {
+	return r.db.WithContext(ctx).First(l).Error
 }
 
-func (r *loanRepo) Get(l *model.Loan) error {
-	return r.db.First(l).Error
+// GetForUpdate fetches the loan and locks its row until the transaction ends, so changes to the loan are made one at a time
+func (r *loanRepo) GetForUpdate(ctx context.Context, l *model.Loan) error {
+	return r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(l).Error
 }
 
-func (r *loanRepo) FindByBorrowerID(borrowerID string) ([]*model.LoanWithCompleteStatus, error) {
+func (r *loanRepo) List(ctx context.Context, f LoanFilter) ([]*model.LoanWithCompleteStatus, *lib.Cursor, error) {
+	q := r.db.WithContext(ctx).
+		Select("l.*, l.outstanding_total = 0 as is_completed").
+		Table("loans l")
+
+	if f.BorrowerID != "" {
+		q = q.Where("l.borrower_id = ?", f.BorrowerID)
+	}
+	switch f.Status {
+	case constant.LoanStatusActive:
+		q = q.Where("l.outstanding_total > 0")
+	case constant.LoanStatusCompleted:
+		q = q.Where("l.outstanding_total = 0")
+	}
+	if f.CreatedFrom != nil {
+		q = q.Where("l.created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		q = q.Where("l.created_at < ?", *f.CreatedTo)
+	}
+
+	// UUIDv7 ids are time-ordered, so paging on id follows creation order
+	op, dir := ">", "asc"
+	if f.Desc {
+		op, dir = "<", "desc"
+	}
+	if f.After != nil {
+		q = q.Where(fmt.Sprintf("l.id %s ?", op), f.After.ID)
+	}
+
 	var loans = make([]*model.LoanWithCompleteStatus, 0)
-	err := r.db.
-		Select(
-			"l.*",
-			`case
-						when count(lp.id) > 0
-							then false
-						else true
-					end as is_completed`,
-		).
+	err := q.Order(fmt.Sprintf("l.id %s", dir)).Limit(f.Limit + 1).Scan(&loans).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(loans) <= f.Limit {
+		return loans, nil, nil
+	}
+	loans = loans[:f.Limit]
+	return loans, &lib.Cursor{ID: loans[len(loans)-1].ID}, nil
+}
+
+// GetStatsByBorrowerID aggregates the loans of a borrower, totalling amounts per currency
+func (r *loanRepo) GetStatsByBorrowerID(ctx context.Context, borrowerID string) (model.LoanStats, error) {
+	stats := model.LoanStats{
+		Currencies: make([]*model.LoanCurrencyStats, 0),
+	}
+	err := r.db.WithContext(ctx).
 		Table("loans l").
-		Joins("left join loan_payments lp on lp.loan_id = l.id and lp.status = ?", constant.LoanPaymentStatusUnpaid).
+		Select(`l.currency,
+			count(*) as loan_count,
+			count(*) filter (where l.outstanding_total > 0) as active_loan_count,
+			coalesce(sum(l.principal), 0) as total_principal,
+			coalesce(sum(l.outstanding_total), 0) as total_outstanding`).
 		Where("l.borrower_id = ?", borrowerID).
-		Group("l.id").
-		Scan(&loans).Error
+		Group("l.currency").
+		Order("l.currency").
+		Scan(&stats.Currencies).Error
+	if err != nil {
+		return stats, err
+	}
+
+	for _, cs := range stats.Currencies {
+		cs.TotalPrincipal = cs.TotalPrincipal.In(cs.Currency)
+		cs.TotalOutstanding = cs.TotalOutstanding.In(cs.Currency)
+		stats.LoanCount += cs.LoanCount
+		stats.ActiveLoanCount += cs.ActiveLoanCount
+	}
+	return stats, nil
+}
+
+// FindAccruing pages through loans whose term overlaps [from, to), that is loans disbursed before to
+// with an installment falling due after from. Loans whose installments were cancelled no longer accrue.
+func (r *loanRepo) FindAccruing(ctx context.Context, from, to time.Time, afterID string, limit int) ([]*model.Loan, error) {
+	dueAfter := r.db.
+		Table("loan_payments lp").
+		Select("1").
+		Where("lp.loan_id = loans.id and lp.due_date > ? and lp.status <> ?", from, constant.LoanPaymentStatusCancelled)
+
+	var loans = make([]*model.Loan, 0)
+	err := r.db.WithContext(ctx).
+		Where("created_at < ? and exists (?)", to, dueAfter).
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&loans).Error
+	return loans, err
+}
+
+// FindUnpaidTimezones returns the timezones of the loans that have unpaid installments
+func (r *loanRepo) FindUnpaidTimezones(ctx context.Context) ([]string, error) {
+	unpaid := r.db.
+		Table("loan_payments lp").
+		Select("1").
+		Where("lp.loan_id = loans.id and lp.status = ?", constant.LoanPaymentStatusUnpaid)
+
+	var timezones = make([]string, 0)
+	err := r.db.WithContext(ctx).
+		Model(&model.Loan{}).
+		Distinct("timezone").
+		Where("exists (?)", unpaid).
+		Order("timezone asc").
+		Pluck("timezone", &timezones).Error
+	return timezones, err
+}
+
+// UpdateDaysPastDue recomputes the days past due of every loan from its oldest overdue installment, moving the loans
+// that changed to their next version and returning how many there were
+func (r *loanRepo) UpdateDaysPastDue(ctx context.Context, now time.Time) (int64, error) {
+	oldestOverdue := r.db.
+		Table("loan_payments lp").
+		Select("min(lp.due_date)").
+		Where("lp.loan_id = loans.id and lp.status = ?", constant.LoanPaymentStatusOverdue)
+	dpd := gorm.Expr("coalesce(ceil(extract(epoch from (?::timestamp - (?))) / 86400), 0)::integer", now, oldestOverdue)
+
+	res := r.db.WithContext(ctx).
+		Model(&model.Loan{}).
+		Where("days_past_due <> (?)", dpd).
+		Updates(map[string]any{
+			"days_past_due": dpd,
+			"version":       gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// UpdateBalances stores the running totals of the loan and moves it to the next version, provided it is still at the
+// version it was read at. It reports false when the loan changed in the meantime.
+func (r *loanRepo) UpdateBalances(ctx context.Context, l *model.Loan) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Loan{}).
+		Where("id = ? and version = ?", l.ID, l.Version).
+		Updates(map[string]any{
+			"outstanding_principal": l.OutstandingPrincipal,
+			"outstanding_total":     l.OutstandingTotal,
+			"paid_total":            l.PaidTotal,
+			"next_due_date":         l.NextDueDate,
+			"installments_overdue":  l.InstallmentsOverdue,
+			"version":               gorm.Expr("version + 1"),
+		})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	l.Version++
+	return true, nil
+}
+
+// BumpVersion moves the loan to the next version for a change stored elsewhere, such as its write-off in the ledger,
+// provided it is still at the version it was read at. It reports false when the loan changed in the meantime.
+func (r *loanRepo) BumpVersion(ctx context.Context, l *model.Loan) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Loan{}).
+		Where("id = ? and version = ?", l.ID, l.Version).
+		Update("version", gorm.Expr("version + 1"))
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	l.Version++
+	return true, nil
+}
+
+// UpdateOverdueBalances recomputes the overdue installment count and next due date of every loan from its installments,
+// moving the loans that changed to their next version and returning how many there were. Moving installments to
+// OVERDUE leaves the amounts of the loan alone.
+func (r *loanRepo) UpdateOverdueBalances(ctx context.Context) (int64, error) {
+	overdueCount := r.db.
+		Table("loan_payments lp").
+		Select("count(*)").
+		Where("lp.loan_id = loans.id and lp.status = ?", constant.LoanPaymentStatusOverdue)
+	nextDueDate := r.db.
+		Table("loan_payments lp").
+		Select("min(lp.due_date)").
+		Where("lp.loan_id = loans.id and lp.status = ?", constant.LoanPaymentStatusUnpaid)
+
+	res := r.db.WithContext(ctx).
+		Model(&model.Loan{}).
+		Where("installments_overdue <> (?) or next_due_date is distinct from (?)", overdueCount, nextDueDate).
+		Updates(map[string]any{
+			"installments_overdue": gorm.Expr("(?)", overdueCount),
+			"next_due_date":        gorm.Expr("(?)", nextDueDate),
+			"version":              gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// FindAfter pages through all loans in id order
+func (r *loanRepo) FindAfter(ctx context.Context, afterID string, limit int) ([]*model.Loan, error) {
+	var loans = make([]*model.Loan, 0)
+	err := r.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&loans).Error
 	return loans, err
 }
@@ -1,21 +1,44 @@
 package repository
 
 import (
+	"context"
 	"time"
 
 	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
-	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 type LoanPaymentRepo interface {
 	WithTx(tx *gorm.DB) LoanPaymentRepo
-	CreateBulk(lps []*model.LoanPayment) error
-	GetTotalOutstandingByLoanID(loanID string) (decimal.Decimal, error)
-	GetTotalOutstandingByBorrowerID(borrowerID string) (decimal.Decimal, error)
-	Find(lp model.LoanPayment) ([]*model.LoanPayment, error)
-	ChangeStatusToPaid(loanIds []string, paidAt time.Time) error
+	CreateBulk(ctx context.Context, lps []*model.LoanPayment) error
+	Get(ctx context.Context, lp *model.LoanPayment) error
+	Find(ctx context.Context, lp model.LoanPayment) ([]*model.LoanPayment, error)
+	FindOutstanding(ctx context.Context, loanID string) ([]*model.LoanPayment, error)
+	List(ctx context.Context, f LoanPaymentFilter) ([]*model.LoanPayment, *lib.Cursor, error)
+	GetStatsByBorrowerID(ctx context.Context, borrowerID string, now time.Time) (model.LoanPaymentStats, error)
+	ChangeStatusToPaid(ctx context.Context, loanIds []string, paidAt time.Time, entryID string) error
+	UpdateStatus(ctx context.Context, lp *model.LoanPayment, from constant.LoanPaymentStatus) (bool, error)
+	RecordPrincipal(ctx context.Context, lp *model.LoanPayment) (bool, error)
+	MarkOverdue(ctx context.Context, timezone string, dueBefore time.Time) (int64, error)
+	FindLateFeeCandidates(ctx context.Context, currency string, dueBefore time.Time, afterID string, limit int) ([]*model.LoanPayment, error)
+	ApplyLateFee(ctx context.Context, id string, fee lib.Money, entryID string) (bool, error)
+	RemoveLateFee(ctx context.Context, id string, entryID string) (bool, error)
+	Cancel(ctx context.Context, loanID string) (int64, error)
+	FindDueBetween(ctx context.Context, from, to time.Time, afterID string, limit int) ([]*model.LoanPayment, error)
+}
+
+// LoanPaymentFilter narrows down and pages the installments of a loan in due date order.
+// DueFrom and DueTo are compared with the local due date, DueTo is exclusive. OverdueOnly keeps installments in OVERDUE status.
+type LoanPaymentFilter struct {
+	LoanID      string
+	Status      constant.LoanPaymentStatus
+	DueFrom     *time.Time
+	DueTo       *time.Time
+	OverdueOnly bool
+	After       *lib.Cursor
+	Limit       int
 }
 
 type loanPaymentRepo struct {
@@ -30,48 +53,223 @@ func (r *loanPaymentRepo) WithTx(tx *gorm.DB) LoanPaymentRepo {
 	return &loanPaymentRepo{db: tx}
 }
 
-func (r *loanPaymentRepo) CreateBulk(lps []*model.LoanPayment) error {
-	return r.db.Create(lps).Error
+func (r *loanPaymentRepo) CreateBulk(ctx context.Context, lps []*model.LoanPayment) error {
+	return r.db.WithContext(ctx).Create(lps).Error
 }
 
-func (r *loanPaymentRepo) GetTotalOutstandingByLoanID(loanID string) (decimal.Decimal, error) {
-	var total decimal.Decimal
-	err := r.db.Model(&model.LoanPayment{}).
-		Where(&model.LoanPayment{
-			LoanID: loanID,
-			Status: constant.LoanPaymentStatusUnpaid,
-		}).
-		Select("coalesce(sum(amount), 0)").
-		Scan(&total).Error
-	return total, err
+func (r *loanPaymentRepo) Get(ctx context.Context, lp *model.LoanPayment) error {
+	return r.db.WithContext(ctx).First(lp).Error
 }
 
-func (r *loanPaymentRepo) GetTotalOutstandingByBorrowerID(borrowerID string) (decimal.Decimal, error) {
-	var total decimal.Decimal
-	err := r.db.Model(&model.LoanPayment{}).
-		Where(&model.LoanPayment{
-			BorrowerID: borrowerID,
-			Status:     constant.LoanPaymentStatusUnpaid,
-		}).
-		Select("coalesce(sum(amount), 0)").
-		Scan(&total).Error
-	return total, err
+func (r *loanPaymentRepo) Find(ctx context.Context, lp model.LoanPayment) ([]*model.LoanPayment, error) {
+	var lps = make([]*model.LoanPayment, 0)
+	err := r.db.WithContext(ctx).Where(&lp).Order("due_date asc").Find(&lps).Error
+	return lps, err
 }
 
-func (r *loanPaymentRepo) Find(lp model.LoanPayment) ([]*model.LoanPayment, error) {
+func (r *loanPaymentRepo) FindOutstanding(ctx context.Context, loanID string) ([]*model.LoanPayment, error) {
 	var lps = make([]*model.LoanPayment, 0)
-	err := r.db.Where(&lp).Order("due_date asc").Find(&lps).Error
+	err := r.db.WithContext(ctx).
+		Where("loan_id = ? and status in ?", loanID, constant.LoanPaymentOutstandingStatuses).
+		Order("due_date asc").
+		Find(&lps).Error
 	return lps, err
 }
 
-func (r *loanPaymentRepo) ChangeStatusToPaid(loanIds []string, paidAt time.Time) error {
-	return r.db.Model(&model.LoanPayment{}).
-		Where(&model.LoanPayment{
-			Status: constant.LoanPaymentStatusUnpaid,
-		}).
+func (r *loanPaymentRepo) List(ctx context.Context, f LoanPaymentFilter) ([]*model.LoanPayment, *lib.Cursor, error) {
+	q := r.db.WithContext(ctx).
+		Model(&model.LoanPayment{}).
+		Where("loan_id = ?", f.LoanID)
+
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.DueFrom != nil {
+		q = q.Where("local_due_date >= ?", *f.DueFrom)
+	}
+	if f.DueTo != nil {
+		q = q.Where("local_due_date < ?", *f.DueTo)
+	}
+	if f.OverdueOnly {
+		q = q.Where("status = ?", constant.LoanPaymentStatusOverdue)
+	}
+	if f.After != nil {
+		after, err := time.Parse(time.RFC3339Nano, f.After.Value)
+		if err != nil {
+			return nil, nil, lib.NewValidationError(constant.ErrCodeInvalidCursor, "invalid cursor").Wrap(err)
+		}
+		q = q.Where("(due_date, id) > (?, ?)", after, f.After.ID)
+	}
+
+	var lps = make([]*model.LoanPayment, 0)
+	err := q.Order("due_date asc, id asc").Limit(f.Limit + 1).Find(&lps).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(lps) <= f.Limit {
+		return lps, nil, nil
+	}
+	lps = lps[:f.Limit]
+	last := lps[len(lps)-1]
+	return lps, &lib.Cursor{ID: last.ID, Value: last.DueDate.Format(time.RFC3339Nano)}, nil
+}
+
+// GetStatsByBorrowerID aggregates the installments of a borrower as of now, totalling amounts per currency. Installments
+// count as overdue when their status is OVERDUE or when they are still UNPAID but due before now, so a later now previews
+// what will be overdue by then.
+func (r *loanPaymentRepo) GetStatsByBorrowerID(ctx context.Context, borrowerID string, now time.Time) (model.LoanPaymentStats, error) {
+	stats := model.LoanPaymentStats{
+		Currencies: make([]*model.LoanPaymentCurrencyStats, 0),
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.LoanPayment{}).
+		Select(`currency,
+			coalesce(sum(amount + late_fee) filter (where status = @paid), 0) as total_repaid,
+			coalesce(sum(amount + late_fee) filter (where status in @outstanding), 0) as total_outstanding,
+			coalesce(sum(amount + late_fee) filter (where status = @overdue or (status = @unpaid and due_date < @now)), 0) as overdue_amount,
+			count(*) filter (where status = @overdue or (status = @unpaid and due_date < @now)) as overdue_count,
+			min(due_date) filter (where status = @overdue or (status = @unpaid and due_date < @now)) as oldest_overdue_due_date,
+			min(due_date) filter (where status = @unpaid and due_date >= @now) as next_due_date`,
+			map[string]any{
+				"paid":        constant.LoanPaymentStatusPaid,
+				"unpaid":      constant.LoanPaymentStatusUnpaid,
+				"overdue":     constant.LoanPaymentStatusOverdue,
+				"outstanding": constant.LoanPaymentOutstandingStatuses,
+				"now":         now,
+			}).
+		Where("borrower_id = ?", borrowerID).
+		Group("currency").
+		Order("currency").
+		Scan(&stats.Currencies).Error
+	if err != nil {
+		return stats, err
+	}
+
+	for _, cs := range stats.Currencies {
+		if cs.NextDueDate != nil {
+			err = r.db.WithContext(ctx).
+				Model(&model.LoanPayment{}).
+				Where("borrower_id = ? and currency = ? and status = ? and due_date = ?", borrowerID, cs.Currency, constant.LoanPaymentStatusUnpaid, *cs.NextDueDate).
+				Select("coalesce(sum(amount + late_fee), 0)").
+				Scan(&cs.NextDueAmount).Error
+			if err != nil {
+				return stats, err
+			}
+		}
+		cs.TotalRepaid = cs.TotalRepaid.In(cs.Currency)
+		cs.TotalOutstanding = cs.TotalOutstanding.In(cs.Currency)
+		cs.OverdueAmount = cs.OverdueAmount.In(cs.Currency)
+		cs.NextDueAmount = cs.NextDueAmount.In(cs.Currency)
+
+		stats.OverdueCount += cs.OverdueCount
+		if cs.OldestOverdueDueDate != nil && (stats.OldestOverdueDueDate == nil || cs.OldestOverdueDueDate.Before(*stats.OldestOverdueDueDate)) {
+			stats.OldestOverdueDueDate = cs.OldestOverdueDueDate
+		}
+	}
+	return stats, nil
+}
+
+// ChangeStatusToPaid marks the installments as paid by the repayment entry
+func (r *loanPaymentRepo) ChangeStatusToPaid(ctx context.Context, loanIds []string, paidAt time.Time, entryID string) error {
+	return r.db.WithContext(ctx).Model(&model.LoanPayment{}).
+		Where("status in ?", model.LoanPaymentStatusesBefore(constant.LoanPaymentStatusPaid)).
 		Where("id in ?", loanIds).
 		Updates(&model.LoanPayment{
-			Status: constant.LoanPaymentStatusPaid,
-			PaidAt: &paidAt,
+			Status:           constant.LoanPaymentStatusPaid,
+			PaidAt:           &paidAt,
+			SettledByEntryID: &entryID,
 		}).Error
 }
+
+// UpdateStatus stores the status the installment was transitioned to, provided it is still in status from.
+// It reports false when the installment changed in the meantime.
+func (r *loanPaymentRepo) UpdateStatus(ctx context.Context, lp *model.LoanPayment, from constant.LoanPaymentStatus) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.LoanPayment{}).
+		Where("id = ? and status = ?", lp.ID, from).
+		Updates(map[string]any{
+			"status":              lp.Status,
+			"paid_at":             lp.PaidAt,
+			"settled_by_entry_id": lp.SettledByEntryID,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// RecordPrincipal stores the principal of an installment that was created without one. It reports false when the
+// installment already had its principal recorded.
+func (r *loanPaymentRepo) RecordPrincipal(ctx context.Context, lp *model.LoanPayment) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.LoanPayment{}).
+		Where("id = ? and principal is null", lp.ID).
+		Update("principal", lp.Principal)
+	return res.RowsAffected > 0, res.Error
+}
+
+// MarkOverdue moves the unpaid installments of loans in the timezone whose local due date is before the given date to
+// OVERDUE, returning how many were moved
+func (r *loanPaymentRepo) MarkOverdue(ctx context.Context, timezone string, dueBefore time.Time) (int64, error) {
+	loans := r.db.Model(&model.Loan{}).Select("id").Where("timezone = ?", timezone)
+
+	res := r.db.WithContext(ctx).Model(&model.LoanPayment{}).
+		Where("status in ? and local_due_date < ?", model.LoanPaymentStatusesBefore(constant.LoanPaymentStatusOverdue), dueBefore).
+		Where("loan_id in (?)", loans).
+		Update("status", constant.LoanPaymentStatusOverdue)
+	return res.RowsAffected, res.Error
+}
+
+// FindLateFeeCandidates pages through overdue installments in the currency due before the given time that have no
+// late fee yet
+func (r *loanPaymentRepo) FindLateFeeCandidates(ctx context.Context, currency string, dueBefore time.Time, afterID string, limit int) ([]*model.LoanPayment, error) {
+	var lps = make([]*model.LoanPayment, 0)
+	err := r.db.WithContext(ctx).
+		Where("status = ? and due_date < ? and late_fee = 0", constant.LoanPaymentStatusOverdue, dueBefore).
+		Where("currency = ?", currency).
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&lps).Error
+	return lps, err
+}
+
+// ApplyLateFee charges the fee booked by the entry on an overdue installment in the currency of the fee unless it
+// already carries one, reporting whether it was charged
+func (r *loanPaymentRepo) ApplyLateFee(ctx context.Context, id string, fee lib.Money, entryID string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.LoanPayment{}).
+		Where("id = ? and status = ? and late_fee = 0 and currency = ?", id, constant.LoanPaymentStatusOverdue, fee.Currency).
+		Updates(map[string]any{
+			"late_fee":          fee.Amount,
+			"late_fee_entry_id": entryID,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// RemoveLateFee takes the late fee booked by the entry off its installment as long as the installment is outstanding,
+// reporting whether it did
+func (r *loanPaymentRepo) RemoveLateFee(ctx context.Context, id string, entryID string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.LoanPayment{}).
+		Where("id = ? and late_fee_entry_id = ? and status in ?", id, entryID, constant.LoanPaymentOutstandingStatuses).
+		Updates(map[string]any{
+			"late_fee":          0,
+			"late_fee_entry_id": nil,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Cancel moves every outstanding installment of the loan to CANCELLED, returning how many were moved
+func (r *loanPaymentRepo) Cancel(ctx context.Context, loanID string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.LoanPayment{}).
+		Where("loan_id = ? and status in ?", loanID, model.LoanPaymentStatusesBefore(constant.LoanPaymentStatusCancelled)).
+		Update("status", constant.LoanPaymentStatusCancelled)
+	return res.RowsAffected, res.Error
+}
+
+// FindDueBetween pages through unpaid installments falling due in [from, to)
+func (r *loanPaymentRepo) FindDueBetween(ctx context.Context, from, to time.Time, afterID string, limit int) ([]*model.LoanPayment, error) {
+	var lps = make([]*model.LoanPayment, 0)
+	err := r.db.WithContext(ctx).
+		Where("status = ? and due_date >= ? and due_date < ?", constant.LoanPaymentStatusUnpaid, from, to).
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&lps).Error
+	return lps, err
+}
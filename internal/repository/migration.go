@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"gorm.io/gorm"
+)
+
+type MigrationRepo interface {
+	WithTx(tx *gorm.DB) MigrationRepo
+	CreateTable(ctx context.Context) error
+	ListApplied(ctx context.Context) ([]*model.SchemaMigration, error)
+	Exec(ctx context.Context, sql string) error
+	Create(ctx context.Context, m *model.SchemaMigration) error
+	Delete(ctx context.Context, version int64) error
+}
+
+type migrationRepo struct {
+	db *gorm.DB
+}
+
+func NewMigrationRepo(db *gorm.DB) MigrationRepo {
+	return &migrationRepo{db: db}
+}
+
+func (r *migrationRepo) WithTx(tx *gorm.DB) MigrationRepo {
+	return &migrationRepo{db: tx}
+}
+
+// CreateTable creates the table recording applied migrations when it does not exist yet
+func (r *migrationRepo) CreateTable(ctx context.Context) error {
+	return r.db.WithContext(ctx).Exec(`create table if not exists schema_migrations (
+		version bigint primary key,
+		name varchar(100) not null,
+		applied_at timestamp not null
+	)`).Error
+}
+
+// ListApplied returns the applied migrations, oldest version first
+func (r *migrationRepo) ListApplied(ctx context.Context) ([]*model.SchemaMigration, error) {
+	var ms = make([]*model.SchemaMigration, 0)
+	err := r.db.WithContext(ctx).Order("version").Find(&ms).Error
+	return ms, err
+}
+
+// Exec runs the SQL of a migration, which may hold several statements
+func (r *migrationRepo) Exec(ctx context.Context, sql string) error {
+	return r.db.WithContext(ctx).Exec(sql).Error
+}
+
+func (r *migrationRepo) Create(ctx context.Context, m *model.SchemaMigration) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *migrationRepo) Delete(ctx context.Context, version int64) error {
+	return r.db.WithContext(ctx).Where("version = ?", version).Delete(&model.SchemaMigration{}).Error
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepo interface {
+	WithTx(tx *gorm.DB) OutboxRepo
+	Create(ctx context.Context, e *model.OutboxEvent) (bool, error)
+}
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) OutboxRepo {
+	return &outboxRepo{db: db}
+}
+
+func (r *outboxRepo) WithTx(tx *gorm.DB) OutboxRepo {
+	return &outboxRepo{db: tx}
+}
+
+// Create inserts the event unless one with the same dedup key was already emitted, reporting whether it was inserted
+func (r *outboxRepo) Create(ctx context.Context, e *model.OutboxEvent) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "dedup_key"}},
+			DoNothing: true,
+		}).
+		Create(e)
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SimulationClockRepo interface {
+	Get(ctx context.Context) (*model.SimulationClock, error)
+	Save(ctx context.Context, simulatedAt time.Time) error
+}
+
+type simulationClockRepo struct {
+	db *gorm.DB
+}
+
+func NewSimulationClockRepo(db *gorm.DB) SimulationClockRepo {
+	return &simulationClockRepo{db: db}
+}
+
+// Get returns the stored simulated clock, or gorm.ErrRecordNotFound when the clock was never stored
+func (r *simulationClockRepo) Get(ctx context.Context) (*model.SimulationClock, error) {
+	c := &model.SimulationClock{}
+	err := r.db.WithContext(ctx).Where("id = ?", model.SimulationClockID).First(c).Error
+	return c, err
+}
+
+// Save stores the time the simulated clock was moved to
+func (r *simulationClockRepo) Save(ctx context.Context, simulatedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"simulated_at", "updated_at"}),
+		}).
+		Create(&model.SimulationClock{ID: model.SimulationClockID, SimulatedAt: simulatedAt, UpdatedAt: time.Now().UTC()}).Error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type TxManager interface {
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+type txManager struct {
+	db *gorm.DB
+}
+
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+func (m *txManager) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Transaction(fn)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+const accrualBatchSize = 100
+
+type AccrualService struct {
+	loanRepo            repository.LoanRepo
+	loanPaymentRepo     repository.LoanPaymentRepo
+	interestAccrualRepo repository.InterestAccrualRepo
+	ledgerRepo          repository.LedgerRepo
+	txManager           repository.TxManager
+}
+
+func NewAccrualService(
+	loanRepo repository.LoanRepo,
+	loanPaymentRepo repository.LoanPaymentRepo,
+	interestAccrualRepo repository.InterestAccrualRepo,
+	ledgerRepo repository.LedgerRepo,
+	txManager repository.TxManager,
+) *AccrualService {
+	return &AccrualService{
+		loanRepo:            loanRepo,
+		loanPaymentRepo:     loanPaymentRepo,
+		interestAccrualRepo: interestAccrualRepo,
+		ledgerRepo:          ledgerRepo,
+		txManager:           txManager,
+	}
+}
+
+// AccrualResult summarises an accrual run. The accrued interest is totalled per currency.
+type AccrualResult struct {
+	Loans   int                  `json:"loans"`
+	Accrued int                  `json:"accrued"`
+	Skipped int                  `json:"skipped"`
+	Amounts map[string]lib.Money `json:"amounts" swaggertype:"object,string"`
+}
+
+// AccrueInterest records the interest every loan earned on each day from from to to, both inclusive.
+// Days that were already accrued are skipped, so the same range can be run again safely.
+// Written-off loans stop accruing.
+func (s *AccrualService) AccrueInterest(ctx context.Context, from, to time.Time) (*AccrualResult, error) {
+	from, to = lib.TruncateToDate(from), lib.TruncateToDate(to)
+	if to.Before(from) {
+		return nil, lib.NewValidationError(constant.ErrCodeInvalidRequest, "accrual range ends before it starts")
+	}
+
+	result := &AccrualResult{
+		Amounts: make(map[string]lib.Money),
+	}
+	afterID := ""
+	for {
+		loans, err := s.loanRepo.FindAccruing(ctx, from, to.AddDate(0, 0, 1), afterID, accrualBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range loans {
+			err := s.accrueLoan(ctx, l, from, to, result)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(loans) < accrualBatchSize {
+			return result, nil
+		}
+		afterID = loans[len(loans)-1].ID
+	}
+}
+
+func (s *AccrualService) accrueLoan(ctx context.Context, l *model.Loan, from, to time.Time, result *AccrualResult) error {
+	writtenOff, err := s.ledgerRepo.IsLoanWrittenOff(ctx, l.ID)
+	if err != nil || writtenOff {
+		return err
+	}
+
+	lps, err := s.loanPaymentRepo.Find(ctx, model.LoanPayment{
+		LoanID: l.ID,
+	})
+	if err != nil || len(lps) == 0 {
+		return err
+	}
+	dueDates := make([]time.Time, len(lps))
+	for i, lp := range lps {
+		dueDates[i] = lp.LocalDueDate
+	}
+	installments := l.Installments()
+
+	// the loan earns interest from the local disbursement date up to the day before the last local due date
+	start := lib.LocalDate(l.CreatedAt, l.Location())
+	end := dueDates[len(dueDates)-1]
+
+	result.Loans++
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		if date.Before(start) || !date.Before(end) {
+			continue
+		}
+
+		a := &model.InterestAccrual{
+			LoanID:      l.ID,
+			AccrualDate: date,
+			Amount:      lib.CalculateDailyInterest(start, dueDates, installments, date).Amount,
+		}
+		var e *model.JournalEntry
+		if a.Amount.IsPositive() {
+			e = newInterestAccrualEntry(l.ID, lib.NewMoney(a.Amount, l.CurrencyUnit().Code), date)
+			e.ID = uuid.Must(uuid.NewV7()).String()
+			a.JournalEntryID = &e.ID
+		}
+
+		created := false
+		err := s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+			var err error
+			created, err = s.interestAccrualRepo.WithTx(tx).Create(ctx, a)
+			if err != nil || !created || e == nil {
+				return err
+			}
+			return s.ledgerRepo.WithTx(tx).CreateEntry(ctx, e)
+		})
+		if err != nil {
+			return err
+		}
+
+		if !created {
+			result.Skipped++
+			continue
+		}
+		result.Accrued++
+		currency := l.CurrencyUnit().Code
+		total, ok := result.Amounts[currency]
+		if !ok {
+			total = lib.ZeroMoney(currency)
+		}
+		result.Amounts[currency] = total.Add(lib.NewMoney(a.Amount, currency))
+	}
+
+	return nil
+}
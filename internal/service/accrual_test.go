@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockInterestAccrualRepo is a mock implementation of repository.InterestAccrualRepo
+type MockInterestAccrualRepo struct {
+	mock.Mock
+}
+
+func (m *MockInterestAccrualRepo) WithTx(tx *gorm.DB) repository.InterestAccrualRepo {
+	args := m.Called(tx)
+	return args.Get(0).(repository.InterestAccrualRepo)
+}
+
+func (m *MockInterestAccrualRepo) Create(ctx context.Context, a *model.InterestAccrual) (bool, error) {
+	args := m.Called(ctx, a)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInterestAccrualRepo) DeleteByJournalEntryID(ctx context.Context, entryID string) (bool, error) {
+	args := m.Called(ctx, entryID)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestAccrualService_AccrueInterest(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	// 1.000.000 at 10% flat over 2 weeks is 3.846 interest, 1.923 per weekly installment
+	loan := &model.Loan{
+		ID:                 "loan-id-1",
+		Principal:          idr(1_000_000),
+		AnnualInterestRate: decimal.NewFromInt(10),
+		InterestMethod:     constant.InterestMethodFlat,
+		Period:             2,
+		PeriodUnit:         constant.PeriodUnitWeek,
+		CreatedAt:          createdAt,
+	}
+	loanPayments := []*model.LoanPayment{
+		{LoanID: "loan-id-1", LocalDueDate: time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC), DueDate: time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)},
+		{LoanID: "loan-id-1", LocalDueDate: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), DueDate: time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	onDate := func(date string) func(a *model.InterestAccrual) bool {
+		return func(a *model.InterestAccrual) bool {
+			return a.AccrualDate.Format(time.DateOnly) == date
+		}
+	}
+
+	tests := []struct {
+		name            string
+		from            time.Time
+		to              time.Time
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo, mockLedgerRepo *MockLedgerRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+		expectedResult  *AccrualResult
+	}{
+		{
+			name: "Accrues New Days And Skips Accrued Ones",
+			from: time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo, mockLedgerRepo *MockLedgerRepo) {
+				mockLoanRepo.On("FindAccruing", mock.Anything, mock.Anything, mock.Anything, "", accrualBatchSize).
+					Return([]*model.Loan{loan}, nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return(loanPayments, nil)
+
+				mockAccrualRepo.On("WithTx", mock.Anything).Return(mockAccrualRepo)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+
+				// last day of the first installment period, not accrued yet
+				mockAccrualRepo.On("Create", mock.Anything, mock.MatchedBy(onDate("2025-01-07"))).Return(true, nil)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeInterestAccrual &&
+						e.Validate() == nil &&
+						e.Lines[0].AccountCode == constant.LedgerAccountInterestReceivable &&
+						e.Lines[0].Debit.Equal(decimal.RequireFromString("274.7143"))
+				})).Return(nil).Once()
+
+				// first day of the second period, accrued by an earlier run
+				mockAccrualRepo.On("Create", mock.Anything, mock.MatchedBy(onDate("2025-01-08"))).Return(false, nil)
+			},
+			expectedError: false,
+			expectedResult: &AccrualResult{
+				Loans:   1,
+				Accrued: 1,
+				Skipped: 1,
+				Amounts: map[string]lib.Money{
+					"IDR": lib.NewMoney(decimal.RequireFromString("274.7143"), "IDR"),
+				},
+			},
+		},
+		{
+			name: "Written Off Loan Is Skipped",
+			from: time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo, mockLedgerRepo *MockLedgerRepo) {
+				mockLoanRepo.On("FindAccruing", mock.Anything, mock.Anything, mock.Anything, "", accrualBatchSize).
+					Return([]*model.Loan{loan}, nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(true, nil)
+			},
+			expectedError: false,
+			expectedResult: &AccrualResult{
+				Amounts: map[string]lib.Money{},
+			},
+		},
+		{
+			name: "Range Ends Before It Starts",
+			from: time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo, mockLedgerRepo *MockLedgerRepo) {
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			mockAccrualRepo := new(MockInterestAccrualRepo)
+			mockLedgerRepo := new(MockLedgerRepo)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo, mockAccrualRepo, mockLedgerRepo)
+
+			service := NewAccrualService(mockLoanRepo, mockLoanPaymentRepo, mockAccrualRepo, mockLedgerRepo, new(MockTxManager))
+			result, err := service.AccrueInterest(context.Background(), tt.from, tt.to)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult.Loans, result.Loans)
+				assert.Equal(t, tt.expectedResult.Accrued, result.Accrued)
+				assert.Equal(t, tt.expectedResult.Skipped, result.Skipped)
+				assert.Len(t, result.Amounts, len(tt.expectedResult.Amounts))
+				for currency, amount := range tt.expectedResult.Amounts {
+					assert.True(t, amount.Equal(result.Amounts[currency]), "%s %s", currency, result.Amounts[currency])
+				}
+			}
+
+			mockLoanRepo.AssertExpectations(t)
+			mockLoanPaymentRepo.AssertExpectations(t)
+			mockAccrualRepo.AssertExpectations(t)
+			mockLedgerRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+const billingBatchSize = 100
+
+// BillingConfig tunes the daily billing run. The late fee is only charged on installments in its currency; a zero
+// amount disables late fees and zero reminder days disables due reminders.
+type BillingConfig struct {
+	LateFee            lib.Money
+	LateFeeGraceDays   int
+	ReminderDaysBefore int
+	Calendar           CalendarConfig
+}
+
+// Validate rejects a late fee in an unsupported currency, finer than its minor units or negative
+func (c BillingConfig) Validate() error {
+	currency, err := lib.LookupCurrency(c.LateFee.Currency)
+	if err != nil {
+		return err
+	}
+	if c.LateFee.IsNegative() || !currency.Round(c.LateFee.Amount).Equal(c.LateFee.Amount) {
+		return lib.NewValidationError(constant.ErrCodeInvalidRequest, "late fee %s is not a positive amount in the minor units of %s", c.LateFee, currency.Code)
+	}
+	return nil
+}
+
+type BillingService struct {
+	loanRepo        repository.LoanRepo
+	loanPaymentRepo repository.LoanPaymentRepo
+	ledgerRepo      repository.LedgerRepo
+	holidayRepo     repository.HolidayRepo
+	outboxRepo      repository.OutboxRepo
+	jobRunRepo      repository.JobRunRepo
+	jobLocker       repository.JobLocker
+	txManager       repository.TxManager
+	clock           lib.Clock
+	cfg             BillingConfig
+}
+
+func NewBillingService(
+	loanRepo repository.LoanRepo,
+	loanPaymentRepo repository.LoanPaymentRepo,
+	ledgerRepo repository.LedgerRepo,
+	holidayRepo repository.HolidayRepo,
+	outboxRepo repository.OutboxRepo,
+	jobRunRepo repository.JobRunRepo,
+	jobLocker repository.JobLocker,
+	txManager repository.TxManager,
+	clock lib.Clock,
+	cfg BillingConfig,
+) *BillingService {
+	return &BillingService{
+		loanRepo:        loanRepo,
+		loanPaymentRepo: loanPaymentRepo,
+		ledgerRepo:      ledgerRepo,
+		holidayRepo:     holidayRepo,
+		outboxRepo:      outboxRepo,
+		jobRunRepo:      jobRunRepo,
+		jobLocker:       jobLocker,
+		txManager:       txManager,
+		clock:           clock,
+		cfg:             cfg,
+	}
+}
+
+// DailyBillingResult summarises a daily billing run
+type DailyBillingResult struct {
+	MarkedOverdue      int64     `json:"marked_overdue"`
+	LateFeesApplied    int       `json:"late_fees_applied"`
+	LateFeeAmount      lib.Money `json:"late_fee_amount"`
+	LateFeeCurrency    string    `json:"late_fee_currency"`
+	DaysPastDueUpdated int64     `json:"days_past_due_updated"`
+	RemindersEmitted   int       `json:"reminders_emitted"`
+}
+
+// RunDailyBilling marks installments that passed their due date as OVERDUE, charges late fees once the grace
+// period is over, refreshes the days past due of every loan and emits reminders for installments falling due soon.
+// Only one run can be in progress across all replicas. A scheduled or simulated run is skipped when the job already
+// succeeded for the day; a manual run always goes ahead, which is safe because every step can be repeated without effect.
+// The run is recorded in the job run history whatever the outcome.
+func (s *BillingService) RunDailyBilling(ctx context.Context, trigger constant.JobTrigger) (*model.JobRun, error) {
+	now := s.clock.Now()
+	businessDate := lib.TruncateToDate(now)
+
+	var run *model.JobRun
+	var runErr error
+	acquired, err := s.jobLocker.RunExclusive(ctx, constant.JobNameDailyBilling, func(ctx context.Context) error {
+		if trigger != constant.JobTriggerManual {
+			done, err := s.jobRunRepo.HasSucceeded(ctx, constant.JobNameDailyBilling, businessDate)
+			if err != nil {
+				return err
+			}
+			if done {
+				return lib.NewConflictError(constant.ErrCodeJobAlreadyCompleted, "daily billing already completed for %s", businessDate.Format(time.DateOnly))
+			}
+		}
+
+		run = &model.JobRun{
+			JobName:      constant.JobNameDailyBilling,
+			BusinessDate: businessDate,
+			Trigger:      trigger,
+			Status:       constant.JobRunStatusRunning,
+			StartedAt:    now,
+		}
+		err := s.jobRunRepo.Create(ctx, run)
+		if err != nil {
+			return err
+		}
+
+		var result *DailyBillingResult
+		result, runErr = s.runDailyBilling(ctx, now)
+
+		finishedAt := s.clock.Now()
+		run.FinishedAt = &finishedAt
+		run.Status = constant.JobRunStatusSucceeded
+		if runErr != nil {
+			run.Status = constant.JobRunStatusFailed
+			run.Error = runErr.Error()
+		}
+		run.Result, err = json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		// the outcome is recorded even when the run was cancelled halfway
+		return s.jobRunRepo.Update(context.WithoutCancel(ctx), run)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, lib.NewConflictError(constant.ErrCodeJobAlreadyRunning, "daily billing is already running")
+	}
+	if runErr != nil {
+		return run, runErr
+	}
+
+	return run, nil
+}
+
+func (s *BillingService) runDailyBilling(ctx context.Context, now time.Time) (*DailyBillingResult, error) {
+	result := &DailyBillingResult{
+		LateFeeAmount:   lib.ZeroMoney(s.cfg.LateFee.Currency),
+		LateFeeCurrency: s.cfg.LateFee.Currency,
+	}
+
+	calendar, err := s.overdueCalendar(ctx, now)
+	if err != nil {
+		return result, err
+	}
+	// the overdue counts and next due dates of the loans move with their installments
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		timezones, err := s.loanRepo.WithTx(tx).FindUnpaidTimezones(ctx)
+		if err != nil {
+			return err
+		}
+		// due dates are local dates, so each timezone is at its own date
+		for _, timezone := range timezones {
+			loc, err := lib.LoadLocation(timezone)
+			if err != nil {
+				return err
+			}
+			overdueBefore := lib.LocalDate(now, loc)
+			if calendar != nil {
+				overdueBefore = calendar.OverdueCutoff(overdueBefore)
+			}
+			marked, err := s.loanPaymentRepo.WithTx(tx).MarkOverdue(ctx, timezone, overdueBefore)
+			if err != nil {
+				return err
+			}
+			result.MarkedOverdue += marked
+		}
+		_, err = s.loanRepo.WithTx(tx).UpdateOverdueBalances(ctx)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = s.applyLateFees(ctx, now, result)
+	if err != nil {
+		return result, err
+	}
+
+	result.DaysPastDueUpdated, err = s.loanRepo.UpdateDaysPastDue(ctx, now)
+	if err != nil {
+		return result, err
+	}
+
+	err = s.emitDueReminders(ctx, now, result)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// overdueCalendar returns the business calendar around now that gives installments due over the weekend and holidays
+// until the next business day, or nil without a business day convention
+func (s *BillingService) overdueCalendar(ctx context.Context, now time.Time) (*lib.BusinessCalendar, error) {
+	if !s.cfg.Calendar.enabled() {
+		return nil, nil
+	}
+	// local dates run up to a day ahead of UTC
+	return loadBusinessCalendar(ctx, s.holidayRepo, s.cfg.Calendar.CountryCode, now.AddDate(0, -1, 0), now.AddDate(0, 0, 1))
+}
+
+// applyLateFees charges the configured fee once on every outstanding installment in its currency that is overdue by
+// more than the grace period, booking it as fee income. Written-off loans are not charged.
+func (s *BillingService) applyLateFees(ctx context.Context, now time.Time, result *DailyBillingResult) error {
+	fee := s.cfg.LateFee
+	if !fee.IsPositive() {
+		return nil
+	}
+	dueBefore := now.AddDate(0, 0, -s.cfg.LateFeeGraceDays)
+
+	writtenOff := make(map[string]bool)
+	afterID := ""
+	for {
+		lps, err := s.loanPaymentRepo.FindLateFeeCandidates(ctx, fee.Currency, dueBefore, afterID, billingBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, lp := range lps {
+			skip, ok := writtenOff[lp.LoanID]
+			if !ok {
+				skip, err = s.ledgerRepo.IsLoanWrittenOff(ctx, lp.LoanID)
+				if err != nil {
+					return err
+				}
+				writtenOff[lp.LoanID] = skip
+			}
+			if skip {
+				continue
+			}
+
+			description := fmt.Sprintf("late fee for installment due %s", lp.LocalDueDate.Format(time.DateOnly))
+			e := newFeeAccrualEntry(lp.LoanID, fee, description, now)
+			e.ID = uuid.Must(uuid.NewV7()).String()
+			applied := false
+			err := s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+				l, err := lockLoan(ctx, s.loanRepo.WithTx(tx), lp.LoanID, 0)
+				if err != nil {
+					return err
+				}
+				// a write-off locks the loan too, so one committed since the check above is seen here
+				skip, err := s.ledgerRepo.WithTx(tx).IsLoanWrittenOff(ctx, lp.LoanID)
+				if err != nil {
+					return err
+				}
+				if skip {
+					writtenOff[lp.LoanID] = true
+					return nil
+				}
+				applied, err = s.loanPaymentRepo.WithTx(tx).ApplyLateFee(ctx, lp.ID, fee, e.ID)
+				if err != nil || !applied {
+					return err
+				}
+				err = refreshLoanBalances(ctx, s.loanRepo.WithTx(tx), s.loanPaymentRepo.WithTx(tx), l)
+				if err != nil {
+					return err
+				}
+				return s.ledgerRepo.WithTx(tx).CreateEntry(ctx, e)
+			})
+			if err != nil {
+				return err
+			}
+			if applied {
+				result.LateFeesApplied++
+				result.LateFeeAmount = result.LateFeeAmount.Add(fee)
+			}
+		}
+		if len(lps) < billingBatchSize {
+			return nil
+		}
+		afterID = lps[len(lps)-1].ID
+	}
+}
+
+// emitDueReminders queues one PAYMENT_DUE_REMINDER event per installment falling due within the reminder window
+func (s *BillingService) emitDueReminders(ctx context.Context, now time.Time, result *DailyBillingResult) error {
+	if s.cfg.ReminderDaysBefore <= 0 {
+		return nil
+	}
+	to := now.AddDate(0, 0, s.cfg.ReminderDaysBefore)
+
+	afterID := ""
+	for {
+		lps, err := s.loanPaymentRepo.FindDueBetween(ctx, now, to, afterID, billingBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, lp := range lps {
+			payload, err := json.Marshal(model.PaymentDueReminder{
+				LoanPaymentID: lp.ID,
+				LoanID:        lp.LoanID,
+				BorrowerID:    lp.BorrowerID,
+				Currency:      lp.Currency,
+				AmountDue:     lp.AmountDue(),
+				LocalDueDate:  lp.LocalDueDate,
+				DueDate:       lp.DueDate,
+			})
+			if err != nil {
+				return err
+			}
+			created, err := s.outboxRepo.Create(ctx, &model.OutboxEvent{
+				Type:        constant.OutboxEventPaymentDueReminder,
+				AggregateID: lp.ID,
+				DedupKey:    fmt.Sprintf("%s:%s", constant.OutboxEventPaymentDueReminder, lp.ID),
+				Payload:     string(payload),
+			})
+			if err != nil {
+				return err
+			}
+			if created {
+				result.RemindersEmitted++
+			}
+		}
+		if len(lps) < billingBatchSize {
+			return nil
+		}
+		afterID = lps[len(lps)-1].ID
+	}
+}
+
+// JobRunListFilter is the job run list query as received from the client, with an opaque cursor
+type JobRunListFilter struct {
+	JobName string
+	Status  constant.JobRunStatus
+	Cursor  string
+	Limit   int
+}
+
+func (s *BillingService) ListJobRuns(ctx context.Context, f JobRunListFilter) ([]*model.JobRun, string, error) {
+	after, err := lib.DecodeCursor(f.Cursor)
+	if err != nil {
+		return nil, "", lib.NewValidationError(constant.ErrCodeInvalidCursor, "invalid cursor").Wrap(err)
+	}
+
+	runs, next, err := s.jobRunRepo.List(ctx, repository.JobRunFilter{
+		JobName: f.JobName,
+		Status:  f.Status,
+		After:   after,
+		Limit:   lib.NormalizePageLimit(f.Limit),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return runs, lib.EncodeCursor(next), nil
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockOutboxRepo is a mock implementation of repository.OutboxRepo
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepo) WithTx(tx *gorm.DB) repository.OutboxRepo {
+	args := m.Called(tx)
+	return args.Get(0).(repository.OutboxRepo)
+}
+
+func (m *MockOutboxRepo) Create(ctx context.Context, e *model.OutboxEvent) (bool, error) {
+	args := m.Called(ctx, e)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockJobRunRepo is a mock implementation of repository.JobRunRepo
+type MockJobRunRepo struct {
+	mock.Mock
+}
+
+func (m *MockJobRunRepo) Create(ctx context.Context, run *model.JobRun) error {
+	args := m.Called(ctx, run)
+	return args.Error(0)
+}
+
+func (m *MockJobRunRepo) Update(ctx context.Context, run *model.JobRun) error {
+	args := m.Called(ctx, run)
+	return args.Error(0)
+}
+
+func (m *MockJobRunRepo) HasSucceeded(ctx context.Context, jobName string, businessDate time.Time) (bool, error) {
+	args := m.Called(ctx, jobName, businessDate)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockJobRunRepo) List(ctx context.Context, f repository.JobRunFilter) ([]*model.JobRun, *lib.Cursor, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]*model.JobRun), args.Get(1).(*lib.Cursor), args.Error(2)
+}
+
+// MockJobLocker is a mock implementation of repository.JobLocker that runs the callback when the lock is granted
+type MockJobLocker struct {
+	mock.Mock
+}
+
+func (m *MockJobLocker) RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	args := m.Called(ctx, name)
+	if !args.Bool(0) {
+		return false, args.Error(1)
+	}
+	return true, fn(ctx)
+}
+
+func TestBillingService_RunDailyBilling(t *testing.T) {
+	dueDate := time.Now().UTC().AddDate(0, 0, -5)
+	cfg := BillingConfig{
+		LateFee:            idr(25_000),
+		LateFeeGraceDays:   3,
+		ReminderDaysBefore: 3,
+	}
+	withStatus := func(status constant.JobRunStatus) func(run *model.JobRun) bool {
+		return func(run *model.JobRun) bool {
+			return run.Status == status
+		}
+	}
+
+	tests := []struct {
+		name            string
+		trigger         constant.JobTrigger
+		cfg             BillingConfig
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockOutboxRepo *MockOutboxRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+		expectedStatus  constant.JobRunStatus
+		expectedResult  *DailyBillingResult
+	}{
+		{
+			name:    "Runs Every Step",
+			trigger: constant.JobTriggerScheduled,
+			cfg:     cfg,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockOutboxRepo *MockOutboxRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+				mockJobRunRepo.On("HasSucceeded", mock.Anything, constant.JobNameDailyBilling, mock.Anything).Return(false, nil)
+				mockJobRunRepo.On("Create", mock.Anything, mock.MatchedBy(withStatus(constant.JobRunStatusRunning))).Return(nil)
+
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("FindUnpaidTimezones", mock.Anything).Return([]string{"UTC"}, nil)
+				mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, "UTC", mock.Anything).Return(int64(2), nil)
+				mockLoanRepo.On("UpdateOverdueBalances", mock.Anything).Return(int64(2), nil)
+
+				// installments of an active loan, a written-off loan and a loan written off while billing runs are past
+				// the grace period
+				mockLoanPaymentRepo.On("FindLateFeeCandidates", mock.Anything, "IDR", mock.Anything, "", billingBatchSize).Return([]*model.LoanPayment{
+					{ID: "lp-id-1", LoanID: "loan-id-1", DueDate: dueDate},
+					{ID: "lp-id-2", LoanID: "loan-id-2", DueDate: dueDate},
+					{ID: "lp-id-5", LoanID: "loan-id-4", DueDate: dueDate},
+				}, nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-2").Return(true, nil)
+				// the write-off commits before the loan is locked, so the check under the lock skips it
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-4").Return(false, nil).Once()
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool { return l.ID == "loan-id-4" })).Return(nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-4").Return(true, nil).Once()
+				// the installment is linked to the fee accrual entry that books its late fee
+				var chargedBy string
+				mockLoanPaymentRepo.On("ApplyLateFee", mock.Anything, "lp-id-1", idr(25_000), mock.MatchedBy(func(entryID string) bool {
+					chargedBy = entryID
+					return entryID != ""
+				})).Return(true, nil)
+				// the late fee is added to the outstanding total of the loan
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool { return l.ID == "loan-id-1" })).Return(nil)
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return([]*model.LoanPayment{
+					{ID: "lp-id-1", LoanID: "loan-id-1", Amount: idr(110_000), LateFee: idr(25_000), DueDate: dueDate, Status: constant.LoanPaymentStatusOverdue},
+				}, nil)
+				mockLoanRepo.On("UpdateBalances", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.OutstandingTotal.Amount.Equal(decimal.NewFromInt(135_000)) && l.InstallmentsOverdue == 1
+				})).Return(true, nil)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeFeeAccrual &&
+						e.ID == chargedBy &&
+						e.LoanID == "loan-id-1" &&
+						e.Validate() == nil &&
+						e.Lines[0].Currency == "IDR" &&
+						e.Lines[0].Debit.Equal(decimal.NewFromInt(25_000))
+				})).Return(nil)
+
+				mockLoanRepo.On("UpdateDaysPastDue", mock.Anything, mock.Anything).Return(int64(1), nil)
+
+				// the second installment was already reminded about on a previous day
+				mockLoanPaymentRepo.On("FindDueBetween", mock.Anything, mock.Anything, mock.Anything, "", billingBatchSize).Return([]*model.LoanPayment{
+					{ID: "lp-id-3", LoanID: "loan-id-1", BorrowerID: "borrower-id-1", Amount: idr(110_000)},
+					{ID: "lp-id-4", LoanID: "loan-id-3", BorrowerID: "borrower-id-2", Amount: idr(110_000)},
+				}, nil)
+				mockOutboxRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *model.OutboxEvent) bool {
+					return e.DedupKey == "PAYMENT_DUE_REMINDER:lp-id-3"
+				})).Return(true, nil)
+				mockOutboxRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *model.OutboxEvent) bool {
+					return e.DedupKey == "PAYMENT_DUE_REMINDER:lp-id-4"
+				})).Return(false, nil)
+
+				mockJobRunRepo.On("Update", mock.Anything, mock.MatchedBy(withStatus(constant.JobRunStatusSucceeded))).Return(nil)
+			},
+			expectedError:  false,
+			expectedStatus: constant.JobRunStatusSucceeded,
+			expectedResult: &DailyBillingResult{
+				MarkedOverdue:      2,
+				LateFeesApplied:    1,
+				LateFeeAmount:      idr(25_000),
+				DaysPastDueUpdated: 1,
+				RemindersEmitted:   1,
+			},
+		},
+		{
+			name:    "Manual Run Without Late Fees And Reminders",
+			trigger: constant.JobTriggerManual,
+			cfg:     BillingConfig{},
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockOutboxRepo *MockOutboxRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+				mockJobRunRepo.On("Create", mock.Anything, mock.MatchedBy(func(run *model.JobRun) bool {
+					return run.Trigger == constant.JobTriggerManual
+				})).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("FindUnpaidTimezones", mock.Anything).Return([]string{"UTC"}, nil)
+				mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, "UTC", mock.Anything).Return(int64(0), nil)
+				mockLoanRepo.On("UpdateOverdueBalances", mock.Anything).Return(int64(0), nil)
+				mockLoanRepo.On("UpdateDaysPastDue", mock.Anything, mock.Anything).Return(int64(0), nil)
+				mockJobRunRepo.On("Update", mock.Anything, mock.MatchedBy(withStatus(constant.JobRunStatusSucceeded))).Return(nil)
+			},
+			expectedError:  false,
+			expectedStatus: constant.JobRunStatusSucceeded,
+			expectedResult: &DailyBillingResult{
+				LateFeeAmount: lib.ZeroMoney(""),
+			},
+		},
+		{
+			name:    "Scheduled Run Already Completed Today",
+			trigger: constant.JobTriggerScheduled,
+			cfg:     cfg,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockOutboxRepo *MockOutboxRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+				mockJobRunRepo.On("HasSucceeded", mock.Anything, constant.JobNameDailyBilling, mock.Anything).Return(true, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+		{
+			name:    "Lock Held By Another Replica",
+			trigger: constant.JobTriggerManual,
+			cfg:     cfg,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockOutboxRepo *MockOutboxRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(false, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+		{
+			name:    "Failed Step Is Recorded",
+			trigger: constant.JobTriggerScheduled,
+			cfg:     cfg,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockOutboxRepo *MockOutboxRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+				mockJobRunRepo.On("HasSucceeded", mock.Anything, constant.JobNameDailyBilling, mock.Anything).Return(false, nil)
+				mockJobRunRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("FindUnpaidTimezones", mock.Anything).Return([]string{"UTC"}, nil)
+				mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, "UTC", mock.Anything).Return(int64(0), errors.New("connection reset"))
+				mockJobRunRepo.On("Update", mock.Anything, mock.MatchedBy(func(run *model.JobRun) bool {
+					return run.Status == constant.JobRunStatusFailed && run.Error == "connection reset" && run.FinishedAt != nil
+				})).Return(nil)
+			},
+			expectedError:  true,
+			expectedStatus: constant.JobRunStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			mockLedgerRepo := new(MockLedgerRepo)
+			mockOutboxRepo := new(MockOutboxRepo)
+			mockJobRunRepo := new(MockJobRunRepo)
+			mockJobLocker := new(MockJobLocker)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo, mockLedgerRepo, mockOutboxRepo, mockJobRunRepo, mockJobLocker)
+
+			service := NewBillingService(mockLoanRepo, mockLoanPaymentRepo, mockLedgerRepo, new(MockHolidayRepo), mockOutboxRepo, mockJobRunRepo, mockJobLocker, new(MockTxManager), newTestClock(), tt.cfg)
+			run, err := service.RunDailyBilling(context.Background(), tt.trigger)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectedStatus != "" {
+				assert.Equal(t, tt.expectedStatus, run.Status)
+			}
+			if tt.expectedResult != nil {
+				var result DailyBillingResult
+				assert.NoError(t, json.Unmarshal(run.Result, &result))
+				assert.Equal(t, tt.expectedResult.MarkedOverdue, result.MarkedOverdue)
+				assert.Equal(t, tt.expectedResult.LateFeesApplied, result.LateFeesApplied)
+				assert.True(t, tt.expectedResult.LateFeeAmount.Equal(result.LateFeeAmount), result.LateFeeAmount.String())
+				assert.Equal(t, tt.expectedResult.DaysPastDueUpdated, result.DaysPastDueUpdated)
+				assert.Equal(t, tt.expectedResult.RemindersEmitted, result.RemindersEmitted)
+			}
+
+			mockLoanRepo.AssertExpectations(t)
+			mockLoanPaymentRepo.AssertExpectations(t)
+			mockLedgerRepo.AssertExpectations(t)
+			mockOutboxRepo.AssertExpectations(t)
+			mockJobRunRepo.AssertExpectations(t)
+			mockJobLocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBillingConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		lateFee       lib.Money
+		expectedError bool
+	}{
+		{
+			name:    "Late Fee In Minor Units",
+			lateFee: lib.NewMoney(decimal.RequireFromString("2.50"), "USD"),
+		},
+		{
+			name:    "Late Fees Disabled",
+			lateFee: lib.ZeroMoney("IDR"),
+		},
+		{
+			name:          "Late Fee Finer Than Currency",
+			lateFee:       lib.NewMoney(decimal.RequireFromString("25000.5"), "IDR"),
+			expectedError: true,
+		},
+		{
+			name:          "Negative Late Fee",
+			lateFee:       idr(-25_000),
+			expectedError: true,
+		},
+		{
+			name:          "Unsupported Currency",
+			lateFee:       lib.NewMoneyFromInt(5, "XXX"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BillingConfig{LateFee: tt.lateFee}.Validate()
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
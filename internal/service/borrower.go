@@ -1,25 +1,93 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
 	"github.com/ramabmtr/billing-engine/internal/repository"
+	"gorm.io/gorm"
 )
 
 type BorrowerService struct {
-	borrowerRepo repository.BorrowerRepo
+	borrowerRepo    repository.BorrowerRepo
+	loanRepo        repository.LoanRepo
+	loanPaymentRepo repository.LoanPaymentRepo
+	clock           lib.Clock
 }
 
-func NewBorrowerService(borrowerRepo repository.BorrowerRepo) *BorrowerService {
+func NewBorrowerService(
+	borrowerRepo repository.BorrowerRepo,
+	loanRepo repository.LoanRepo,
+	loanPaymentRepo repository.LoanPaymentRepo,
+	clock lib.Clock,
+) *BorrowerService {
 	return &BorrowerService{
-		borrowerRepo: borrowerRepo,
+		borrowerRepo:    borrowerRepo,
+		loanRepo:        loanRepo,
+		loanPaymentRepo: loanPaymentRepo,
+		clock:           clock,
+	}
+}
+
+// BorrowerProfile holds the editable borrower fields. Nil fields are left untouched on update.
+type BorrowerProfile struct {
+	Name        *string
+	Phone       *string
+	Email       *string
+	NationalID  *string
+	Address     *string
+	DateOfBirth *time.Time
+	Timezone    *string
+}
+
+// BorrowerListFilter is the borrower list query as received from the client, with an opaque cursor
+type BorrowerListFilter struct {
+	Name         string
+	IsDelinquent *bool
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Sort         string
+	Cursor       string
+	Limit        int
+}
+
+func (p BorrowerProfile) applyTo(b *model.Borrower) {
+	if p.Name != nil {
+		b.Name = *p.Name
+	}
+	if p.Phone != nil {
+		b.Phone = *p.Phone
+	}
+	if p.Email != nil {
+		b.Email = *p.Email
+	}
+	if p.NationalID != nil {
+		b.NationalID = *p.NationalID
+	}
+	if p.Address != nil {
+		b.Address = *p.Address
+	}
+	if p.DateOfBirth != nil {
+		b.DateOfBirth = p.DateOfBirth
+	}
+	if p.Timezone != nil {
+		b.Timezone = *p.Timezone
 	}
 }
 
-func (s *BorrowerService) Create(name string) (*model.Borrower, error) {
+func (s *BorrowerService) Create(ctx context.Context, p BorrowerProfile) (*model.Borrower, error) {
 	b := &model.Borrower{
-		Name: name,
+		Status: constant.BorrowerStatusActive,
 	}
-	err := s.borrowerRepo.Create(b)
+	p.applyTo(b)
+	err := s.borrowerRepo.Create(ctx, b)
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +95,166 @@ func (s *BorrowerService) Create(name string) (*model.Borrower, error) {
 	return b, nil
 }
 
-func (s *BorrowerService) List() ([]*model.BorrowerWithDelinquentStatus, error) {
-	l, err := s.borrowerRepo.List()
-	return l, err
+func (s *BorrowerService) Get(ctx context.Context, id string) (*model.Borrower, error) {
+	b := &model.Borrower{
+		ID: id,
+	}
+	err := s.borrowerRepo.Get(ctx, b)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, lib.NewNotFoundError(constant.ErrCodeBorrowerNotFound, "borrower not found").Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (s *BorrowerService) Update(ctx context.Context, id string, p BorrowerProfile) (*model.Borrower, error) {
+	b, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	p.applyTo(b)
+	err = s.borrowerRepo.Update(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Deactivate prevents the borrower from taking new loans. Existing loans are not affected.
+func (s *BorrowerService) Deactivate(ctx context.Context, id string) (*model.Borrower, error) {
+	b, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status == constant.BorrowerStatusBlacklisted {
+		return nil, lib.NewConflictError(constant.ErrCodeBorrowerBlacklisted, "borrower is blacklisted")
+	}
+
+	return s.changeStatus(ctx, b, constant.BorrowerStatusInactive)
+}
+
+// Blacklist permanently prevents the borrower from taking new loans
+func (s *BorrowerService) Blacklist(ctx context.Context, id string) (*model.Borrower, error) {
+	b, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.changeStatus(ctx, b, constant.BorrowerStatusBlacklisted)
+}
+
+func (s *BorrowerService) changeStatus(ctx context.Context, b *model.Borrower, status constant.BorrowerStatus) (*model.Borrower, error) {
+	if b.Status == status {
+		return b, nil
+	}
+
+	from := b.Status
+	b.Status = status
+	ok, err := s.borrowerRepo.UpdateStatus(ctx, b, from)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, lib.NewConflictError(constant.ErrCodeBorrowerStatusChanged, "borrower status changed while it was being updated")
+	}
+
+	return b, nil
+}
+
+// GetSummary aggregates the financial position of a borrower across all of their loans. With an as-of time in the
+// context the current schedule is projected to that time: unpaid installments due before it count as overdue.
+func (s *BorrowerService) GetSummary(ctx context.Context, id string) (*model.BorrowerSummary, error) {
+	_, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ls, err := s.loanRepo.GetStatsByBorrowerID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := lib.ClockFromContext(ctx, s.clock).Now()
+	ps, err := s.loanPaymentRepo.GetStatsByBorrowerID(ctx, id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	dpd := lib.DaysPastDue(ps.OldestOverdueDueDate, now)
+	return &model.BorrowerSummary{
+		BorrowerID:         id,
+		Currencies:         currencySummaries(ls, ps),
+		LoanCount:          ls.LoanCount,
+		ActiveLoanCount:    ls.ActiveLoanCount,
+		CompletedLoanCount: ls.LoanCount - ls.ActiveLoanCount,
+		DaysPastDue:        dpd,
+		DelinquencyBucket:  lib.GetDelinquencyBucket(dpd),
+		AsOf:               now,
+	}, nil
+}
+
+// currencySummaries lines up the loan and installment totals of every currency the borrower has loans in, in
+// currency order
+func currencySummaries(ls model.LoanStats, ps model.LoanPaymentStats) []*model.BorrowerCurrencySummary {
+	byCurrency := make(map[string]*model.BorrowerCurrencySummary)
+	summaryOf := func(currency string) *model.BorrowerCurrencySummary {
+		cs, ok := byCurrency[currency]
+		if !ok {
+			cs = &model.BorrowerCurrencySummary{
+				Currency:         currency,
+				TotalPrincipal:   lib.ZeroMoney(currency),
+				TotalRepaid:      lib.ZeroMoney(currency),
+				TotalOutstanding: lib.ZeroMoney(currency),
+				OverdueAmount:    lib.ZeroMoney(currency),
+				NextDueAmount:    lib.ZeroMoney(currency),
+			}
+			byCurrency[currency] = cs
+		}
+		return cs
+	}
+
+	for _, l := range ls.Currencies {
+		summaryOf(l.Currency).TotalPrincipal = l.TotalPrincipal
+	}
+	for _, p := range ps.Currencies {
+		cs := summaryOf(p.Currency)
+		cs.TotalRepaid = p.TotalRepaid
+		cs.TotalOutstanding = p.TotalOutstanding
+		cs.OverdueAmount = p.OverdueAmount
+		cs.NextDueDate = p.NextDueDate
+		cs.NextDueAmount = p.NextDueAmount
+	}
+
+	summaries := slices.AppendSeq(make([]*model.BorrowerCurrencySummary, 0, len(byCurrency)), maps.Values(byCurrency))
+	slices.SortFunc(summaries, func(a, b *model.BorrowerCurrencySummary) int {
+		return strings.Compare(a.Currency, b.Currency)
+	})
+	return summaries
+}
+
+func (s *BorrowerService) List(ctx context.Context, f BorrowerListFilter) ([]*model.BorrowerWithDelinquentStatus, string, error) {
+	after, err := lib.DecodeCursor(f.Cursor)
+	if err != nil {
+		return nil, "", lib.NewValidationError(constant.ErrCodeInvalidCursor, "invalid cursor").Wrap(err)
+	}
+
+	l, next, err := s.borrowerRepo.List(ctx, repository.BorrowerFilter{
+		Name:         f.Name,
+		IsDelinquent: f.IsDelinquent,
+		CreatedFrom:  f.CreatedFrom,
+		CreatedTo:    f.CreatedTo,
+		Sort:         f.Sort,
+		After:        after,
+		Limit:        lib.NormalizePageLimit(f.Limit),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return l, lib.EncodeCursor(next), nil
 }
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
 	"github.com/ramabmtr/billing-engine/internal/repository"
 
@@ -24,8 +27,8 @@ func (m *MockBorrowerRepo) WithTx(tx *gorm.DB) repository.BorrowerRepo {
 	return args.Get(0).(repository.BorrowerRepo)
 }
 
-func (m *MockBorrowerRepo) Create(b *model.Borrower) error {
-	args := m.Called(b)
+func (m *MockBorrowerRepo) Create(ctx context.Context, b *model.Borrower) error {
+	args := m.Called(ctx, b)
 	// Set ID if it's empty to simulate the BeforeCreate hook
 	if b.ID == "" {
 		b.ID = uuid.Must(uuid.NewV7()).String()
@@ -33,9 +36,33 @@ func (m *MockBorrowerRepo) Create(b *model.Borrower) error {
 	return args.Error(0)
 }
 
-func (m *MockBorrowerRepo) List() ([]*model.BorrowerWithDelinquentStatus, error) {
-	args := m.Called()
-	return args.Get(0).([]*model.BorrowerWithDelinquentStatus), args.Error(1)
+func (m *MockBorrowerRepo) Get(ctx context.Context, b *model.Borrower) error {
+	args := m.Called(ctx, b)
+	return args.Error(0)
+}
+
+func (m *MockBorrowerRepo) Update(ctx context.Context, b *model.Borrower) error {
+	args := m.Called(ctx, b)
+	return args.Error(0)
+}
+
+func (m *MockBorrowerRepo) UpdateStatus(ctx context.Context, b *model.Borrower, from constant.BorrowerStatus) (bool, error) {
+	args := m.Called(ctx, b, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBorrowerRepo) List(ctx context.Context, f repository.BorrowerFilter) ([]*model.BorrowerWithDelinquentStatus, *lib.Cursor, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]*model.BorrowerWithDelinquentStatus), args.Get(1).(*lib.Cursor), args.Error(2)
+}
+
+// setBorrowerStatus simulates Get returning a borrower with the given status
+func setBorrowerStatus(status constant.BorrowerStatus) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		b := args.Get(1).(*model.Borrower)
+		b.Name = "John Doe"
+		b.Status = status
+	}
 }
 
 func TestBorrowerService_Create(t *testing.T) {
@@ -49,8 +76,10 @@ func TestBorrowerService_Create(t *testing.T) {
 			name:         "Success",
 			borrowerName: "John Doe",
 			mockSetup: func(mockRepo *MockBorrowerRepo) {
-				mockRepo.On("Create", mock.MatchedBy(func(b *model.Borrower) bool {
-					return b.Name == "John Doe"
+				mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.Name == "John Doe" &&
+						b.Email == "john@example.com" &&
+						b.Status == constant.BorrowerStatusActive
 				})).Return(nil)
 			},
 			expectedError: false,
@@ -59,7 +88,7 @@ func TestBorrowerService_Create(t *testing.T) {
 			name:         "Repository Error",
 			borrowerName: "Jane Doe",
 			mockSetup: func(mockRepo *MockBorrowerRepo) {
-				mockRepo.On("Create", mock.MatchedBy(func(b *model.Borrower) bool {
+				mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
 					return b.Name == "Jane Doe"
 				})).Return(errors.New("database error"))
 			},
@@ -72,8 +101,12 @@ func TestBorrowerService_Create(t *testing.T) {
 			mockRepo := new(MockBorrowerRepo)
 			tt.mockSetup(mockRepo)
 
-			service := NewBorrowerService(mockRepo)
-			borrower, err := service.Create(tt.borrowerName)
+			email := "john@example.com"
+			service := NewBorrowerService(mockRepo, new(MockLoanRepo), new(MockLoanPaymentRepo), newTestClock())
+			borrower, err := service.Create(context.Background(), BorrowerProfile{
+				Name:  &tt.borrowerName,
+				Email: &email,
+			})
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -82,6 +115,7 @@ func TestBorrowerService_Create(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, borrower)
 				assert.Equal(t, tt.borrowerName, borrower.Name)
+				assert.Equal(t, constant.BorrowerStatus(constant.BorrowerStatusActive), borrower.Status)
 				assert.NotEmpty(t, borrower.ID)
 			}
 
@@ -90,15 +124,258 @@ func TestBorrowerService_Create(t *testing.T) {
 	}
 }
 
+func TestBorrowerService_Get(t *testing.T) {
+	tests := []struct {
+		name            string
+		borrowerID      string
+		mockSetup       func(mockRepo *MockBorrowerRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+	}{
+		{
+			name:       "Success",
+			borrowerID: "borrower-id-1",
+			mockSetup: func(mockRepo *MockBorrowerRepo) {
+				mockRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-1"
+				})).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Not Found",
+			borrowerID: "borrower-id-2",
+			mockSetup: func(mockRepo *MockBorrowerRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:       "Repository Error",
+			borrowerID: "borrower-id-3",
+			mockSetup: func(mockRepo *MockBorrowerRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Return(errors.New("database error"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockBorrowerRepo)
+			tt.mockSetup(mockRepo)
+
+			service := NewBorrowerService(mockRepo, new(MockLoanRepo), new(MockLoanPaymentRepo), newTestClock())
+			borrower, err := service.Get(context.Background(), tt.borrowerID)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, borrower)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.borrowerID, borrower.ID)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBorrowerService_Update(t *testing.T) {
+	newName := "Johnny Doe"
+	newPhone := "+6281234567890"
+	dob := time.Date(1990, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		borrowerID      string
+		mockSetup       func(mockRepo *MockBorrowerRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+	}{
+		{
+			name:       "Success",
+			borrowerID: "borrower-id-1",
+			mockSetup: func(mockRepo *MockBorrowerRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).
+					Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.Name == newName &&
+						b.Phone == newPhone &&
+						b.DateOfBirth.Equal(dob) &&
+						b.Status == constant.BorrowerStatusActive
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Not Found",
+			borrowerID: "borrower-id-2",
+			mockSetup: func(mockRepo *MockBorrowerRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:       "Repository Error",
+			borrowerID: "borrower-id-3",
+			mockSetup: func(mockRepo *MockBorrowerRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).
+					Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockRepo.On("Update", mock.Anything, mock.Anything).Return(errors.New("database error"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockBorrowerRepo)
+			tt.mockSetup(mockRepo)
+
+			service := NewBorrowerService(mockRepo, new(MockLoanRepo), new(MockLoanPaymentRepo), newTestClock())
+			borrower, err := service.Update(context.Background(), tt.borrowerID, BorrowerProfile{
+				Name:        &newName,
+				Phone:       &newPhone,
+				DateOfBirth: &dob,
+			})
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, borrower)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, newName, borrower.Name)
+				assert.Equal(t, newPhone, borrower.Phone)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBorrowerService_ChangeStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		currentStatus   constant.BorrowerStatus
+		action          func(s *BorrowerService) (*model.Borrower, error)
+		expectUpdate    bool
+		statusChanged   bool
+		expectedStatus  constant.BorrowerStatus
+		expectedErrKind lib.ErrorKind
+	}{
+		{
+			name:          "Deactivate Active Borrower",
+			currentStatus: constant.BorrowerStatusActive,
+			action: func(s *BorrowerService) (*model.Borrower, error) {
+				return s.Deactivate(context.Background(), "borrower-id-1")
+			},
+			expectUpdate:   true,
+			expectedStatus: constant.BorrowerStatusInactive,
+		},
+		{
+			name:          "Deactivate Inactive Borrower Is No-op",
+			currentStatus: constant.BorrowerStatusInactive,
+			action: func(s *BorrowerService) (*model.Borrower, error) {
+				return s.Deactivate(context.Background(), "borrower-id-1")
+			},
+			expectUpdate:   false,
+			expectedStatus: constant.BorrowerStatusInactive,
+		},
+		{
+			name:          "Deactivate Blacklisted Borrower",
+			currentStatus: constant.BorrowerStatusBlacklisted,
+			action: func(s *BorrowerService) (*model.Borrower, error) {
+				return s.Deactivate(context.Background(), "borrower-id-1")
+			},
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+		{
+			name:          "Blacklist Active Borrower",
+			currentStatus: constant.BorrowerStatusActive,
+			action: func(s *BorrowerService) (*model.Borrower, error) {
+				return s.Blacklist(context.Background(), "borrower-id-1")
+			},
+			expectUpdate:   true,
+			expectedStatus: constant.BorrowerStatusBlacklisted,
+		},
+		{
+			name:          "Blacklist Inactive Borrower",
+			currentStatus: constant.BorrowerStatusInactive,
+			action: func(s *BorrowerService) (*model.Borrower, error) {
+				return s.Blacklist(context.Background(), "borrower-id-1")
+			},
+			expectUpdate:   true,
+			expectedStatus: constant.BorrowerStatusBlacklisted,
+		},
+		{
+			name:          "Deactivate Borrower Blacklisted Meanwhile",
+			currentStatus: constant.BorrowerStatusActive,
+			action: func(s *BorrowerService) (*model.Borrower, error) {
+				return s.Deactivate(context.Background(), "borrower-id-1")
+			},
+			expectUpdate:    true,
+			statusChanged:   true,
+			expectedStatus:  constant.BorrowerStatusInactive,
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockBorrowerRepo)
+			mockRepo.On("Get", mock.Anything, mock.Anything).
+				Run(setBorrowerStatus(tt.currentStatus)).Return(nil)
+			if tt.expectUpdate {
+				mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.Status == tt.expectedStatus
+				}), tt.currentStatus).Return(!tt.statusChanged, nil)
+			}
+
+			borrower, err := tt.action(NewBorrowerService(mockRepo, new(MockLoanRepo), new(MockLoanPaymentRepo), newTestClock()))
+
+			if tt.expectedErrKind != "" {
+				assert.Error(t, err)
+				assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, borrower.Status)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestBorrowerService_List(t *testing.T) {
+	delinquent := true
+	lastID := uuid.Must(uuid.NewV7()).String()
+
 	tests := []struct {
-		name          string
-		mockSetup     func(mockRepo *MockBorrowerRepo)
-		expectedError bool
-		expectedCount int
+		name               string
+		filter             BorrowerListFilter
+		mockSetup          func(mockRepo *MockBorrowerRepo)
+		expectedError      bool
+		expectedErrKind    lib.ErrorKind
+		expectedCount      int
+		expectedNextCursor string
 	}{
 		{
-			name: "Success with borrowers",
+			name: "Success with borrowers and next page",
+			filter: BorrowerListFilter{
+				Name:         "doe",
+				IsDelinquent: &delinquent,
+				Sort:         "-name",
+				Limit:        2,
+			},
 			mockSetup: func(mockRepo *MockBorrowerRepo) {
 				borrowers := []*model.BorrowerWithDelinquentStatus{
 					{
@@ -107,38 +384,62 @@ func TestBorrowerService_List(t *testing.T) {
 							Name:      "John Doe",
 							CreatedAt: time.Now(),
 						},
-						IsDelinquent: false,
+						IsDelinquent: true,
 					},
 					{
 						Borrower: model.Borrower{
-							ID:        uuid.Must(uuid.NewV7()).String(),
+							ID:        lastID,
 							Name:      "Jane Doe",
 							CreatedAt: time.Now(),
 						},
 						IsDelinquent: true,
 					},
 				}
-				mockRepo.On("List").Return(borrowers, nil)
+				mockRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.BorrowerFilter) bool {
+					return f.Name == "doe" &&
+						*f.IsDelinquent &&
+						f.Sort == "-name" &&
+						f.After == nil &&
+						f.Limit == 2
+				})).Return(borrowers, &lib.Cursor{ID: lastID, Value: "Jane Doe"}, nil)
 			},
-			expectedError: false,
-			expectedCount: 2,
+			expectedError:      false,
+			expectedCount:      2,
+			expectedNextCursor: lib.EncodeCursor(&lib.Cursor{ID: lastID, Value: "Jane Doe"}),
 		},
 		{
-			name: "Success with empty list",
+			name: "Success with cursor and default limit",
+			filter: BorrowerListFilter{
+				Cursor: lib.EncodeCursor(&lib.Cursor{ID: lastID}),
+			},
 			mockSetup: func(mockRepo *MockBorrowerRepo) {
-				borrowers := []*model.BorrowerWithDelinquentStatus{}
-				mockRepo.On("List").Return(borrowers, nil)
+				mockRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.BorrowerFilter) bool {
+					return f.After != nil &&
+						f.After.ID == lastID &&
+						f.Limit == lib.DefaultPageLimit
+				})).Return([]*model.BorrowerWithDelinquentStatus{}, (*lib.Cursor)(nil), nil)
 			},
-			expectedError: false,
-			expectedCount: 0,
+			expectedError:      false,
+			expectedCount:      0,
+			expectedNextCursor: "",
 		},
 		{
-			name: "Repository Error",
+			name: "Invalid Cursor",
+			filter: BorrowerListFilter{
+				Cursor: "not a cursor!",
+			},
+			mockSetup:       func(mockRepo *MockBorrowerRepo) {},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+		},
+		{
+			name:   "Repository Error",
+			filter: BorrowerListFilter{},
 			mockSetup: func(mockRepo *MockBorrowerRepo) {
-				mockRepo.On("List").Return([]*model.BorrowerWithDelinquentStatus{}, errors.New("database error"))
+				mockRepo.On("List", mock.Anything, mock.Anything).
+					Return([]*model.BorrowerWithDelinquentStatus{}, (*lib.Cursor)(nil), errors.New("database error"))
 			},
 			expectedError: true,
-			expectedCount: 0,
 		},
 	}
 
@@ -147,17 +448,226 @@ func TestBorrowerService_List(t *testing.T) {
 			mockRepo := new(MockBorrowerRepo)
 			tt.mockSetup(mockRepo)
 
-			service := NewBorrowerService(mockRepo)
-			borrowers, err := service.List()
+			service := NewBorrowerService(mockRepo, new(MockLoanRepo), new(MockLoanPaymentRepo), newTestClock())
+			borrowers, nextCursor, err := service.List(context.Background(), tt.filter)
 
 			if tt.expectedError {
 				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Len(t, borrowers, tt.expectedCount)
+				assert.Equal(t, tt.expectedNextCursor, nextCursor)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBorrowerService_GetSummary(t *testing.T) {
+	now := newTestClock().Now()
+	oldestOverdue := now.AddDate(0, 0, -14)
+	nextDue := now.AddDate(0, 0, 7)
+	asOf := lib.TruncateToDate(now.AddDate(0, 0, 30))
+	oldestDueDate := lib.TruncateToDate(oldestOverdue)
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		borrowerID      string
+		mockSetup       func(mockRepo *MockBorrowerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+		checkSummary    func(t *testing.T, s *model.BorrowerSummary)
+	}{
+		{
+			name:       "Success With Overdue Installments",
+			borrowerID: "borrower-id-1",
+			mockSetup: func(mockRepo *MockBorrowerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1").Return(model.LoanStats{
+					LoanCount:       3,
+					ActiveLoanCount: 1,
+					Currencies: []*model.LoanCurrencyStats{
+						{Currency: "IDR", LoanCount: 3, ActiveLoanCount: 1, TotalPrincipal: idr(15_000_000), TotalOutstanding: idr(5_500_000)},
+					},
+				}, nil)
+				mockLoanPaymentRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1", mock.Anything).Return(model.LoanPaymentStats{
+					OverdueCount:         2,
+					OldestOverdueDueDate: &oldestOverdue,
+					Currencies: []*model.LoanPaymentCurrencyStats{
+						{
+							Currency:             "IDR",
+							TotalRepaid:          idr(11_000_000),
+							TotalOutstanding:     idr(5_500_000),
+							OverdueAmount:        idr(220_000),
+							OverdueCount:         2,
+							OldestOverdueDueDate: &oldestOverdue,
+							NextDueDate:          &nextDue,
+							NextDueAmount:        idr(110_000),
+						},
+					},
+				}, nil)
+			},
+			expectedError: false,
+			checkSummary: func(t *testing.T, s *model.BorrowerSummary) {
+				assert.Equal(t, "borrower-id-1", s.BorrowerID)
+				assert.Len(t, s.Currencies, 1)
+				assert.Equal(t, "IDR", s.Currencies[0].Currency)
+				assert.True(t, idr(15_000_000).Equal(s.Currencies[0].TotalPrincipal))
+				assert.True(t, idr(11_000_000).Equal(s.Currencies[0].TotalRepaid))
+				assert.True(t, idr(5_500_000).Equal(s.Currencies[0].TotalOutstanding))
+				assert.True(t, idr(220_000).Equal(s.Currencies[0].OverdueAmount))
+				assert.Equal(t, &nextDue, s.Currencies[0].NextDueDate)
+				assert.True(t, idr(110_000).Equal(s.Currencies[0].NextDueAmount))
+				assert.Equal(t, 3, s.LoanCount)
+				assert.Equal(t, 1, s.ActiveLoanCount)
+				assert.Equal(t, 2, s.CompletedLoanCount)
+				assert.Equal(t, 14, s.DaysPastDue)
+				assert.Equal(t, constant.DelinquencyBucket(constant.DelinquencyBucket1To30), s.DelinquencyBucket)
+				assert.Equal(t, now, s.AsOf)
+			},
+		},
+		{
+			name:       "Success With Loans In Several Currencies",
+			borrowerID: "borrower-id-1",
+			mockSetup: func(mockRepo *MockBorrowerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1").Return(model.LoanStats{
+					LoanCount:       2,
+					ActiveLoanCount: 2,
+					Currencies: []*model.LoanCurrencyStats{
+						{Currency: "IDR", LoanCount: 1, ActiveLoanCount: 1, TotalPrincipal: idr(5_000_000), TotalOutstanding: idr(5_500_000)},
+						{Currency: "USD", LoanCount: 1, ActiveLoanCount: 1, TotalPrincipal: lib.NewMoneyFromInt(1_000, "USD"), TotalOutstanding: lib.NewMoneyFromInt(1_100, "USD")},
+					},
+				}, nil)
+				mockLoanPaymentRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1", mock.Anything).Return(model.LoanPaymentStats{
+					Currencies: []*model.LoanPaymentCurrencyStats{
+						{Currency: "IDR", TotalRepaid: idr(0), TotalOutstanding: idr(5_500_000), OverdueAmount: idr(0), NextDueDate: &nextDue, NextDueAmount: idr(110_000)},
+						{Currency: "USD", TotalRepaid: lib.ZeroMoney("USD"), TotalOutstanding: lib.NewMoneyFromInt(1_100, "USD"), OverdueAmount: lib.ZeroMoney("USD"), NextDueDate: &nextDue, NextDueAmount: lib.NewMoneyFromInt(22, "USD")},
+					},
+				}, nil)
+			},
+			expectedError: false,
+			checkSummary: func(t *testing.T, s *model.BorrowerSummary) {
+				// each currency is totalled on its own
+				assert.Len(t, s.Currencies, 2)
+				assert.Equal(t, "IDR", s.Currencies[0].Currency)
+				assert.True(t, idr(5_500_000).Equal(s.Currencies[0].TotalOutstanding))
+				assert.Equal(t, "USD", s.Currencies[1].Currency)
+				assert.True(t, lib.NewMoneyFromInt(1_000, "USD").Equal(s.Currencies[1].TotalPrincipal))
+				assert.True(t, lib.NewMoneyFromInt(1_100, "USD").Equal(s.Currencies[1].TotalOutstanding))
+				assert.True(t, lib.NewMoneyFromInt(22, "USD").Equal(s.Currencies[1].NextDueAmount))
+				assert.Equal(t, 2, s.LoanCount)
+				assert.Equal(t, 0, s.CompletedLoanCount)
+			},
+		},
+		{
+			name:       "Success As Of A Future Date",
+			ctx:        lib.WithAsOf(context.Background(), asOf),
+			borrowerID: "borrower-id-1",
+			mockSetup: func(mockRepo *MockBorrowerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1").Return(model.LoanStats{
+					LoanCount:       1,
+					ActiveLoanCount: 1,
+					Currencies: []*model.LoanCurrencyStats{
+						{Currency: "IDR", LoanCount: 1, ActiveLoanCount: 1, TotalPrincipal: idr(5_000_000), TotalOutstanding: idr(5_500_000)},
+					},
+				}, nil)
+				// the stats are projected to the as-of date instead of the clock
+				mockLoanPaymentRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1", asOf).Return(model.LoanPaymentStats{
+					OverdueCount:         1,
+					OldestOverdueDueDate: &oldestDueDate,
+					Currencies: []*model.LoanPaymentCurrencyStats{
+						{
+							Currency:             "IDR",
+							TotalRepaid:          idr(0),
+							TotalOutstanding:     idr(5_500_000),
+							OverdueAmount:        idr(110_000),
+							OverdueCount:         1,
+							OldestOverdueDueDate: &oldestDueDate,
+							NextDueAmount:        idr(0),
+						},
+					},
+				}, nil)
+			},
+			expectedError: false,
+			checkSummary: func(t *testing.T, s *model.BorrowerSummary) {
+				assert.Equal(t, 44, s.DaysPastDue)
+				assert.Equal(t, constant.DelinquencyBucket(constant.DelinquencyBucket31To60), s.DelinquencyBucket)
+				assert.Equal(t, asOf, s.AsOf)
+			},
+		},
+		{
+			name:       "Success Without Loans",
+			borrowerID: "borrower-id-2",
+			mockSetup: func(mockRepo *MockBorrowerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-2").Return(model.LoanStats{}, nil)
+				mockLoanPaymentRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-2", mock.Anything).Return(model.LoanPaymentStats{}, nil)
+			},
+			expectedError: false,
+			checkSummary: func(t *testing.T, s *model.BorrowerSummary) {
+				assert.Empty(t, s.Currencies)
+				assert.NotNil(t, s.Currencies)
+				assert.Equal(t, 0, s.LoanCount)
+				assert.Equal(t, 0, s.DaysPastDue)
+				assert.Equal(t, constant.DelinquencyBucket(constant.DelinquencyBucketCurrent), s.DelinquencyBucket)
+			},
+		},
+		{
+			name:       "Borrower Not Found",
+			borrowerID: "borrower-id-3",
+			mockSetup: func(mockRepo *MockBorrowerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:       "Repository Error",
+			borrowerID: "borrower-id-4",
+			mockSetup: func(mockRepo *MockBorrowerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockRepo.On("Get", mock.Anything, mock.Anything).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-4").Return(model.LoanStats{}, errors.New("database error"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockBorrowerRepo)
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			tt.mockSetup(mockRepo, mockLoanRepo, mockLoanPaymentRepo)
+
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			service := NewBorrowerService(mockRepo, mockLoanRepo, mockLoanPaymentRepo, newTestClock())
+			summary, err := service.GetSummary(ctx, tt.borrowerID)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, summary)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				tt.checkSummary(t, summary)
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockLoanRepo.AssertExpectations(t)
+			mockLoanPaymentRepo.AssertExpectations(t)
 		})
 	}
 }
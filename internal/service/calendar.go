@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+)
+
+// CalendarConfig picks the holiday calendar of a country and the convention moving due dates off weekends and
+// holidays. NONE, the default, turns business day handling off.
+type CalendarConfig struct {
+	CountryCode string
+	Adjustment  constant.DateAdjustment
+}
+
+func (c CalendarConfig) enabled() bool {
+	return c.Adjustment != "" && c.Adjustment != constant.DateAdjustmentNone
+}
+
+// loadBusinessCalendar builds the calendar of the country with the holidays between from and to
+func loadBusinessCalendar(ctx context.Context, holidayRepo repository.HolidayRepo, countryCode string, from, to time.Time) (*lib.BusinessCalendar, error) {
+	hs, err := holidayRepo.List(ctx, countryCode, lib.TruncateToDate(from), lib.TruncateToDate(to).AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	dates := make([]time.Time, len(hs))
+	for i, h := range hs {
+		dates[i] = h.Date
+	}
+	return lib.NewBusinessCalendar(dates), nil
+}
+
+type CalendarService struct {
+	holidayRepo repository.HolidayRepo
+}
+
+func NewCalendarService(holidayRepo repository.HolidayRepo) *CalendarService {
+	return &CalendarService{holidayRepo: holidayRepo}
+}
+
+// ListHolidays returns the holidays of a country in a calendar year
+func (s *CalendarService) ListHolidays(ctx context.Context, countryCode string, year int) ([]*model.Holiday, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return s.holidayRepo.List(ctx, countryCode, from, from.AddDate(1, 0, 0))
+}
+
+// SaveHolidays stores the holidays, renaming the ones already known for the same country and date. Schedules of
+// loans already created are not moved.
+func (s *CalendarService) SaveHolidays(ctx context.Context, hs []*model.Holiday) error {
+	for _, h := range hs {
+		h.Date = lib.TruncateToDate(h.Date)
+	}
+	return s.holidayRepo.Save(ctx, hs)
+}
+
+func (s *CalendarService) DeleteHoliday(ctx context.Context, id string) error {
+	deleted, err := s.holidayRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return lib.NewNotFoundError(constant.ErrCodeHolidayNotFound, "holiday not found")
+	}
+	return nil
+}
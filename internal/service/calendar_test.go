@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHolidayRepo is a mock implementation of repository.HolidayRepo
+type MockHolidayRepo struct {
+	mock.Mock
+}
+
+func (m *MockHolidayRepo) Save(ctx context.Context, hs []*model.Holiday) error {
+	args := m.Called(ctx, hs)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepo) Delete(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockHolidayRepo) List(ctx context.Context, countryCode string, from, to time.Time) ([]*model.Holiday, error) {
+	args := m.Called(ctx, countryCode, from, to)
+	return args.Get(0).([]*model.Holiday), args.Error(1)
+}
+
+func TestCalendarService_ListHolidays(t *testing.T) {
+	mockHolidayRepo := new(MockHolidayRepo)
+	mockHolidayRepo.On("List", mock.Anything, "ID",
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	).Return([]*model.Holiday{{ID: "holiday-id-1", CountryCode: "ID"}}, nil)
+
+	service := NewCalendarService(mockHolidayRepo)
+	hs, err := service.ListHolidays(context.Background(), "ID", 2025)
+
+	assert.NoError(t, err)
+	assert.Len(t, hs, 1)
+	mockHolidayRepo.AssertExpectations(t)
+}
+
+func TestCalendarService_SaveHolidays(t *testing.T) {
+	mockHolidayRepo := new(MockHolidayRepo)
+	mockHolidayRepo.On("Save", mock.Anything, mock.MatchedBy(func(hs []*model.Holiday) bool {
+		return hs[0].Date.Equal(time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC))
+	})).Return(nil)
+
+	service := NewCalendarService(mockHolidayRepo)
+	err := service.SaveHolidays(context.Background(), []*model.Holiday{
+		{CountryCode: "ID", Date: time.Date(2025, 12, 25, 9, 30, 0, 0, time.UTC), Name: "Christmas Day"},
+	})
+
+	assert.NoError(t, err)
+	mockHolidayRepo.AssertExpectations(t)
+}
+
+func TestCalendarService_DeleteHoliday(t *testing.T) {
+	tests := []struct {
+		name            string
+		id              string
+		mockSetup       func(mockHolidayRepo *MockHolidayRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+	}{
+		{
+			name: "Success",
+			id:   "holiday-id-1",
+			mockSetup: func(mockHolidayRepo *MockHolidayRepo) {
+				mockHolidayRepo.On("Delete", mock.Anything, "holiday-id-1").Return(true, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Holiday Not Found",
+			id:   "holiday-id-2",
+			mockSetup: func(mockHolidayRepo *MockHolidayRepo) {
+				mockHolidayRepo.On("Delete", mock.Anything, "holiday-id-2").Return(false, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name: "Repository Error",
+			id:   "holiday-id-3",
+			mockSetup: func(mockHolidayRepo *MockHolidayRepo) {
+				mockHolidayRepo.On("Delete", mock.Anything, "holiday-id-3").Return(false, errors.New("database error"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHolidayRepo := new(MockHolidayRepo)
+			tt.mockSetup(mockHolidayRepo)
+
+			service := NewCalendarService(mockHolidayRepo)
+			err := service.DeleteHoliday(context.Background(), tt.id)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockHolidayRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoanService_SimulateLoanOnBusinessDays(t *testing.T) {
+	// the test clock is on a Saturday, so every weekly due date is too, and 2025-03-24 is a holiday
+	mockHolidayRepo := new(MockHolidayRepo)
+	mockHolidayRepo.On("List", mock.Anything, "ID", mock.Anything, mock.Anything).Return([]*model.Holiday{
+		{CountryCode: "ID", Date: time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+
+	service := NewLoanService(new(MockLoanRepo), new(MockLoanPaymentRepo), new(MockBorrowerRepo), new(MockLedgerRepo), mockHolidayRepo, new(MockTxManager), newTestClock(), LoanConfig{
+		Calendar: CalendarConfig{
+			CountryCode: "ID",
+			Adjustment:  constant.DateAdjustmentFollowing,
+		},
+	})
+	simulation, err := service.SimulateLoan(context.Background(), model.Loan{
+		Principal:          idr(1_000_000),
+		AnnualInterestRate: decimal.NewFromInt(10),
+		Period:             3,
+		PeriodUnit:         constant.PeriodUnitWeek,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 25, 0, 0, 0, 0, time.UTC), simulation.Installments[0].LocalDueDate)
+	assert.Equal(t, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), simulation.Installments[1].LocalDueDate)
+	assert.Equal(t, time.Date(2025, 4, 7, 0, 0, 0, 0, time.UTC), simulation.Installments[2].LocalDueDate)
+	assert.Equal(t, time.Date(2025, 4, 8, 0, 0, 0, 0, time.UTC), simulation.Installments[2].DueDate)
+	mockHolidayRepo.AssertExpectations(t)
+}
+
+func TestBillingService_RunDailyBillingOnBusinessDays(t *testing.T) {
+	date := func(d int) time.Time {
+		return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+	calendar := CalendarConfig{CountryCode: "ID", Adjustment: constant.DateAdjustmentFollowing}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		calendar CalendarConfig
+		// the local due date installments must fall due before to be overdue, per loan timezone
+		expectedDueBefore map[string]time.Time
+	}{
+		{
+			// on Saturday the weekend has only started, so Friday's installments are overdue
+			name:     "Saturday",
+			now:      time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+			calendar: calendar,
+			expectedDueBefore: map[string]time.Time{
+				"America/New_York": date(15),
+				"Asia/Jakarta":     date(15),
+				"UTC":              date(15),
+			},
+		},
+		{
+			// it is still Monday in New York: the Friday installment, due Saturday morning UTC, is overdue while the
+			// weekend ones can be paid until the day ends. Tuesday has begun in Jakarta and UTC, so Monday's are overdue.
+			name:     "Monday Evening In New York",
+			now:      time.Date(2025, 3, 18, 3, 0, 0, 0, time.UTC),
+			calendar: calendar,
+			expectedDueBefore: map[string]time.Time{
+				"America/New_York": date(15),
+				"Asia/Jakarta":     date(18),
+				"UTC":              date(18),
+			},
+		},
+		{
+			name: "Without Business Day Convention",
+			now:  time.Date(2025, 3, 18, 3, 0, 0, 0, time.UTC),
+			expectedDueBefore: map[string]time.Time{
+				"America/New_York": date(17),
+				"Asia/Jakarta":     date(18),
+				"UTC":              date(18),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			mockHolidayRepo := new(MockHolidayRepo)
+			mockJobRunRepo := new(MockJobRunRepo)
+			mockJobLocker := new(MockJobLocker)
+			mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+			mockJobRunRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			mockJobRunRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+			if tt.calendar.enabled() {
+				mockHolidayRepo.On("List", mock.Anything, "ID", mock.Anything, mock.Anything).Return([]*model.Holiday{}, nil)
+			}
+			mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+			mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+			mockLoanRepo.On("FindUnpaidTimezones", mock.Anything).Return([]string{"America/New_York", "Asia/Jakarta", "UTC"}, nil)
+			for timezone, dueBefore := range tt.expectedDueBefore {
+				mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, timezone, dueBefore).Return(int64(1), nil)
+			}
+			mockLoanRepo.On("UpdateOverdueBalances", mock.Anything).Return(int64(0), nil)
+			mockLoanRepo.On("UpdateDaysPastDue", mock.Anything, mock.Anything).Return(int64(0), nil)
+
+			service := NewBillingService(mockLoanRepo, mockLoanPaymentRepo, new(MockLedgerRepo), mockHolidayRepo, new(MockOutboxRepo), mockJobRunRepo, mockJobLocker, new(MockTxManager), lib.NewFakeClock(tt.now), BillingConfig{
+				Calendar: tt.calendar,
+			})
+			run, err := service.RunDailyBilling(context.Background(), constant.JobTriggerManual)
+
+			assert.NoError(t, err)
+			assert.Equal(t, constant.JobRunStatus(constant.JobRunStatusSucceeded), run.Status)
+			mockLoanPaymentRepo.AssertExpectations(t)
+			mockHolidayRepo.AssertExpectations(t)
+		})
+	}
+}
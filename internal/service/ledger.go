@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type LedgerService struct {
+	ledgerRepo          repository.LedgerRepo
+	loanRepo            repository.LoanRepo
+	loanPaymentRepo     repository.LoanPaymentRepo
+	interestAccrualRepo repository.InterestAccrualRepo
+	txManager           repository.TxManager
+	clock               lib.Clock
+}
+
+func NewLedgerService(ledgerRepo repository.LedgerRepo, loanRepo repository.LoanRepo, loanPaymentRepo repository.LoanPaymentRepo, interestAccrualRepo repository.InterestAccrualRepo, txManager repository.TxManager, clock lib.Clock) *LedgerService {
+	return &LedgerService{
+		ledgerRepo:          ledgerRepo,
+		loanRepo:            loanRepo,
+		loanPaymentRepo:     loanPaymentRepo,
+		interestAccrualRepo: interestAccrualRepo,
+		txManager:           txManager,
+		clock:               clock,
+	}
+}
+
+// JournalEntryListFilter is the journal entry list query as received from the client, with an opaque cursor
+type JournalEntryListFilter struct {
+	LoanID string
+	Type   constant.JournalEntryType
+	Cursor string
+	Limit  int
+}
+
+func (s *LedgerService) ListEntries(ctx context.Context, f JournalEntryListFilter) ([]*model.JournalEntry, string, error) {
+	after, err := lib.DecodeCursor(f.Cursor)
+	if err != nil {
+		return nil, "", lib.NewValidationError(constant.ErrCodeInvalidCursor, "invalid cursor").Wrap(err)
+	}
+
+	entries, next, err := s.ledgerRepo.ListEntries(ctx, repository.JournalEntryFilter{
+		LoanID: f.LoanID,
+		Type:   f.Type,
+		After:  after,
+		Limit:  lib.NormalizePageLimit(f.Limit),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return entries, lib.EncodeCursor(next), nil
+}
+
+// ReverseEntry posts the mirror image of an entry. An entry can be reversed once and reversals themselves cannot be reversed.
+// What the entry changed besides the ledger is undone in the same transaction: the installments a repayment or waiver
+// settled are outstanding again, a late fee is taken off its installment, an interest accrual is removed so its day
+// can be accrued again and a disbursement cancels the installments of its loan. The loan balances are then recomputed,
+// moving the loan to its next version. A disbursement can only be reversed once every other entry of its loan has been.
+func (s *LedgerService) ReverseEntry(ctx context.Context, id string) (*model.JournalEntry, error) {
+	e := &model.JournalEntry{
+		ID: id,
+	}
+	err := s.ledgerRepo.GetEntry(ctx, e)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, lib.NewNotFoundError(constant.ErrCodeJournalEntryNotFound, "journal entry not found").Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.Type == constant.JournalEntryTypeReversal {
+		return nil, lib.NewBusinessRuleError(constant.ErrCodeReversalNotReversible, "a reversal entry cannot be reversed")
+	}
+
+	reversed, err := s.ledgerRepo.IsReversed(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reversed {
+		return nil, lib.NewConflictError(constant.ErrCodeEntryAlreadyReversed, "journal entry has already been reversed")
+	}
+
+	now := s.clock.Now()
+	r := e.Reverse(now)
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		l, err := lockLoan(ctx, s.loanRepo.WithTx(tx), e.LoanID, 0)
+		if err != nil {
+			return err
+		}
+		// reversals of the loan's entries are serialised by the loan lock, so one may have committed since the check above
+		reversed, err := s.ledgerRepo.WithTx(tx).IsReversed(ctx, id)
+		if err != nil {
+			return err
+		}
+		if reversed {
+			return lib.NewConflictError(constant.ErrCodeEntryAlreadyReversed, "journal entry has already been reversed")
+		}
+		err = restoreInstallments(ctx, s.loanPaymentRepo.WithTx(tx), e, now)
+		if err != nil {
+			return err
+		}
+		switch e.Type {
+		case constant.JournalEntryTypeInterestAccrual:
+			deleted, err := s.interestAccrualRepo.WithTx(tx).DeleteByJournalEntryID(ctx, e.ID)
+			if err != nil {
+				return err
+			}
+			if !deleted {
+				return lib.NewBusinessRuleError(constant.ErrCodeEntryNotReversible, "journal entry %s did not book any interest accrual", e.ID)
+			}
+		case constant.JournalEntryTypeDisbursement:
+			// the loan is undone as a whole, after whatever was booked on it since
+			open, err := s.ledgerRepo.WithTx(tx).HasUnreversedEntries(ctx, e.LoanID, e.ID)
+			if err != nil {
+				return err
+			}
+			if open {
+				return lib.NewBusinessRuleError(constant.ErrCodeEntryNotReversible, "the other entries of loan %s must be reversed first", e.LoanID)
+			}
+			_, err = s.loanPaymentRepo.WithTx(tx).Cancel(ctx, e.LoanID)
+			if err != nil {
+				return err
+			}
+		}
+		err = refreshLoanBalances(ctx, s.loanRepo.WithTx(tx), s.loanPaymentRepo.WithTx(tx), l)
+		if err != nil {
+			return err
+		}
+		return s.ledgerRepo.WithTx(tx).CreateEntry(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// restoreInstallments undoes what a repayment, waiver or fee accrual entry did to the installments of its loan. Other
+// entries leave the installments alone. Installments are settled oldest first, so a settlement can only be undone while
+// no later installment has been settled, and a late fee only while its installment is outstanding.
+func restoreInstallments(ctx context.Context, loanPaymentRepo repository.LoanPaymentRepo, e *model.JournalEntry, at time.Time) error {
+	switch e.Type {
+	case constant.JournalEntryTypeRepayment, constant.JournalEntryTypeWaiver:
+		lps, err := loanPaymentRepo.Find(ctx, model.LoanPayment{LoanID: e.LoanID})
+		if err != nil {
+			return err
+		}
+		settled := make([]*model.LoanPayment, 0)
+		for _, lp := range lps {
+			if lp.IsSettledBy(e.ID) {
+				settled = append(settled, lp)
+			}
+		}
+		if len(settled) == 0 {
+			return lib.NewBusinessRuleError(constant.ErrCodeEntryNotReversible, "journal entry %s did not settle any installment", e.ID)
+		}
+		// lps come in due date order
+		last := settled[len(settled)-1]
+		for _, lp := range lps {
+			if lp.IsSettled() && !lp.IsSettledBy(e.ID) && lp.DueDate.After(last.DueDate) {
+				return lib.NewBusinessRuleError(constant.ErrCodeEntryNotReversible, "installment %s was settled later and must be reopened first", lp.ID)
+			}
+		}
+
+		for _, lp := range settled {
+			from := lp.Status
+			if err := lp.Reopen(at); err != nil {
+				return err
+			}
+			updated, err := loanPaymentRepo.UpdateStatus(ctx, lp, from)
+			if err != nil {
+				return err
+			}
+			if !updated {
+				return lib.NewConflictError(constant.ErrCodeInvalidStatusTransition, "installment changed while it was being reopened")
+			}
+		}
+	case constant.JournalEntryTypeFeeAccrual:
+		lps, err := loanPaymentRepo.Find(ctx, model.LoanPayment{LateFeeEntryID: &e.ID})
+		if err != nil {
+			return err
+		}
+		if len(lps) == 0 {
+			return lib.NewBusinessRuleError(constant.ErrCodeEntryNotReversible, "journal entry %s did not charge a late fee on any installment", e.ID)
+		}
+		lp := lps[0]
+		if lp.IsSettled() {
+			return lib.NewBusinessRuleError(constant.ErrCodeEntryNotReversible, "installment %s has been settled with its late fee and must be reopened first", lp.ID)
+		}
+		removed, err := loanPaymentRepo.RemoveLateFee(ctx, lp.ID, e.ID)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return lib.NewConflictError(constant.ErrCodeInvalidStatusTransition, "installment changed while its late fee was being removed")
+		}
+	}
+	return nil
+}
+
+// GetTrialBalance sums every account up to and including the asOf date, or over the whole ledger when asOf is nil.
+// Each currency is balanced on its own, and besides comparing its totals it lists any stored entry that breaks the
+// debit equals credit invariant.
+func (s *LedgerService) GetTrialBalance(ctx context.Context, asOf *time.Time) (*model.TrialBalance, error) {
+	var postedBefore *time.Time
+	if asOf != nil {
+		t := asOf.AddDate(0, 0, 1)
+		postedBefore = &t
+	}
+
+	lines, err := s.ledgerRepo.GetTrialBalance(ctx, postedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	unbalanced, err := s.ledgerRepo.FindUnbalancedEntryIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &model.TrialBalance{
+		AsOf:               asOf,
+		Currencies:         make([]*model.CurrencyTrialBalance, 0),
+		IsBalanced:         len(unbalanced) == 0,
+		UnbalancedEntryIDs: unbalanced,
+	}
+	// lines come ordered by currency
+	var ctb *model.CurrencyTrialBalance
+	for _, l := range lines {
+		if ctb == nil || ctb.Currency != l.Currency {
+			ctb = &model.CurrencyTrialBalance{
+				Currency:    l.Currency,
+				Accounts:    make([]*model.TrialBalanceLine, 0),
+				TotalDebit:  decimal.Zero,
+				TotalCredit: decimal.Zero,
+			}
+			tb.Currencies = append(tb.Currencies, ctb)
+		}
+		a := model.LedgerAccount{Type: l.AccountType}
+		if a.IsDebitNormal() {
+			l.Balance = l.Debit.Sub(l.Credit)
+		} else {
+			l.Balance = l.Credit.Sub(l.Debit)
+		}
+		ctb.Accounts = append(ctb.Accounts, l)
+		ctb.TotalDebit = ctb.TotalDebit.Add(l.Debit)
+		ctb.TotalCredit = ctb.TotalCredit.Add(l.Credit)
+	}
+	for _, ctb := range tb.Currencies {
+		ctb.IsBalanced = ctb.TotalDebit.Equal(ctb.TotalCredit)
+		tb.IsBalanced = tb.IsBalanced && ctb.IsBalanced
+	}
+
+	return tb, nil
+}
+
+func newEntry(entryType constant.JournalEntryType, loanID, description string, postedAt time.Time, lines ...*model.JournalLine) *model.JournalEntry {
+	return &model.JournalEntry{
+		Type:        entryType,
+		LoanID:      loanID,
+		Description: description,
+		PostedAt:    postedAt,
+		Lines:       lines,
+	}
+}
+
+func debit(account string, amount lib.Money) *model.JournalLine {
+	return &model.JournalLine{AccountCode: account, Currency: amount.Currency, Debit: amount.Amount, Credit: decimal.Zero}
+}
+
+func credit(account string, amount lib.Money) *model.JournalLine {
+	return &model.JournalLine{AccountCode: account, Currency: amount.Currency, Debit: decimal.Zero, Credit: amount.Amount}
+}
+
+// newDisbursementEntry books the principal owed against the cash paid out, the origination fee being earned upfront
+func newDisbursementEntry(l model.Loan) *model.JournalEntry {
+	lines := []*model.JournalLine{
+		debit(constant.LedgerAccountLoanReceivable, l.Principal),
+		credit(constant.LedgerAccountCash, l.Principal.Sub(l.OriginationFee)),
+	}
+	if l.OriginationFee.IsPositive() {
+		lines = append(lines, credit(constant.LedgerAccountFeeIncome, l.OriginationFee))
+	}
+	return newEntry(constant.JournalEntryTypeDisbursement, l.ID, "loan disbursement", l.CreatedAt, lines...)
+}
+
+// newRepaymentEntry books the cash received, settling the principal part of the loan receivable and the rest
+// against the accrued interest. Interest paid ahead of its accrual leaves the interest receivable negative,
+// which is the unearned interest still to be recognised by the accrual job.
+func newRepaymentEntry(loanID string, amount, principal lib.Money, postedAt time.Time) *model.JournalEntry {
+	if amount.LessThan(principal) {
+		principal = amount
+	}
+	lines := []*model.JournalLine{
+		debit(constant.LedgerAccountCash, amount),
+		credit(constant.LedgerAccountLoanReceivable, principal),
+	}
+	if interest := amount.Sub(principal); interest.IsPositive() {
+		lines = append(lines, credit(constant.LedgerAccountInterestReceivable, interest))
+	}
+	return newEntry(constant.JournalEntryTypeRepayment, loanID, "loan repayment", postedAt, lines...)
+}
+
+// newInterestAccrualEntry recognises interest earned on a day as income
+func newInterestAccrualEntry(loanID string, amount lib.Money, date time.Time) *model.JournalEntry {
+	return newEntry(constant.JournalEntryTypeInterestAccrual, loanID, fmt.Sprintf("interest accrual for %s", date.Format(time.DateOnly)), date,
+		debit(constant.LedgerAccountInterestReceivable, amount),
+		credit(constant.LedgerAccountInterestIncome, amount),
+	)
+}
+
+func newFeeAccrualEntry(loanID string, amount lib.Money, description string, postedAt time.Time) *model.JournalEntry {
+	return newEntry(constant.JournalEntryTypeFeeAccrual, loanID, description, postedAt,
+		debit(constant.LedgerAccountLoanReceivable, amount),
+		credit(constant.LedgerAccountFeeIncome, amount),
+	)
+}
+
+// newWriteOffEntry charges the remaining loan and interest receivables of a loan to loan loss expense
+func newWriteOffEntry(loanID string, principal, interest lib.Money, postedAt time.Time) *model.JournalEntry {
+	return newLossEntry(constant.JournalEntryTypeWriteOff, loanID, "loan write-off", principal, interest, postedAt)
+}
+
+// newWaiverEntry charges the principal and interest of a waived installment to loan loss expense
+func newWaiverEntry(loanID string, principal, interest lib.Money, postedAt time.Time) *model.JournalEntry {
+	return newLossEntry(constant.JournalEntryTypeWaiver, loanID, "installment waiver", principal, interest, postedAt)
+}
+
+func newLossEntry(entryType constant.JournalEntryType, loanID, description string, principal, interest lib.Money, postedAt time.Time) *model.JournalEntry {
+	if principal.IsNegative() {
+		principal = lib.ZeroMoney(principal.Currency)
+	}
+	if interest.IsNegative() {
+		interest = lib.ZeroMoney(interest.Currency)
+	}
+	lines := []*model.JournalLine{
+		debit(constant.LedgerAccountLoanLossExpense, principal.Add(interest)),
+	}
+	if principal.IsPositive() {
+		lines = append(lines, credit(constant.LedgerAccountLoanReceivable, principal))
+	}
+	if interest.IsPositive() {
+		lines = append(lines, credit(constant.LedgerAccountInterestReceivable, interest))
+	}
+	return newEntry(entryType, loanID, description, postedAt, lines...)
+}
+
+// repaymentPrincipal returns the principal part of paying count installments starting at index first of the schedule
+func repaymentPrincipal(l model.Loan, first, count int) lib.Money {
+	installments := l.Installments()
+	principal := lib.ZeroMoney(l.Currency)
+	for i := max(first, 0); i < first+count && i < len(installments); i++ {
+		principal = principal.Add(installments[i].Principal)
+	}
+	return principal
+}
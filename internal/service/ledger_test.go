@@ -0,0 +1,499 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockLedgerRepo is a mock implementation of repository.LedgerRepo
+type MockLedgerRepo struct {
+	mock.Mock
+}
+
+func (m *MockLedgerRepo) WithTx(tx *gorm.DB) repository.LedgerRepo {
+	args := m.Called(tx)
+	return args.Get(0).(repository.LedgerRepo)
+}
+
+func (m *MockLedgerRepo) CreateEntry(ctx context.Context, e *model.JournalEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepo) GetEntry(ctx context.Context, e *model.JournalEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepo) IsReversed(ctx context.Context, entryID string) (bool, error) {
+	args := m.Called(ctx, entryID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLedgerRepo) ListEntries(ctx context.Context, f repository.JournalEntryFilter) ([]*model.JournalEntry, *lib.Cursor, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]*model.JournalEntry), args.Get(1).(*lib.Cursor), args.Error(2)
+}
+
+func (m *MockLedgerRepo) GetLoanAccountBalance(ctx context.Context, loanID, accountCode string) (decimal.Decimal, error) {
+	args := m.Called(ctx, loanID, accountCode)
+	return args.Get(0).(decimal.Decimal), args.Error(1)
+}
+
+func (m *MockLedgerRepo) IsLoanWrittenOff(ctx context.Context, loanID string) (bool, error) {
+	args := m.Called(ctx, loanID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLedgerRepo) HasUnreversedEntries(ctx context.Context, loanID, exceptID string) (bool, error) {
+	args := m.Called(ctx, loanID, exceptID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLedgerRepo) GetTrialBalance(ctx context.Context, postedBefore *time.Time) ([]*model.TrialBalanceLine, error) {
+	args := m.Called(ctx, postedBefore)
+	return args.Get(0).([]*model.TrialBalanceLine), args.Error(1)
+}
+
+func (m *MockLedgerRepo) FindUnbalancedEntryIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// setRepaymentEntry simulates GetEntry returning a posted repayment
+func setRepaymentEntry(entryType constant.JournalEntryType) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		e := args.Get(1).(*model.JournalEntry)
+		e.Type = entryType
+		e.LoanID = "loan-id-1"
+		e.Lines = []*model.JournalLine{
+			debit(constant.LedgerAccountCash, idr(110_000)),
+			credit(constant.LedgerAccountLoanReceivable, idr(100_000)),
+			credit(constant.LedgerAccountInterestIncome, idr(10_000)),
+		}
+	}
+}
+
+func TestLedgerService_ReverseEntry(t *testing.T) {
+	now := newTestClock().Now()
+	entryID := func(id string) *string {
+		return &id
+	}
+	// installments of loan-id-1: the first two paid by separate repayments, the third overdue with a late fee
+	installments := func() []*model.LoanPayment {
+		paidAt := now.AddDate(0, 0, -10)
+		return []*model.LoanPayment{
+			{ID: "lp-id-1", LoanID: "loan-id-1", Amount: idr(110_000), LateFee: idr(0), DueDate: now.AddDate(0, 0, -14), Status: constant.LoanPaymentStatusPaid, PaidAt: &paidAt, SettledByEntryID: entryID("entry-id-0")},
+			{ID: "lp-id-2", LoanID: "loan-id-1", Amount: idr(110_000), LateFee: idr(0), DueDate: now.AddDate(0, 0, -7), Status: constant.LoanPaymentStatusPaid, PaidAt: &paidAt, SettledByEntryID: entryID("entry-id-1")},
+			{ID: "lp-id-3", LoanID: "loan-id-1", Amount: idr(110_000), LateFee: idr(25_000), DueDate: now.AddDate(0, 0, -1), Status: constant.LoanPaymentStatusOverdue, LateFeeEntryID: entryID("entry-id-6")},
+			{ID: "lp-id-4", LoanID: "loan-id-1", Amount: idr(110_000), LateFee: idr(0), DueDate: now.AddDate(0, 0, 6), Status: constant.LoanPaymentStatusUnpaid},
+		}
+	}
+	// lockAndRefresh expects the loan to be locked and its balances recomputed from the installments
+	lockAndRefresh := func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, lps []*model.LoanPayment, checkLoan func(l *model.Loan) bool) {
+		mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+		mockLoanRepo.On("GetForUpdate", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+			return l.ID == "loan-id-1"
+		})).Return(nil)
+		mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+		mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return(lps, nil)
+		mockLoanRepo.On("UpdateBalances", mock.Anything, mock.MatchedBy(checkLoan)).Return(true, nil)
+	}
+
+	tests := []struct {
+		name            string
+		entryID         string
+		mockSetup       func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+	}{
+		{
+			name:    "Success - Repayment Reversal Reopens Its Installments",
+			entryID: "entry-id-1",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.ID == "entry-id-1"
+				})).Run(setRepaymentEntry(constant.JournalEntryTypeRepayment)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-1").Return(false, nil)
+
+				// the installment is past its due date, so it is overdue again
+				lockAndRefresh(mockLoanRepo, mockLoanPaymentRepo, installments(), func(l *model.Loan) bool {
+					return l.OutstandingTotal.Equal(idr(355_000)) && l.PaidTotal.Equal(idr(110_000)) && l.InstallmentsOverdue == 2
+				})
+				mockLoanPaymentRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(lp *model.LoanPayment) bool {
+					return lp.ID == "lp-id-2" &&
+						lp.Status == constant.LoanPaymentStatusOverdue &&
+						lp.PaidAt == nil &&
+						lp.SettledByEntryID == nil
+				}), constant.LoanPaymentStatus(constant.LoanPaymentStatusPaid)).Return(true, nil).Once()
+
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeReversal &&
+						*e.ReversalOfID == "entry-id-1" &&
+						e.LoanID == "loan-id-1" &&
+						e.Validate() == nil &&
+						e.Lines[0].Credit.Equal(decimal.NewFromInt(110_000)) &&
+						e.Lines[1].Debit.Equal(decimal.NewFromInt(100_000))
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:    "Success - Fee Reversal Removes The Late Fee",
+			entryID: "entry-id-6",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeFeeAccrual)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-6").Return(false, nil)
+
+				lps := installments()
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LateFeeEntryID: entryID("entry-id-6")}).Return([]*model.LoanPayment{lps[2]}, nil)
+				mockLoanPaymentRepo.On("RemoveLateFee", mock.Anything, "lp-id-3", "entry-id-6").Run(func(args mock.Arguments) {
+					lps[2].LateFee = idr(0)
+					lps[2].LateFeeEntryID = nil
+				}).Return(true, nil)
+				// the late fee no longer counts towards the outstanding total
+				lockAndRefresh(mockLoanRepo, mockLoanPaymentRepo, lps, func(l *model.Loan) bool {
+					return l.OutstandingTotal.Equal(idr(220_000)) && l.PaidTotal.Equal(idr(220_000))
+				})
+
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return *e.ReversalOfID == "entry-id-6"
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:    "Success - Write-Off Reversal Moves Loan Version",
+			entryID: "entry-id-5",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeWriteOff)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-5").Return(false, nil)
+				// the installments are left alone and the balances refresh moves the loan to its next version
+				lockAndRefresh(mockLoanRepo, mockLoanPaymentRepo, installments(), func(l *model.Loan) bool {
+					return l.Version == 1
+				})
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.Anything).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:    "Success - Interest Accrual Reversal Removes The Accrual",
+			entryID: "entry-id-8",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeInterestAccrual)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-8").Return(false, nil)
+				lockAndRefresh(mockLoanRepo, mockLoanPaymentRepo, installments(), func(l *model.Loan) bool {
+					return true
+				})
+				// the day can be accrued again once its accrual is gone
+				mockAccrualRepo.On("WithTx", mock.Anything).Return(mockAccrualRepo)
+				mockAccrualRepo.On("DeleteByJournalEntryID", mock.Anything, "entry-id-8").Return(true, nil)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return *e.ReversalOfID == "entry-id-8"
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:    "Error - Interest Accrual Without Accrual Row",
+			entryID: "entry-id-9",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeInterestAccrual)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-9").Return(false, nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockAccrualRepo.On("WithTx", mock.Anything).Return(mockAccrualRepo)
+				mockAccrualRepo.On("DeleteByJournalEntryID", mock.Anything, "entry-id-9").Return(false, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:    "Error - Reversed While Waiting For The Loan Lock",
+			entryID: "entry-id-1",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeRepayment)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-1").Return(false, nil).Once()
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				// a concurrent reversal committed once the loan lock was released
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-1").Return(true, nil).Once()
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+		{
+			name:    "Success - Disbursement Reversal Cancels The Installments",
+			entryID: "entry-id-10",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeDisbursement)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-10").Return(false, nil)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("HasUnreversedEntries", mock.Anything, "loan-id-1", "entry-id-10").Return(false, nil)
+
+				// every repayment, waiver and late fee was reversed before, so the installments are all outstanding
+				lps := installments()
+				for _, lp := range lps {
+					lp.Status, lp.PaidAt, lp.SettledByEntryID = constant.LoanPaymentStatusUnpaid, nil, nil
+					lp.LateFee, lp.LateFeeEntryID = idr(0), nil
+				}
+				mockLoanPaymentRepo.On("Cancel", mock.Anything, "loan-id-1").Run(func(args mock.Arguments) {
+					for _, lp := range lps {
+						lp.Status = constant.LoanPaymentStatusCancelled
+					}
+				}).Return(int64(len(lps)), nil)
+				// cancelled installments are neither outstanding nor paid
+				lockAndRefresh(mockLoanRepo, mockLoanPaymentRepo, lps, func(l *model.Loan) bool {
+					return l.OutstandingTotal.IsZero() &&
+						l.OutstandingPrincipal.IsZero() &&
+						l.PaidTotal.IsZero() &&
+						l.NextDueDate == nil &&
+						l.InstallmentsOverdue == 0
+				})
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return *e.ReversalOfID == "entry-id-10"
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:    "Error - Disbursement Of A Loan With Unreversed Entries",
+			entryID: "entry-id-10",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeDisbursement)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-10").Return(false, nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("HasUnreversedEntries", mock.Anything, "loan-id-1", "entry-id-10").Return(true, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:    "Error - Later Installment Settled",
+			entryID: "entry-id-0",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeRepayment)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-0").Return(false, nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				// lp-id-2 was paid by a later repayment, which has to be reversed first
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return(installments(), nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:    "Error - Late Fee Already Paid",
+			entryID: "entry-id-7",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeFeeAccrual)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-7").Return(false, nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				paid := installments()[0]
+				paid.LateFee = idr(25_000)
+				paid.LateFeeEntryID = entryID("entry-id-7")
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LateFeeEntryID: entryID("entry-id-7")}).Return([]*model.LoanPayment{paid}, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:    "Entry Not Found",
+			entryID: "entry-id-2",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:    "Already Reversed",
+			entryID: "entry-id-3",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeRepayment)).Return(nil)
+				mockLedgerRepo.On("IsReversed", mock.Anything, "entry-id-3").Return(true, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+		{
+			name:    "Reversal Cannot Be Reversed",
+			entryID: "entry-id-4",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo, mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockAccrualRepo *MockInterestAccrualRepo) {
+				mockLedgerRepo.On("GetEntry", mock.Anything, mock.Anything).Run(setRepaymentEntry(constant.JournalEntryTypeReversal)).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLedgerRepo := new(MockLedgerRepo)
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			mockAccrualRepo := new(MockInterestAccrualRepo)
+			tt.mockSetup(mockLedgerRepo, mockLoanRepo, mockLoanPaymentRepo, mockAccrualRepo)
+
+			service := NewLedgerService(mockLedgerRepo, mockLoanRepo, mockLoanPaymentRepo, mockAccrualRepo, new(MockTxManager), newTestClock())
+			entry, err := service.ReverseEntry(context.Background(), tt.entryID)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, entry)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, entry)
+			}
+
+			mockLedgerRepo.AssertExpectations(t)
+			mockLoanRepo.AssertExpectations(t)
+			mockLoanPaymentRepo.AssertExpectations(t)
+			mockAccrualRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLedgerService_GetTrialBalance(t *testing.T) {
+	asOf := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		asOf               *time.Time
+		mockSetup          func(mockLedgerRepo *MockLedgerRepo)
+		expectedError      bool
+		expectedBalanced   bool
+		expectedCurrencies []string
+		expectedBalances   map[string]map[string]string
+	}{
+		{
+			name: "Balanced",
+			asOf: &asOf,
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo) {
+				mockLedgerRepo.On("GetTrialBalance", mock.Anything, mock.MatchedBy(func(before *time.Time) bool {
+					return before.Equal(asOf.AddDate(0, 0, 1))
+				})).Return([]*model.TrialBalanceLine{
+					{Currency: "IDR", AccountCode: constant.LedgerAccountCash, AccountType: constant.LedgerAccountTypeAsset, Debit: decimal.NewFromInt(110_000), Credit: decimal.NewFromInt(5_000_000)},
+					{Currency: "IDR", AccountCode: constant.LedgerAccountInterestIncome, AccountType: constant.LedgerAccountTypeIncome, Debit: decimal.Zero, Credit: decimal.NewFromInt(10_000)},
+					{Currency: "IDR", AccountCode: constant.LedgerAccountLoanReceivable, AccountType: constant.LedgerAccountTypeAsset, Debit: decimal.NewFromInt(5_000_000), Credit: decimal.NewFromInt(100_000)},
+					{Currency: "USD", AccountCode: constant.LedgerAccountCash, AccountType: constant.LedgerAccountTypeAsset, Debit: decimal.Zero, Credit: decimal.NewFromInt(1_000)},
+					{Currency: "USD", AccountCode: constant.LedgerAccountInterestIncome, AccountType: constant.LedgerAccountTypeIncome, Debit: decimal.Zero, Credit: decimal.Zero},
+					{Currency: "USD", AccountCode: constant.LedgerAccountLoanReceivable, AccountType: constant.LedgerAccountTypeAsset, Debit: decimal.NewFromInt(1_000), Credit: decimal.Zero},
+				}, nil)
+				mockLedgerRepo.On("FindUnbalancedEntryIDs", mock.Anything).Return([]string{}, nil)
+			},
+			expectedError:      false,
+			expectedBalanced:   true,
+			expectedCurrencies: []string{"IDR", "USD"},
+			expectedBalances: map[string]map[string]string{
+				// debit-normal accounts are debit minus credit, income is credit minus debit
+				"IDR": {
+					constant.LedgerAccountCash:           "-4890000",
+					constant.LedgerAccountInterestIncome: "10000",
+					constant.LedgerAccountLoanReceivable: "4900000",
+				},
+				// amounts in another currency are never added to those in IDR
+				"USD": {
+					constant.LedgerAccountCash:           "-1000",
+					constant.LedgerAccountInterestIncome: "0",
+					constant.LedgerAccountLoanReceivable: "1000",
+				},
+			},
+		},
+		{
+			name: "Unbalanced Entry Found",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo) {
+				mockLedgerRepo.On("GetTrialBalance", mock.Anything, (*time.Time)(nil)).Return([]*model.TrialBalanceLine{}, nil)
+				mockLedgerRepo.On("FindUnbalancedEntryIDs", mock.Anything).Return([]string{"entry-id-1"}, nil)
+			},
+			expectedError:      false,
+			expectedBalanced:   false,
+			expectedCurrencies: []string{},
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockLedgerRepo *MockLedgerRepo) {
+				mockLedgerRepo.On("GetTrialBalance", mock.Anything, mock.Anything).Return([]*model.TrialBalanceLine{}, errors.New("database error"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLedgerRepo := new(MockLedgerRepo)
+			tt.mockSetup(mockLedgerRepo)
+
+			service := NewLedgerService(mockLedgerRepo, new(MockLoanRepo), new(MockLoanPaymentRepo), new(MockInterestAccrualRepo), new(MockTxManager), newTestClock())
+			tb, err := service.GetTrialBalance(context.Background(), tt.asOf)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, tb)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedBalanced, tb.IsBalanced)
+				currencies := make([]string, 0)
+				for _, ctb := range tb.Currencies {
+					currencies = append(currencies, ctb.Currency)
+					assert.True(t, ctb.IsBalanced, ctb.Currency)
+					assert.True(t, ctb.TotalDebit.Equal(ctb.TotalCredit), ctb.Currency)
+					for _, l := range ctb.Accounts {
+						assert.Equal(t, tt.expectedBalances[ctb.Currency][l.AccountCode], l.Balance.String(), l.AccountCode)
+					}
+				}
+				assert.Equal(t, tt.expectedCurrencies, currencies)
+			}
+
+			mockLedgerRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRepaymentPrincipal(t *testing.T) {
+	l := model.Loan{
+		Principal:          idr(5_000_000),
+		AnnualInterestRate: decimal.NewFromInt(10),
+		InterestMethod:     constant.InterestMethodFlat,
+		Period:             3,
+		PeriodUnit:         constant.PeriodUnitMonth,
+	}
+
+	// 5.000.000 / 3 rounds to 1.666.667 per installment
+	assert.Equal(t, "1666667", repaymentPrincipal(l, 0, 1).Amount.String())
+	assert.Equal(t, "3333334", repaymentPrincipal(l, 0, 2).Amount.String())
+
+	// the final installment takes the rounding difference so the whole principal is settled
+	assert.Equal(t, "1666666", repaymentPrincipal(l, 2, 1).Amount.String())
+	assert.True(t, repaymentPrincipal(l, 0, 1).Add(repaymentPrincipal(l, 1, 2)).Equal(l.Principal))
+}
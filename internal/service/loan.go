@@ -1,11 +1,12 @@
 package service
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/ramabmtr/billing-engine/config"
 	"github.com/ramabmtr/billing-engine/internal/constant"
 	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
@@ -14,52 +15,113 @@ import (
 	"gorm.io/gorm"
 )
 
+// LoanConfig holds the terms of the loan product offered to borrowers. Currency is the currency loans are made in,
+// the default currency when empty. DefaultTimezone sets the due dates of borrowers who have no timezone of their own.
+type LoanConfig struct {
+	Currency        string
+	OriginationFee  model.OriginationFeePolicy
+	Calendar        CalendarConfig
+	DefaultTimezone string
+}
+
 type LoanService struct {
 	loanRepo        repository.LoanRepo
 	loanPaymentRepo repository.LoanPaymentRepo
+	borrowerRepo    repository.BorrowerRepo
+	ledgerRepo      repository.LedgerRepo
+	holidayRepo     repository.HolidayRepo
+	txManager       repository.TxManager
 	lockManager     lib.LockManager
+	clock           lib.Clock
+	cfg             LoanConfig
 }
 
-func NewLoanService(loanRepo repository.LoanRepo, loanPaymentRepo repository.LoanPaymentRepo) *LoanService {
+func NewLoanService(
+	loanRepo repository.LoanRepo,
+	loanPaymentRepo repository.LoanPaymentRepo,
+	borrowerRepo repository.BorrowerRepo,
+	ledgerRepo repository.LedgerRepo,
+	holidayRepo repository.HolidayRepo,
+	txManager repository.TxManager,
+	clock lib.Clock,
+	cfg LoanConfig,
+) *LoanService {
 	return &LoanService{
 		loanRepo:        loanRepo,
 		loanPaymentRepo: loanPaymentRepo,
+		borrowerRepo:    borrowerRepo,
+		ledgerRepo:      ledgerRepo,
+		holidayRepo:     holidayRepo,
+		txManager:       txManager,
 		lockManager:     lib.NewLockManager(),
+		clock:           clock,
+		cfg:             cfg,
 	}
 }
 
-func (s *LoanService) CreateLoanRequest(borrowerID string) (*model.Loan, error) {
+func (s *LoanService) CreateLoanRequest(ctx context.Context, borrowerID string) (*model.Loan, error) {
+	b := &model.Borrower{
+		ID: borrowerID,
+	}
+	err := s.borrowerRepo.Get(ctx, b)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, lib.NewNotFoundError(constant.ErrCodeBorrowerNotFound, "borrower not found").Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsActive() {
+		return nil, lib.NewBusinessRuleError(constant.ErrCodeBorrowerNotActive, "borrower is %s and cannot take new loans", strings.ToLower(string(b.Status)))
+	}
+
 	// check if there is an outstanding amount for that borrower id
-	outstandingAmount, err := s.loanPaymentRepo.GetTotalOutstandingByBorrowerID(borrowerID)
+	stats, err := s.loanRepo.GetStatsByBorrowerID(ctx, borrowerID)
 	if err != nil {
 		return nil, err
 	}
-	if !outstandingAmount.IsZero() {
-		return nil, fmt.Errorf("there is an outstanding loan for this borrower")
+	if stats.ActiveLoanCount > 0 {
+		return nil, lib.NewConflictError(constant.ErrCodeOutstandingLoanExists, "there is an outstanding loan for this borrower")
 	}
 
 	l := &model.Loan{
 		ID:                 uuid.Must(uuid.NewV7()).String(),
 		BorrowerID:         borrowerID,
-		Principal:          decimal.NewFromInt(5_000_000),
+		Currency:           s.currency(""),
+		Principal:          lib.NewMoneyFromInt(5_000_000, s.currency("")),
 		AnnualInterestRate: decimal.NewFromInt(10),
+		InterestMethod:     constant.InterestMethodFlat,
 		Period:             50,
 		PeriodUnit:         constant.PeriodUnitWeek,
-		CreatedAt:          time.Now().UTC(),
+		Timezone:           s.timezone(b.Timezone),
+		CreatedAt:          s.clock.Now(),
+	}
+	err = s.cfg.OriginationFee.Apply(l)
+	if err != nil {
+		return nil, err
+	}
+
+	l.TotalRepayment = lib.SumInstallments(l.Installments())
+	l.APR, l.EffectiveRate = l.CostOfCredit()
+	dueDates, err := s.dueDates(ctx, *l)
+	if err != nil {
+		return nil, err
 	}
 
-	l.TotalRepayment = lib.CalculateTotalRepayment(l.Principal, l.AnnualInterestRate, l.Period, l.PeriodUnit).Round(0)
+	lps := s.generateLoanPayment(*l, dueDates)
+	if err := l.SetBalances(lps); err != nil {
+		return nil, err
+	}
 
-	err = config.GetDB().Transaction(func(tx *gorm.DB) error {
-		err := s.loanRepo.WithTx(tx).Create(l)
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		err := s.loanRepo.WithTx(tx).Create(ctx, l)
 		if err != nil {
 			return err
 		}
-		err = s.loanPaymentRepo.WithTx(tx).CreateBulk(s.generateLoanPayment(*l))
+		err = s.loanPaymentRepo.WithTx(tx).CreateBulk(ctx, lps)
 		if err != nil {
 			return err
 		}
-		return nil
+		return s.ledgerRepo.WithTx(tx).CreateEntry(ctx, newDisbursementEntry(*l))
 	})
 	if err != nil {
 		return nil, err
@@ -68,80 +130,337 @@ func (s *LoanService) CreateLoanRequest(borrowerID string) (*model.Loan, error)
 	return l, nil
 }
 
-func (s *LoanService) generateLoanPayment(l model.Loan) []*model.LoanPayment {
+func (s *LoanService) generateLoanPayment(l model.Loan, dueDates []time.Time) []*model.LoanPayment {
+	installments := l.Installments()
+	loc := l.Location()
 	var lps = make([]*model.LoanPayment, l.Period)
 	for i := 0; i < l.Period; i++ {
 		lps[i] = &model.LoanPayment{
-			LoanID:     l.ID,
-			BorrowerID: l.BorrowerID,
-			Amount:     l.TotalRepayment.Div(decimal.NewFromInt(int64(l.Period))),
-			DueDate:    l.CreatedAt.AddDate(0, 0, 7*(i+1)),
-			Status:     constant.LoanPaymentStatusUnpaid,
+			LoanID:       l.ID,
+			BorrowerID:   l.BorrowerID,
+			Currency:     l.Currency,
+			Amount:       installments[i].Amount(),
+			Principal:    &installments[i].Principal,
+			LocalDueDate: dueDates[i],
+			DueDate:      lib.EndOfLocalDay(dueDates[i], loc),
+			Status:       constant.LoanPaymentStatusUnpaid,
 		}
 	}
 
 	return lps
 }
 
-func (s *LoanService) GetLoansByBorrowerID(borrowerID string) ([]*model.LoanWithCompleteStatus, error) {
-	ls, err := s.loanRepo.FindByBorrowerID(borrowerID)
+// checkLoanVersion fails with a conflict when the client expects another version of the loan than the one read.
+// A zero version means the client did not ask for one.
+func checkLoanVersion(l *model.Loan, version int64) error {
+	if version != 0 && version != l.Version {
+		return lib.NewConflictError(constant.ErrCodeLoanVersionMismatch, "loan is at version %d, not %d", l.Version, version)
+	}
+	return nil
+}
+
+// lockLoan locks the loan until the transaction ends, so changes to a loan are made one at a time across processes,
+// and makes sure it is still at the version the change was worked out from. A zero version accepts any version.
+func lockLoan(ctx context.Context, loanRepo repository.LoanRepo, loanID string, version int64) (*model.Loan, error) {
+	l := &model.Loan{
+		ID: loanID,
+	}
+	err := loanRepo.GetForUpdate(ctx, l)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkLoanVersion(l, version); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
 
-	return ls, nil
+// refreshLoanBalances recomputes the running totals of a locked loan from its installments and stores them, moving the
+// loan to its next version. It runs in the transaction that changed the installments, with repositories bound to it.
+func refreshLoanBalances(ctx context.Context, loanRepo repository.LoanRepo, loanPaymentRepo repository.LoanPaymentRepo, l *model.Loan) error {
+	lps, err := loanPaymentRepo.Find(ctx, model.LoanPayment{LoanID: l.ID})
+	if err != nil {
+		return err
+	}
+
+	if err := l.SetBalances(lps); err != nil {
+		return err
+	}
+	updated, err := loanRepo.UpdateBalances(ctx, l)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return lib.NewConflictError(constant.ErrCodeLoanVersionMismatch, "loan changed while it was being updated")
+	}
+	return nil
 }
 
-func (s *LoanService) GetLoanDetail(id string) (*model.Loan, decimal.Decimal, error) {
+// bumpLoanVersion moves a locked loan to its next version for a change that does not touch its installments
+func bumpLoanVersion(ctx context.Context, loanRepo repository.LoanRepo, l *model.Loan) error {
+	bumped, err := loanRepo.BumpVersion(ctx, l)
+	if err != nil {
+		return err
+	}
+	if !bumped {
+		return lib.NewConflictError(constant.ErrCodeLoanVersionMismatch, "loan changed while it was being updated")
+	}
+	return nil
+}
+
+// currency picks the currency of a loan: the one requested, else the product currency, else the default currency
+func (s *LoanService) currency(requested string) string {
+	for _, code := range []string{requested, s.cfg.Currency} {
+		if code != "" {
+			return code
+		}
+	}
+	return lib.DefaultCurrency
+}
+
+// timezone picks the timezone a loan's due dates are set in: the borrower's own, else the product default, else UTC
+func (s *LoanService) timezone(borrowerTimezone string) string {
+	for _, tz := range []string{borrowerTimezone, s.cfg.DefaultTimezone} {
+		if _, err := lib.LoadLocation(tz); tz != "" && err == nil {
+			return tz
+		}
+	}
+	return time.UTC.String()
+}
+
+// dueDates returns the local due dates of the loan, moved off weekends and holidays when a business day convention is set
+func (s *LoanService) dueDates(ctx context.Context, l model.Loan) ([]time.Time, error) {
+	dueDates := l.DueDates()
+	if !s.cfg.Calendar.enabled() || len(dueDates) == 0 {
+		return dueDates, nil
+	}
+
+	// a month either side leaves room for the dates to roll over the ends of the schedule
+	calendar, err := loadBusinessCalendar(ctx, s.holidayRepo, s.cfg.Calendar.CountryCode,
+		dueDates[0].AddDate(0, -1, 0), dueDates[len(dueDates)-1].AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	for i, d := range dueDates {
+		dueDates[i] = calendar.Adjust(d, s.cfg.Calendar.Adjustment)
+	}
+	return dueDates, nil
+}
+
+// SimulateLoan builds the repayment schedule of a loan of the requested amount with the given terms as if it were
+// disbursed now, charging the product's origination fee, without storing anything. The loan is made in the
+// requested currency and its due dates are set in the requested timezone, or the product defaults when not given.
+func (s *LoanService) SimulateLoan(ctx context.Context, l model.Loan) (*model.LoanSimulation, error) {
+	if l.InterestMethod == "" {
+		l.InterestMethod = constant.InterestMethodFlat
+	}
+	l.Currency = s.currency(l.Currency)
+	if _, err := lib.LookupCurrency(l.Currency); err != nil {
+		return nil, err
+	}
+	l.Timezone = s.timezone(l.Timezone)
+	l.CreatedAt = s.clock.Now()
+	err := s.cfg.OriginationFee.Apply(&l)
+	if err != nil {
+		return nil, err
+	}
+
+	dueDates, err := s.dueDates(ctx, l)
+	if err != nil {
+		return nil, err
+	}
+
+	installments := l.Installments()
+	loc := l.Location()
+	simulated := make([]*model.SimulatedInstallment, len(installments))
+	for i, installment := range installments {
+		simulated[i] = &model.SimulatedInstallment{
+			Number:       i + 1,
+			LocalDueDate: dueDates[i],
+			DueDate:      lib.EndOfLocalDay(dueDates[i], loc),
+			Principal:    installment.Principal,
+			Interest:     installment.Interest,
+			Amount:       installment.Amount(),
+		}
+	}
+	totalRepayment := lib.SumInstallments(installments)
+	apr, eir := l.CostOfCredit()
+
+	return &model.LoanSimulation{
+		Currency:           l.Currency,
+		Principal:          l.Principal,
+		OriginationFee:     l.OriginationFee,
+		OriginationFeeMode: l.OriginationFeeMode,
+		NetDisbursement:    l.NetDisbursement,
+		AnnualInterestRate: l.AnnualInterestRate,
+		InterestMethod:     l.InterestMethod,
+		Period:             l.Period,
+		PeriodUnit:         l.PeriodUnit,
+		Timezone:           l.Timezone,
+		TotalRepayment:     totalRepayment,
+		TotalInterest:      totalRepayment.Sub(l.Principal),
+		APR:                apr,
+		EffectiveRate:      eir,
+		Installments:       simulated,
+	}, nil
+}
+
+// LoanListFilter is the loan list query as received from the client, with an opaque cursor
+type LoanListFilter struct {
+	BorrowerID  string
+	Status      constant.LoanStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Desc        bool
+	Cursor      string
+	Limit       int
+}
+
+// SearchLoans lists loans across borrowers, or for one borrower when BorrowerID is set
+func (s *LoanService) SearchLoans(ctx context.Context, f LoanListFilter) ([]*model.LoanWithCompleteStatus, string, error) {
+	after, err := lib.DecodeCursor(f.Cursor)
+	if err != nil {
+		return nil, "", lib.NewValidationError(constant.ErrCodeInvalidCursor, "invalid cursor").Wrap(err)
+	}
+
+	ls, next, err := s.loanRepo.List(ctx, repository.LoanFilter{
+		BorrowerID:  f.BorrowerID,
+		Status:      f.Status,
+		CreatedFrom: f.CreatedFrom,
+		CreatedTo:   f.CreatedTo,
+		Desc:        f.Desc,
+		After:       after,
+		Limit:       lib.NormalizePageLimit(f.Limit),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ls, lib.EncodeCursor(next), nil
+}
+
+func (s *LoanService) GetLoansByBorrowerID(ctx context.Context, borrowerID string, f LoanListFilter) ([]*model.LoanWithCompleteStatus, string, error) {
+	f.BorrowerID = borrowerID
+	return s.SearchLoans(ctx, f)
+}
+
+// getBorrowerLoan fetches the loan and makes sure it belongs to the given borrower.
+// A loan owned by another borrower is reported as not found so its existence is not leaked.
+func (s *LoanService) getBorrowerLoan(ctx context.Context, borrowerID, loanID string) (*model.Loan, error) {
 	l := &model.Loan{
-		ID: id,
+		ID: loanID,
+	}
+	err := s.loanRepo.Get(ctx, l)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, lib.NewNotFoundError(constant.ErrCodeLoanNotFound, "loan not found").Wrap(err)
 	}
-	err := s.loanRepo.Get(l)
 	if err != nil {
-		return nil, decimal.NewFromInt(0), err
+		return nil, err
+	}
+	if l.BorrowerID != borrowerID {
+		return nil, lib.NewNotFoundError(constant.ErrCodeLoanNotFound, "loan not found")
 	}
 
-	o, err := s.loanPaymentRepo.GetTotalOutstandingByLoanID(id)
+	return l, nil
+}
+
+func (s *LoanService) GetLoanDetail(ctx context.Context, borrowerID, id string) (*model.Loan, decimal.Decimal, error) {
+	l, err := s.getBorrowerLoan(ctx, borrowerID, id)
 	if err != nil {
 		return nil, decimal.NewFromInt(0), err
 	}
 
-	return l, o, nil
+	return l, l.OutstandingTotal.Amount, nil
+}
+
+// LoanPaymentListFilter is the installment list query as received from the client, with an opaque cursor
+type LoanPaymentListFilter struct {
+	Status      constant.LoanPaymentStatus
+	DueFrom     *time.Time
+	DueTo       *time.Time
+	OverdueOnly bool
+	Cursor      string
+	Limit       int
 }
 
-func (s *LoanService) GetLoanPaymentsByLoanID(loanID string) ([]*model.LoanPayment, error) {
-	lps, err := s.loanPaymentRepo.Find(model.LoanPayment{
-		LoanID: loanID,
+func (s *LoanService) GetLoanPaymentsByLoanID(ctx context.Context, borrowerID, loanID string, f LoanPaymentListFilter) ([]*model.LoanPayment, string, error) {
+	_, err := s.getBorrowerLoan(ctx, borrowerID, loanID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	after, err := lib.DecodeCursor(f.Cursor)
+	if err != nil {
+		return nil, "", lib.NewValidationError(constant.ErrCodeInvalidCursor, "invalid cursor").Wrap(err)
+	}
+
+	lps, next, err := s.loanPaymentRepo.List(ctx, repository.LoanPaymentFilter{
+		LoanID:      loanID,
+		Status:      f.Status,
+		DueFrom:     f.DueFrom,
+		DueTo:       f.DueTo,
+		OverdueOnly: f.OverdueOnly,
+		After:       after,
+		Limit:       lib.NormalizePageLimit(f.Limit),
 	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return lps, nil
+	return lps, lib.EncodeCursor(next), nil
 }
 
-func (s *LoanService) MakePayment(loanID string, amount decimal.Decimal) error {
+// MakePayment pays off the oldest outstanding installments of a loan with the amount and returns the loan with its new
+// balances and version. An amount without a currency is taken to be in the currency of the loan. A non-zero version
+// makes the payment conditional on the loan still being at that version.
+func (s *LoanService) MakePayment(ctx context.Context, borrowerID, loanID string, amount lib.Money, version int64) (*model.Loan, error) {
+	l, err := s.getBorrowerLoan(ctx, borrowerID, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLoanVersion(l, version); err != nil {
+		return nil, err
+	}
+	loanCurrency := l.CurrencyUnit().Code
+	if amount.Currency == "" {
+		amount = amount.In(loanCurrency)
+	}
+	if amount.Currency != loanCurrency {
+		return nil, lib.NewBusinessRuleError(constant.ErrCodeCurrencyMismatch, "payment is in %s but the loan is in %s", amount.Currency, loanCurrency)
+	}
+
 	lock := s.lockManager.GetLock(loanID)
 	lock.Lock()
 	defer lock.Unlock()
 
-	lps, err := s.loanPaymentRepo.Find(model.LoanPayment{
-		LoanID: loanID,
-		Status: constant.LoanPaymentStatusUnpaid,
-	})
+	writtenOff, err := s.ledgerRepo.IsLoanWrittenOff(ctx, loanID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if writtenOff {
+		return nil, lib.NewBusinessRuleError(constant.ErrCodeLoanWrittenOff, "loan has been written off")
+	}
+
+	lps, err := s.loanPaymentRepo.FindOutstanding(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lps) == 0 {
+		return nil, lib.NewBusinessRuleError(constant.ErrCodeLoanAlreadyPaid, "there is no outstanding payment for this loan")
+	}
+	if err := l.CheckInstallmentCurrency(lps); err != nil {
+		return nil, err
 	}
 
-	now := time.Now().UTC()
-	minimumPayment := decimal.NewFromInt(0)
-	paymentPlan := make([]decimal.Decimal, 0)
-	tempAmount := decimal.NewFromInt(0)
+	now := s.clock.Now()
+	minimumPayment := lib.ZeroMoney(amount.Currency)
+	paymentPlan := make([]lib.Money, 0)
+	tempAmount := lib.ZeroMoney(amount.Currency)
 	for _, lp := range lps {
-		tempAmount = tempAmount.Add(lp.Amount)
+		tempAmount = tempAmount.Add(lp.AmountDue())
 		paymentPlan = append(paymentPlan, tempAmount)
-		if lp.DueDate.Before(now) {
-			minimumPayment = minimumPayment.Add(lp.Amount)
+		if lp.Status == constant.LoanPaymentStatusOverdue {
+			minimumPayment = minimumPayment.Add(lp.AmountDue())
 		}
 	}
 
@@ -155,22 +474,203 @@ func (s *LoanService) MakePayment(loanID string, amount decimal.Decimal) error {
 		}
 	}
 
+	// installments are rounded to the minor units of the currency, so a finer amount can never settle them. Loans
+	// scheduled before that may still be paid the exact amounts they were stored with.
+	if !isInPlan && !amount.Round().Equal(amount) {
+		return nil, lib.NewValidationError(constant.ErrCodeInvalidRequest, "amount %s is finer than the minor units of %s", amount, amount.Currency)
+	}
+
 	if amount.LessThan(minimumPayment) {
-		return fmt.Errorf("you must make payment equal to %s at minimum", minimumPayment)
+		return nil, lib.NewBusinessRuleError(constant.ErrCodePaymentBelowMinimum, "you must make payment equal to %s at minimum", minimumPayment)
 	}
 
 	if !isInPlan {
-		return fmt.Errorf("you must make payment equal to %s at minimum or multiples thereof and maximum %s", paymentPlan[0], paymentPlan[len(paymentPlan)-1])
+		return nil, lib.NewBusinessRuleError(constant.ErrCodePaymentNotInPlan, "you must make payment equal to %s at minimum or multiples thereof and maximum %s", paymentPlan[0], paymentPlan[len(paymentPlan)-1])
 	}
 
 	idToUpdate := make([]string, planIndex+1)
+	lateFees := lib.ZeroMoney(amount.Currency)
 	for i := 0; i <= planIndex; i++ {
 		idToUpdate[i] = lps[i].ID
+		lateFees = lateFees.Add(lps[i].LateFee)
 	}
-	err = s.loanPaymentRepo.ChangeStatusToPaid(idToUpdate, now)
+	// outstanding installments are always the last ones of the schedule. Late fees were booked onto the
+	// loan receivable, so they settle it together with the principal.
+	principal := repaymentPrincipal(*l, l.Period-len(lps), len(idToUpdate)).Add(lateFees)
+	e := newRepaymentEntry(loanID, amount, principal, now)
+	e.ID = uuid.Must(uuid.NewV7()).String()
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		// the plan was worked out from the loan as read, so it must not have changed since
+		locked, err := lockLoan(ctx, s.loanRepo.WithTx(tx), loanID, l.Version)
+		if err != nil {
+			return err
+		}
+		err = s.loanPaymentRepo.WithTx(tx).ChangeStatusToPaid(ctx, idToUpdate, now, e.ID)
+		if err != nil {
+			return err
+		}
+		err = refreshLoanBalances(ctx, s.loanRepo.WithTx(tx), s.loanPaymentRepo.WithTx(tx), locked)
+		if err != nil {
+			return err
+		}
+		l = locked
+		return s.ledgerRepo.WithTx(tx).CreateEntry(ctx, e)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return l, nil
+}
+
+// WriteOffLoan charges whatever is left of the loan and accrued interest receivables to loan loss expense. Further payments on the loan are refused
+// until the write-off entry is reversed. The loan moves to its next version, and a non-zero version makes the
+// write-off conditional on the loan still being at that version.
+func (s *LoanService) WriteOffLoan(ctx context.Context, loanID string, version int64) (*model.JournalEntry, *model.Loan, error) {
+	l := &model.Loan{
+		ID: loanID,
+	}
+	err := s.loanRepo.Get(ctx, l)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, lib.NewNotFoundError(constant.ErrCodeLoanNotFound, "loan not found").Wrap(err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkLoanVersion(l, version); err != nil {
+		return nil, nil, err
+	}
+
+	lock := s.lockManager.GetLock(loanID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	writtenOff, err := s.ledgerRepo.IsLoanWrittenOff(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if writtenOff {
+		return nil, nil, lib.NewConflictError(constant.ErrCodeLoanWrittenOff, "loan has already been written off")
+	}
+
+	principal, err := s.ledgerRepo.GetLoanAccountBalance(ctx, loanID, constant.LedgerAccountLoanReceivable)
+	if err != nil {
+		return nil, nil, err
+	}
+	interest, err := s.ledgerRepo.GetLoanAccountBalance(ctx, loanID, constant.LedgerAccountInterestReceivable)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !principal.IsPositive() && !interest.IsPositive() {
+		return nil, nil, lib.NewBusinessRuleError(constant.ErrCodeNothingToWriteOff, "loan has no receivable left to write off")
+	}
+
+	currency := l.CurrencyUnit().Code
+	e := newWriteOffEntry(loanID, lib.NewMoney(principal, currency), lib.NewMoney(interest, currency), s.clock.Now())
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		locked, err := lockLoan(ctx, s.loanRepo.WithTx(tx), loanID, l.Version)
+		if err != nil {
+			return err
+		}
+		err = bumpLoanVersion(ctx, s.loanRepo.WithTx(tx), locked)
+		if err != nil {
+			return err
+		}
+		l = locked
+		return s.ledgerRepo.WithTx(tx).CreateEntry(ctx, e)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e, l, nil
+}
+
+// WaiveInstallment forgives the oldest outstanding installment of a loan, late fee included, charging it to loan loss
+// expense. Installments are waived oldest first so the ones still outstanding always close the schedule. The loan is
+// returned with its new balances and version, and a non-zero version makes the waiver conditional on the loan still
+// being at that version.
+func (s *LoanService) WaiveInstallment(ctx context.Context, loanID, loanPaymentID string, version int64) (*model.LoanPayment, *model.Loan, error) {
+	l := &model.Loan{
+		ID: loanID,
+	}
+	err := s.loanRepo.Get(ctx, l)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, lib.NewNotFoundError(constant.ErrCodeLoanNotFound, "loan not found").Wrap(err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkLoanVersion(l, version); err != nil {
+		return nil, nil, err
+	}
+
+	lock := s.lockManager.GetLock(loanID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	lp := &model.LoanPayment{
+		ID: loanPaymentID,
+	}
+	err = s.loanPaymentRepo.Get(ctx, lp)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && lp.LoanID != loanID) {
+		return nil, nil, lib.NewNotFoundError(constant.ErrCodeLoanPaymentNotFound, "loan payment not found").Wrap(err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	writtenOff, err := s.ledgerRepo.IsLoanWrittenOff(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if writtenOff {
+		return nil, nil, lib.NewBusinessRuleError(constant.ErrCodeLoanWrittenOff, "loan has been written off")
+	}
+
+	now := s.clock.Now()
+	from := lp.Status
+	if err := lp.TransitionTo(constant.LoanPaymentStatusWaived, now); err != nil {
+		return nil, nil, lib.NewBusinessRuleError(constant.ErrCodeInvalidStatusTransition, "a %s installment cannot be waived", strings.ToLower(string(from))).Wrap(err)
+	}
+
+	lps, err := s.loanPaymentRepo.FindOutstanding(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(lps) == 0 || lps[0].ID != lp.ID {
+		return nil, nil, lib.NewBusinessRuleError(constant.ErrCodeInstallmentNotOldest, "only the oldest outstanding installment can be waived")
+	}
+	if err := l.CheckInstallmentCurrency(lps); err != nil {
+		return nil, nil, err
+	}
+
+	principal := repaymentPrincipal(*l, l.Period-len(lps), 1)
+	e := newWaiverEntry(loanID, principal.Add(lp.LateFee), lp.Amount.Sub(principal), now)
+	e.ID = uuid.Must(uuid.NewV7()).String()
+	lp.SettledByEntryID = &e.ID
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		locked, err := lockLoan(ctx, s.loanRepo.WithTx(tx), loanID, l.Version)
+		if err != nil {
+			return err
+		}
+		updated, err := s.loanPaymentRepo.WithTx(tx).UpdateStatus(ctx, lp, from)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return lib.NewConflictError(constant.ErrCodeInvalidStatusTransition, "installment changed while it was being waived")
+		}
+		err = refreshLoanBalances(ctx, s.loanRepo.WithTx(tx), s.loanPaymentRepo.WithTx(tx), locked)
+		if err != nil {
+			return err
+		}
+		l = locked
+		return s.ledgerRepo.WithTx(tx).CreateEntry(ctx, e)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lp, l, nil
 }
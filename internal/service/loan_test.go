@@ -1,12 +1,14 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
 	"github.com/ramabmtr/billing-engine/internal/model"
 	"github.com/ramabmtr/billing-engine/internal/repository"
 
@@ -27,28 +29,86 @@ func (m *MockLoanRepo) WithTx(tx *gorm.DB) repository.LoanRepo {
 	return args.Get(0).(repository.LoanRepo)
 }
 
-func (m *MockLoanRepo) Create(l *model.Loan) error {
-	args := m.Called(l)
+func (m *MockLoanRepo) Create(ctx context.Context, l *model.Loan) error {
+	args := m.Called(ctx, l)
 	return args.Error(0)
 }
 
-func (m *MockLoanRepo) Get(l *model.Loan) error {
-	args := m.Called(l)
+func (m *MockLoanRepo) Get(ctx context.Context, l *model.Loan) error {
+	args := m.Called(ctx, l)
 	// Simulate the behavior of Get by setting fields on the loan
 	if args.Error(0) == nil && l != nil {
-		l.Principal = decimal.NewFromInt(5_000_000)
+		if l.Version == 0 {
+			l.Version = 1
+		}
+		l.Principal = idr(5_000_000)
 		l.AnnualInterestRate = decimal.NewFromInt(10)
 		l.Period = 50
 		l.PeriodUnit = constant.PeriodUnitWeek
-		l.TotalRepayment = decimal.NewFromInt(5_500_000)
+		l.TotalRepayment = idr(5_500_000)
 		l.CreatedAt = time.Now().UTC()
 	}
 	return args.Error(0)
 }
 
-func (m *MockLoanRepo) FindByBorrowerID(borrowerID string) ([]*model.LoanWithCompleteStatus, error) {
-	args := m.Called(borrowerID)
-	return args.Get(0).([]*model.LoanWithCompleteStatus), args.Error(1)
+func (m *MockLoanRepo) List(ctx context.Context, f repository.LoanFilter) ([]*model.LoanWithCompleteStatus, *lib.Cursor, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]*model.LoanWithCompleteStatus), args.Get(1).(*lib.Cursor), args.Error(2)
+}
+
+func (m *MockLoanRepo) GetStatsByBorrowerID(ctx context.Context, borrowerID string) (model.LoanStats, error) {
+	args := m.Called(ctx, borrowerID)
+	return args.Get(0).(model.LoanStats), args.Error(1)
+}
+
+func (m *MockLoanRepo) FindAccruing(ctx context.Context, from, to time.Time, afterID string, limit int) ([]*model.Loan, error) {
+	args := m.Called(ctx, from, to, afterID, limit)
+	return args.Get(0).([]*model.Loan), args.Error(1)
+}
+
+func (m *MockLoanRepo) FindUnpaidTimezones(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockLoanRepo) UpdateDaysPastDue(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoanRepo) GetForUpdate(ctx context.Context, l *model.Loan) error {
+	args := m.Called(ctx, l)
+	if args.Error(0) == nil && l != nil {
+		if l.Version == 0 {
+			l.Version = 1
+		}
+		l.Principal = idr(5_000_000)
+		l.AnnualInterestRate = decimal.NewFromInt(10)
+		l.Period = 50
+		l.PeriodUnit = constant.PeriodUnitWeek
+		l.TotalRepayment = idr(5_500_000)
+	}
+	return args.Error(0)
+}
+
+func (m *MockLoanRepo) UpdateBalances(ctx context.Context, l *model.Loan) (bool, error) {
+	args := m.Called(ctx, l)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoanRepo) BumpVersion(ctx context.Context, l *model.Loan) (bool, error) {
+	args := m.Called(ctx, l)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoanRepo) UpdateOverdueBalances(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoanRepo) FindAfter(ctx context.Context, afterID string, limit int) ([]*model.Loan, error) {
+	args := m.Called(ctx, afterID, limit)
+	return args.Get(0).([]*model.Loan), args.Error(1)
 }
 
 // MockLoanPaymentRepo is a mock implementation of repository.LoanPaymentRepo
@@ -61,31 +121,88 @@ func (m *MockLoanPaymentRepo) WithTx(tx *gorm.DB) repository.LoanPaymentRepo {
 	return args.Get(0).(repository.LoanPaymentRepo)
 }
 
-func (m *MockLoanPaymentRepo) CreateBulk(lps []*model.LoanPayment) error {
-	args := m.Called(lps)
+func (m *MockLoanPaymentRepo) CreateBulk(ctx context.Context, lps []*model.LoanPayment) error {
+	args := m.Called(ctx, lps)
 	return args.Error(0)
 }
 
-func (m *MockLoanPaymentRepo) GetTotalOutstandingByLoanID(loanID string) (decimal.Decimal, error) {
-	args := m.Called(loanID)
-	return args.Get(0).(decimal.Decimal), args.Error(1)
+func (m *MockLoanPaymentRepo) Get(ctx context.Context, lp *model.LoanPayment) error {
+	args := m.Called(ctx, lp)
+	return args.Error(0)
 }
 
-func (m *MockLoanPaymentRepo) GetTotalOutstandingByBorrowerID(borrowerID string) (decimal.Decimal, error) {
-	args := m.Called(borrowerID)
-	return args.Get(0).(decimal.Decimal), args.Error(1)
+func (m *MockLoanPaymentRepo) Find(ctx context.Context, lp model.LoanPayment) ([]*model.LoanPayment, error) {
+	args := m.Called(ctx, lp)
+	return args.Get(0).([]*model.LoanPayment), args.Error(1)
 }
 
-func (m *MockLoanPaymentRepo) Find(lp model.LoanPayment) ([]*model.LoanPayment, error) {
-	args := m.Called(lp)
+func (m *MockLoanPaymentRepo) FindOutstanding(ctx context.Context, loanID string) ([]*model.LoanPayment, error) {
+	args := m.Called(ctx, loanID)
 	return args.Get(0).([]*model.LoanPayment), args.Error(1)
 }
 
-func (m *MockLoanPaymentRepo) ChangeStatusToPaid(loanIds []string, paidAt time.Time) error {
-	args := m.Called(loanIds, paidAt)
+func (m *MockLoanPaymentRepo) List(ctx context.Context, f repository.LoanPaymentFilter) ([]*model.LoanPayment, *lib.Cursor, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]*model.LoanPayment), args.Get(1).(*lib.Cursor), args.Error(2)
+}
+
+func (m *MockLoanPaymentRepo) GetStatsByBorrowerID(ctx context.Context, borrowerID string, now time.Time) (model.LoanPaymentStats, error) {
+	args := m.Called(ctx, borrowerID, now)
+	return args.Get(0).(model.LoanPaymentStats), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) ChangeStatusToPaid(ctx context.Context, loanIds []string, paidAt time.Time, entryID string) error {
+	args := m.Called(ctx, loanIds, paidAt, entryID)
 	return args.Error(0)
 }
 
+func (m *MockLoanPaymentRepo) UpdateStatus(ctx context.Context, lp *model.LoanPayment, from constant.LoanPaymentStatus) (bool, error) {
+	args := m.Called(ctx, lp, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) RecordPrincipal(ctx context.Context, lp *model.LoanPayment) (bool, error) {
+	args := m.Called(ctx, lp)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) MarkOverdue(ctx context.Context, timezone string, dueBefore time.Time) (int64, error) {
+	args := m.Called(ctx, timezone, dueBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) FindLateFeeCandidates(ctx context.Context, currency string, dueBefore time.Time, afterID string, limit int) ([]*model.LoanPayment, error) {
+	args := m.Called(ctx, currency, dueBefore, afterID, limit)
+	return args.Get(0).([]*model.LoanPayment), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) ApplyLateFee(ctx context.Context, id string, fee lib.Money, entryID string) (bool, error) {
+	args := m.Called(ctx, id, fee, entryID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) RemoveLateFee(ctx context.Context, id string, entryID string) (bool, error) {
+	args := m.Called(ctx, id, entryID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) Cancel(ctx context.Context, loanID string) (int64, error) {
+	args := m.Called(ctx, loanID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoanPaymentRepo) FindDueBetween(ctx context.Context, from, to time.Time, afterID string, limit int) ([]*model.LoanPayment, error) {
+	args := m.Called(ctx, from, to, afterID, limit)
+	return args.Get(0).([]*model.LoanPayment), args.Error(1)
+}
+
+// MockTxManager is a mock implementation of repository.TxManager that runs the callback without a real transaction
+type MockTxManager struct{}
+
+func (m *MockTxManager) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
 // MockLockManager is a mock implementation of the LockManager interface used in LoanService
 type MockLockManager struct {
 	mock.Mock
@@ -96,94 +213,265 @@ func (m *MockLockManager) GetLock(key string) *sync.Mutex {
 	return args.Get(0).(*sync.Mutex)
 }
 
+// setLoanBorrower simulates Get returning a loan owned by the given borrower
+func setLoanBorrower(borrowerID string) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		args.Get(1).(*model.Loan).BorrowerID = borrowerID
+	}
+}
+
+// newTestClock returns a clock frozen at a fixed instant so time-dependent rules behave the same on every run
+func newTestClock() *lib.FakeClock {
+	return lib.NewFakeClock(time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC))
+}
+
 func TestLoanService_CreateLoanRequest(t *testing.T) {
 	tests := []struct {
-		name          string
-		borrowerID    string
-		mockSetup     func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo)
-		expectedError bool
+		name            string
+		borrowerID      string
+		cfg             LoanConfig
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
 	}{
 		{
 			name:       "Success",
 			borrowerID: "borrower-id-1",
-			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				// Borrower exists
+				mockBorrowerRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-1"
+				})).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+
 				// No outstanding amount
-				mockLoanPaymentRepo.On("GetTotalOutstandingByBorrowerID", "borrower-id-1").
-					Return(decimal.NewFromInt(0), nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1").
+					Return(model.LoanStats{}, nil)
 
 				// Transaction handling
 				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
 				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
 
 				// Create loan
-				mockLoanRepo.On("Create", mock.MatchedBy(func(l *model.Loan) bool {
+				mockLoanRepo.On("Create", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
 					return l.BorrowerID == "borrower-id-1" &&
-						l.Principal.Equal(decimal.NewFromInt(5_000_000)) &&
+						l.Currency == lib.DefaultCurrency &&
+						l.Principal.Equal(idr(5_000_000)) &&
 						l.AnnualInterestRate.Equal(decimal.NewFromInt(10)) &&
 						l.Period == 50 &&
-						l.PeriodUnit == constant.PeriodUnitWeek
+						l.PeriodUnit == constant.PeriodUnitWeek &&
+						l.APR.Equal(decimal.RequireFromString("19.04")) &&
+						l.EffectiveRate.Equal(decimal.RequireFromString("20.93")) &&
+						l.OutstandingPrincipal.Equal(idr(5_000_000)) &&
+						l.OutstandingTotal.Equal(l.TotalRepayment) &&
+						l.PaidTotal.IsZero() &&
+						l.NextDueDate != nil
+				})).Return(nil)
+
+				// Create loan payments, each recording the 100.000 principal it repays
+				mockLoanPaymentRepo.On("CreateBulk", mock.Anything, mock.MatchedBy(func(lps []*model.LoanPayment) bool {
+					return len(lps) == 50 &&
+						lps[0].Principal.Equal(idr(100_000)) &&
+						lps[49].Principal.Equal(idr(100_000))
+				})).Return(nil)
+
+				// Post disbursement
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeDisbursement &&
+						e.Validate() == nil &&
+						e.Lines[0].AccountCode == constant.LedgerAccountLoanReceivable &&
+						e.Lines[0].Debit.Equal(decimal.NewFromInt(5_000_000))
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Success With Deducted Origination Fee",
+			borrowerID: "borrower-id-1",
+			cfg: LoanConfig{
+				OriginationFee: model.OriginationFeePolicy{
+					Type:  constant.OriginationFeeTypePercentage,
+					Value: decimal.NewFromInt(2),
+					Mode:  constant.OriginationFeeModeDeducted,
+				},
+			},
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				mockBorrowerRepo.On("Get", mock.Anything, mock.Anything).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1").
+					Return(model.LoanStats{}, nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+
+				// the fee is taken out of the disbursement and raises the APR
+				mockLoanRepo.On("Create", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.Principal.Equal(idr(5_000_000)) &&
+						l.OriginationFee.Equal(idr(100_000)) &&
+						l.OriginationFeeMode == constant.OriginationFeeModeDeducted &&
+						l.NetDisbursement.Equal(idr(4_900_000)) &&
+						l.APR.GreaterThan(decimal.RequireFromString("19.04"))
 				})).Return(nil)
+				mockLoanPaymentRepo.On("CreateBulk", mock.Anything, mock.Anything).Return(nil)
 
-				// Create loan payments
-				mockLoanPaymentRepo.On("CreateBulk", mock.Anything).Return(nil)
+				// only the net amount leaves as cash, the fee is earned upfront
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeDisbursement &&
+						e.Validate() == nil &&
+						len(e.Lines) == 3 &&
+						e.Lines[0].Debit.Equal(decimal.NewFromInt(5_000_000)) &&
+						e.Lines[1].AccountCode == constant.LedgerAccountCash &&
+						e.Lines[1].Credit.Equal(decimal.NewFromInt(4_900_000)) &&
+						e.Lines[2].AccountCode == constant.LedgerAccountFeeIncome &&
+						e.Lines[2].Credit.Equal(decimal.NewFromInt(100_000))
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Success In Borrower Timezone",
+			borrowerID: "borrower-id-1",
+			cfg:        LoanConfig{DefaultTimezone: "Asia/Jakarta"},
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				mockBorrowerRepo.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					b := args.Get(1).(*model.Borrower)
+					b.Status = constant.BorrowerStatusActive
+					b.Timezone = "America/New_York"
+				}).Return(nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-1").
+					Return(model.LoanStats{}, nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+
+				mockLoanRepo.On("Create", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.Timezone == "America/New_York"
+				})).Return(nil)
+
+				// due on the local Saturday a week later, late once that day has ended in New York
+				mockLoanPaymentRepo.On("CreateBulk", mock.Anything, mock.MatchedBy(func(lps []*model.LoanPayment) bool {
+					return lps[0].LocalDueDate.Equal(time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC)) &&
+						lps[0].DueDate.Equal(time.Date(2025, 3, 23, 4, 0, 0, 0, time.UTC))
+				})).Return(nil)
+
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.Anything).Return(nil)
 			},
 			expectedError: false,
 		},
 		{
 			name:       "Outstanding Amount Exists",
 			borrowerID: "borrower-id-2",
-			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				// Borrower exists
+				mockBorrowerRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-2"
+				})).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+
 				// Outstanding amount exists
-				mockLoanPaymentRepo.On("GetTotalOutstandingByBorrowerID", "borrower-id-2").
-					Return(decimal.NewFromInt(1000), nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-2").
+					Return(model.LoanStats{
+						LoanCount:       1,
+						ActiveLoanCount: 1,
+						Currencies: []*model.LoanCurrencyStats{
+							{Currency: "IDR", LoanCount: 1, ActiveLoanCount: 1, TotalPrincipal: idr(5_000_000), TotalOutstanding: idr(1_000)},
+						},
+					}, nil)
 			},
-			expectedError: true,
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
 		},
 		{
 			name:       "Error Getting Outstanding Amount",
 			borrowerID: "borrower-id-3",
-			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				// Borrower exists
+				mockBorrowerRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-3"
+				})).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+
 				// Error getting outstanding amount
-				mockLoanPaymentRepo.On("GetTotalOutstandingByBorrowerID", "borrower-id-3").
-					Return(decimal.NewFromInt(0), errors.New("database error"))
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-3").
+					Return(model.LoanStats{}, errors.New("database error"))
 			},
 			expectedError: true,
 		},
 		{
 			name:       "Error Creating Loan",
 			borrowerID: "borrower-id-4",
-			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				// Borrower exists
+				mockBorrowerRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-4"
+				})).Run(setBorrowerStatus(constant.BorrowerStatusActive)).Return(nil)
+
 				// No outstanding amount
-				mockLoanPaymentRepo.On("GetTotalOutstandingByBorrowerID", "borrower-id-4").
-					Return(decimal.NewFromInt(0), nil)
+				mockLoanRepo.On("GetStatsByBorrowerID", mock.Anything, "borrower-id-4").
+					Return(model.LoanStats{}, nil)
 
 				// Transaction handling
 				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
 
 				// Error creating loan
-				mockLoanRepo.On("Create", mock.Anything).Return(errors.New("database error"))
+				mockLoanRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("database error"))
 			},
 			expectedError: true,
 		},
+		{
+			name:       "Borrower Not Found",
+			borrowerID: "borrower-id-5",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				mockBorrowerRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-5"
+				})).Return(gorm.ErrRecordNotFound)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:       "Borrower Deactivated",
+			borrowerID: "borrower-id-6",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				mockBorrowerRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-6"
+				})).Run(setBorrowerStatus(constant.BorrowerStatusInactive)).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:       "Borrower Blacklisted",
+			borrowerID: "borrower-id-7",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockBorrowerRepo *MockBorrowerRepo, mockLedgerRepo *MockLedgerRepo) {
+				mockBorrowerRepo.On("Get", mock.Anything, mock.MatchedBy(func(b *model.Borrower) bool {
+					return b.ID == "borrower-id-7"
+				})).Run(setBorrowerStatus(constant.BorrowerStatusBlacklisted)).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockLoanRepo := new(MockLoanRepo)
 			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
-			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo)
+			mockBorrowerRepo := new(MockBorrowerRepo)
+			mockLedgerRepo := new(MockLedgerRepo)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo, mockBorrowerRepo, mockLedgerRepo)
 
-			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo)
-			loan, err := service.CreateLoanRequest(tt.borrowerID)
+			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo, mockBorrowerRepo, mockLedgerRepo, new(MockHolidayRepo), new(MockTxManager), newTestClock(), tt.cfg)
+			loan, err := service.CreateLoanRequest(context.Background(), tt.borrowerID)
 
 			if tt.expectedError {
 				assert.Error(t, err)
 				assert.Nil(t, loan)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, loan)
 				assert.Equal(t, tt.borrowerID, loan.BorrowerID)
-				assert.Equal(t, decimal.NewFromInt(5_000_000), loan.Principal)
+				assert.Equal(t, idr(5_000_000), loan.Principal)
 				assert.Equal(t, decimal.NewFromInt(10), loan.AnnualInterestRate)
 				assert.Equal(t, 50, loan.Period)
 				assert.Equal(t, constant.PeriodUnitWeek, string(loan.PeriodUnit))
@@ -192,6 +480,204 @@ func TestLoanService_CreateLoanRequest(t *testing.T) {
 
 			mockLoanRepo.AssertExpectations(t)
 			mockLoanPaymentRepo.AssertExpectations(t)
+			mockBorrowerRepo.AssertExpectations(t)
+			mockLedgerRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoanService_SimulateLoan(t *testing.T) {
+	today := lib.TruncateToDate(newTestClock().Now())
+
+	financedFee := LoanConfig{
+		OriginationFee: model.OriginationFeePolicy{
+			Type:  constant.OriginationFeeTypeFlat,
+			Value: decimal.NewFromInt(100_000),
+			Mode:  constant.OriginationFeeModeFinanced,
+		},
+	}
+
+	tests := []struct {
+		name                    string
+		loan                    model.Loan
+		cfg                     LoanConfig
+		expectedError           bool
+		expectedErrKind         lib.ErrorKind
+		expectedPrincipal       decimal.Decimal
+		expectedNetDisbursement decimal.Decimal
+		expectedMethod          constant.InterestMethod
+		expectedTotalRepayment  decimal.Decimal
+		expectedAPR             decimal.Decimal
+		expectedEffectiveRate   decimal.Decimal
+		expectedTimezone        string
+		expectedFirstDueDate    time.Time
+		expectedFirstDueAt      time.Time
+		expectedLastDueDate     time.Time
+	}{
+		{
+			name: "Flat Weekly Loan",
+			loan: model.Loan{
+				Principal:          idr(5_000_000),
+				AnnualInterestRate: decimal.NewFromInt(10),
+				Period:             50,
+				PeriodUnit:         constant.PeriodUnitWeek,
+			},
+			expectedPrincipal:       decimal.NewFromInt(5_000_000),
+			expectedNetDisbursement: decimal.NewFromInt(5_000_000),
+			expectedMethod:          constant.InterestMethodFlat,
+			expectedTotalRepayment:  decimal.NewFromInt(5_480_769),
+			expectedAPR:             decimal.RequireFromString("19.04"),
+			expectedEffectiveRate:   decimal.RequireFromString("20.93"),
+			expectedTimezone:        "UTC",
+			expectedFirstDueDate:    today.AddDate(0, 0, 7),
+			expectedFirstDueAt:      today.AddDate(0, 0, 8),
+			expectedLastDueDate:     today.AddDate(0, 0, 350),
+		},
+		{
+			name: "Due Dates Follow The Local Calendar",
+			loan: model.Loan{
+				Principal:          idr(5_000_000),
+				AnnualInterestRate: decimal.NewFromInt(10),
+				Period:             50,
+				PeriodUnit:         constant.PeriodUnitWeek,
+				Timezone:           "Pacific/Kiritimati",
+			},
+			expectedPrincipal:       decimal.NewFromInt(5_000_000),
+			expectedNetDisbursement: decimal.NewFromInt(5_000_000),
+			expectedMethod:          constant.InterestMethodFlat,
+			expectedTotalRepayment:  decimal.NewFromInt(5_480_769),
+			expectedAPR:             decimal.RequireFromString("19.04"),
+			expectedEffectiveRate:   decimal.RequireFromString("20.93"),
+			// noon UTC is already 02:00 the next day at UTC+14, and the local day ends at 10:00 UTC
+			expectedTimezone:     "Pacific/Kiritimati",
+			expectedFirstDueDate: today.AddDate(0, 0, 8),
+			expectedFirstDueAt:   today.AddDate(0, 0, 8).Add(10 * time.Hour),
+			expectedLastDueDate:  today.AddDate(0, 0, 351),
+		},
+		{
+			name: "Product Default Timezone",
+			loan: model.Loan{
+				Principal:          idr(5_000_000),
+				AnnualInterestRate: decimal.NewFromInt(10),
+				Period:             50,
+				PeriodUnit:         constant.PeriodUnitWeek,
+			},
+			cfg:                     LoanConfig{DefaultTimezone: "Asia/Jakarta"},
+			expectedPrincipal:       decimal.NewFromInt(5_000_000),
+			expectedNetDisbursement: decimal.NewFromInt(5_000_000),
+			expectedMethod:          constant.InterestMethodFlat,
+			expectedTotalRepayment:  decimal.NewFromInt(5_480_769),
+			expectedAPR:             decimal.RequireFromString("19.04"),
+			expectedEffectiveRate:   decimal.RequireFromString("20.93"),
+			expectedTimezone:        "Asia/Jakarta",
+			expectedFirstDueDate:    today.AddDate(0, 0, 7),
+			expectedFirstDueAt:      today.AddDate(0, 0, 7).Add(17 * time.Hour),
+			expectedLastDueDate:     today.AddDate(0, 0, 350),
+		},
+		{
+			name: "Annuity Monthly Loan",
+			loan: model.Loan{
+				Principal:          idr(1_000_000),
+				AnnualInterestRate: decimal.NewFromInt(12),
+				InterestMethod:     constant.InterestMethodAnnuity,
+				Period:             12,
+				PeriodUnit:         constant.PeriodUnitMonth,
+			},
+			expectedPrincipal:       decimal.NewFromInt(1_000_000),
+			expectedNetDisbursement: decimal.NewFromInt(1_000_000),
+			expectedMethod:          constant.InterestMethodAnnuity,
+			expectedTotalRepayment:  decimal.NewFromInt(1_066_186),
+			expectedAPR:             decimal.NewFromInt(12),
+			expectedEffectiveRate:   decimal.RequireFromString("12.68"),
+			expectedTimezone:        "UTC",
+			expectedFirstDueDate:    today.AddDate(0, 1, 0),
+			expectedFirstDueAt:      today.AddDate(0, 1, 1),
+			expectedLastDueDate:     today.AddDate(1, 0, 0),
+		},
+		{
+			name: "Financed Origination Fee",
+			loan: model.Loan{
+				Principal:          idr(1_000_000),
+				AnnualInterestRate: decimal.NewFromInt(12),
+				InterestMethod:     constant.InterestMethodAnnuity,
+				Period:             12,
+				PeriodUnit:         constant.PeriodUnitMonth,
+			},
+			cfg:                     financedFee,
+			expectedPrincipal:       decimal.NewFromInt(1_100_000),
+			expectedNetDisbursement: decimal.NewFromInt(1_000_000),
+			expectedMethod:          constant.InterestMethodAnnuity,
+			expectedTotalRepayment:  decimal.NewFromInt(1_172_805),
+			expectedAPR:             decimal.RequireFromString("30.5"),
+			expectedEffectiveRate:   decimal.RequireFromString("35.15"),
+			expectedTimezone:        "UTC",
+			expectedFirstDueDate:    today.AddDate(0, 1, 0),
+			expectedFirstDueAt:      today.AddDate(0, 1, 1),
+			expectedLastDueDate:     today.AddDate(1, 0, 0),
+		},
+		{
+			name: "Unsupported Currency",
+			loan: model.Loan{
+				Currency:           "XXX",
+				Principal:          idr(1_000_000),
+				AnnualInterestRate: decimal.NewFromInt(10),
+				Period:             4,
+				PeriodUnit:         constant.PeriodUnitWeek,
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+		},
+		{
+			name: "Origination Fee Exceeds Principal",
+			loan: model.Loan{
+				Principal:          idr(100_000),
+				AnnualInterestRate: decimal.NewFromInt(10),
+				Period:             4,
+				PeriodUnit:         constant.PeriodUnitWeek,
+			},
+			cfg: LoanConfig{
+				OriginationFee: model.OriginationFeePolicy{
+					Type:  constant.OriginationFeeTypeFlat,
+					Value: decimal.NewFromInt(100_000),
+					Mode:  constant.OriginationFeeModeDeducted,
+				},
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewLoanService(new(MockLoanRepo), new(MockLoanPaymentRepo), new(MockBorrowerRepo), new(MockLedgerRepo), new(MockHolidayRepo), new(MockTxManager), newTestClock(), tt.cfg)
+			simulation, err := service.SimulateLoan(context.Background(), tt.loan)
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, simulation)
+				assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, tt.expectedPrincipal.Equal(simulation.Principal.Amount), simulation.Principal.String())
+			assert.True(t, tt.expectedNetDisbursement.Equal(simulation.NetDisbursement.Amount), simulation.NetDisbursement.String())
+			assert.Equal(t, tt.expectedMethod, simulation.InterestMethod)
+			assert.True(t, tt.expectedTotalRepayment.Equal(simulation.TotalRepayment.Amount), simulation.TotalRepayment.String())
+			assert.True(t, simulation.TotalRepayment.Sub(simulation.Principal).Equal(simulation.TotalInterest))
+			assert.True(t, tt.expectedAPR.Equal(simulation.APR), simulation.APR.String())
+			assert.True(t, tt.expectedEffectiveRate.Equal(simulation.EffectiveRate), simulation.EffectiveRate.String())
+			assert.Len(t, simulation.Installments, tt.loan.Period)
+			assert.Equal(t, tt.expectedTimezone, simulation.Timezone)
+			assert.Equal(t, tt.expectedFirstDueDate, simulation.Installments[0].LocalDueDate)
+			assert.Equal(t, tt.expectedFirstDueAt, simulation.Installments[0].DueDate)
+			assert.Equal(t, tt.expectedLastDueDate, simulation.Installments[tt.loan.Period-1].LocalDueDate)
+
+			total := lib.ZeroMoney(simulation.Currency)
+			for _, installment := range simulation.Installments {
+				assert.True(t, installment.Principal.Add(installment.Interest).Equal(installment.Amount))
+				total = total.Add(installment.Amount)
+			}
+			assert.True(t, total.Equal(simulation.TotalRepayment))
 		})
 	}
 }
@@ -213,11 +699,11 @@ func TestLoanService_GetLoansByBorrowerID(t *testing.T) {
 						Loan: model.Loan{
 							ID:                 uuid.Must(uuid.NewV7()).String(),
 							BorrowerID:         "borrower-id-1",
-							Principal:          decimal.NewFromInt(5_000_000),
+							Principal:          idr(5_000_000),
 							AnnualInterestRate: decimal.NewFromInt(10),
 							Period:             50,
 							PeriodUnit:         constant.PeriodUnitWeek,
-							TotalRepayment:     decimal.NewFromInt(5_500_000),
+							TotalRepayment:     idr(5_500_000),
 							CreatedAt:          time.Now().UTC(),
 						},
 						IsCompleted: false,
@@ -226,17 +712,19 @@ func TestLoanService_GetLoansByBorrowerID(t *testing.T) {
 						Loan: model.Loan{
 							ID:                 uuid.Must(uuid.NewV7()).String(),
 							BorrowerID:         "borrower-id-1",
-							Principal:          decimal.NewFromInt(3_000_000),
+							Principal:          idr(3_000_000),
 							AnnualInterestRate: decimal.NewFromInt(10),
 							Period:             30,
 							PeriodUnit:         constant.PeriodUnitWeek,
-							TotalRepayment:     decimal.NewFromInt(3_300_000),
+							TotalRepayment:     idr(3_300_000),
 							CreatedAt:          time.Now().UTC(),
 						},
 						IsCompleted: true,
 					},
 				}
-				mockLoanRepo.On("FindByBorrowerID", "borrower-id-1").Return(loans, nil)
+				mockLoanRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanFilter) bool {
+					return f.BorrowerID == "borrower-id-1" && f.Limit == lib.DefaultPageLimit
+				})).Return(loans, (*lib.Cursor)(nil), nil)
 			},
 			expectedError: false,
 			expectedCount: 2,
@@ -246,7 +734,9 @@ func TestLoanService_GetLoansByBorrowerID(t *testing.T) {
 			borrowerID: "borrower-id-2",
 			mockSetup: func(mockLoanRepo *MockLoanRepo) {
 				loans := []*model.LoanWithCompleteStatus{}
-				mockLoanRepo.On("FindByBorrowerID", "borrower-id-2").Return(loans, nil)
+				mockLoanRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanFilter) bool {
+					return f.BorrowerID == "borrower-id-2"
+				})).Return(loans, (*lib.Cursor)(nil), nil)
 			},
 			expectedError: false,
 			expectedCount: 0,
@@ -255,8 +745,9 @@ func TestLoanService_GetLoansByBorrowerID(t *testing.T) {
 			name:       "Repository Error",
 			borrowerID: "borrower-id-3",
 			mockSetup: func(mockLoanRepo *MockLoanRepo) {
-				mockLoanRepo.On("FindByBorrowerID", "borrower-id-3").
-					Return([]*model.LoanWithCompleteStatus{}, errors.New("database error"))
+				mockLoanRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanFilter) bool {
+					return f.BorrowerID == "borrower-id-3"
+				})).Return([]*model.LoanWithCompleteStatus{}, (*lib.Cursor)(nil), errors.New("database error"))
 			},
 			expectedError: true,
 			expectedCount: 0,
@@ -269,8 +760,8 @@ func TestLoanService_GetLoansByBorrowerID(t *testing.T) {
 			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
 			tt.mockSetup(mockLoanRepo)
 
-			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo)
-			loans, err := service.GetLoansByBorrowerID(tt.borrowerID)
+			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo, new(MockBorrowerRepo), new(MockLedgerRepo), new(MockHolidayRepo), new(MockTxManager), newTestClock(), LoanConfig{})
+			loans, _, err := service.GetLoansByBorrowerID(context.Background(), tt.borrowerID, LoanListFilter{})
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -287,46 +778,145 @@ func TestLoanService_GetLoansByBorrowerID(t *testing.T) {
 	}
 }
 
+func TestLoanService_SearchLoans(t *testing.T) {
+	createdFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastID := uuid.Must(uuid.NewV7()).String()
+
+	tests := []struct {
+		name               string
+		filter             LoanListFilter
+		mockSetup          func(mockLoanRepo *MockLoanRepo)
+		expectedError      bool
+		expectedErrKind    lib.ErrorKind
+		expectedNextCursor string
+	}{
+		{
+			name: "Success across borrowers with next page",
+			filter: LoanListFilter{
+				Status:      constant.LoanStatusActive,
+				CreatedFrom: &createdFrom,
+				Desc:        true,
+				Limit:       1,
+			},
+			mockSetup: func(mockLoanRepo *MockLoanRepo) {
+				loans := []*model.LoanWithCompleteStatus{
+					{Loan: model.Loan{ID: lastID, BorrowerID: "borrower-id-1"}},
+				}
+				mockLoanRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanFilter) bool {
+					return f.BorrowerID == "" &&
+						f.Status == constant.LoanStatusActive &&
+						f.CreatedFrom.Equal(createdFrom) &&
+						f.Desc &&
+						f.Limit == 1
+				})).Return(loans, &lib.Cursor{ID: lastID}, nil)
+			},
+			expectedError:      false,
+			expectedNextCursor: lib.EncodeCursor(&lib.Cursor{ID: lastID}),
+		},
+		{
+			name: "Limit Is Capped",
+			filter: LoanListFilter{
+				Limit: 1000,
+			},
+			mockSetup: func(mockLoanRepo *MockLoanRepo) {
+				mockLoanRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanFilter) bool {
+					return f.Limit == lib.MaxPageLimit
+				})).Return([]*model.LoanWithCompleteStatus{}, (*lib.Cursor)(nil), nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Invalid Cursor",
+			filter: LoanListFilter{
+				Cursor: "not a cursor!",
+			},
+			mockSetup:       func(mockLoanRepo *MockLoanRepo) {},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			tt.mockSetup(mockLoanRepo)
+
+			service := NewLoanService(mockLoanRepo, new(MockLoanPaymentRepo), new(MockBorrowerRepo), new(MockLedgerRepo), new(MockHolidayRepo), new(MockTxManager), newTestClock(), LoanConfig{})
+			_, nextCursor, err := service.SearchLoans(context.Background(), tt.filter)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedNextCursor, nextCursor)
+			}
+
+			mockLoanRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestLoanService_GetLoanDetail(t *testing.T) {
 	tests := []struct {
-		name          string
-		loanID        string
-		mockSetup     func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo)
-		expectedError bool
+		name            string
+		borrowerID      string
+		loanID          string
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
 	}{
 		{
-			name:   "Success",
-			loanID: "loan-id-1",
+			name:       "Success",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-1",
 			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
-				mockLoanRepo.On("Get", mock.MatchedBy(func(l *model.Loan) bool {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
 					return l.ID == "loan-id-1"
-				})).Return(nil)
-				mockLoanPaymentRepo.On("GetTotalOutstandingByLoanID", "loan-id-1").
-					Return(decimal.NewFromInt(2_000_000), nil)
+				})).Run(func(args mock.Arguments) {
+					l := args.Get(1).(*model.Loan)
+					l.BorrowerID = "borrower-id-1"
+					l.OutstandingTotal = idr(2_000_000)
+				}).Return(nil)
 			},
 			expectedError: false,
 		},
 		{
-			name:   "Error Getting Loan",
-			loanID: "loan-id-2",
+			name:       "Error Getting Loan",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-2",
 			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
-				mockLoanRepo.On("Get", mock.MatchedBy(func(l *model.Loan) bool {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
 					return l.ID == "loan-id-2"
 				})).Return(errors.New("database error"))
 			},
 			expectedError: true,
 		},
 		{
-			name:   "Error Getting Outstanding Amount",
-			loanID: "loan-id-3",
+			name:       "Loan Not Found",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-4",
 			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
-				mockLoanRepo.On("Get", mock.MatchedBy(func(l *model.Loan) bool {
-					return l.ID == "loan-id-3"
-				})).Return(nil)
-				mockLoanPaymentRepo.On("GetTotalOutstandingByLoanID", "loan-id-3").
-					Return(decimal.NewFromInt(0), errors.New("database error"))
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-4"
+				})).Return(gorm.ErrRecordNotFound)
 			},
-			expectedError: true,
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:       "Loan Owned By Another Borrower",
+			borrowerID: "borrower-id-2",
+			loanID:     "loan-id-5",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-5"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
 		},
 	}
 
@@ -336,16 +926,20 @@ func TestLoanService_GetLoanDetail(t *testing.T) {
 			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
 			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo)
 
-			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo)
-			loan, outstanding, err := service.GetLoanDetail(tt.loanID)
+			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo, new(MockBorrowerRepo), new(MockLedgerRepo), new(MockHolidayRepo), new(MockTxManager), newTestClock(), LoanConfig{})
+			loan, outstanding, err := service.GetLoanDetail(context.Background(), tt.borrowerID, tt.loanID)
 
 			if tt.expectedError {
 				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, loan)
 				assert.Equal(t, tt.loanID, loan.ID)
-				assert.Equal(t, decimal.NewFromInt(2_000_000), outstanding)
+				assert.Equal(t, tt.borrowerID, loan.BorrowerID)
+				assert.True(t, decimal.NewFromInt(2_000_000).Equal(outstanding), outstanding.String())
 			}
 
 			mockLoanRepo.AssertExpectations(t)
@@ -356,22 +950,29 @@ func TestLoanService_GetLoanDetail(t *testing.T) {
 
 func TestLoanService_GetLoanPaymentsByLoanID(t *testing.T) {
 	tests := []struct {
-		name          string
-		loanID        string
-		mockSetup     func(mockLoanPaymentRepo *MockLoanPaymentRepo)
-		expectedError bool
-		expectedCount int
+		name            string
+		borrowerID      string
+		loanID          string
+		filter          LoanPaymentListFilter
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+		expectedCount   int
 	}{
 		{
-			name:   "Success with loan payments",
-			loanID: "loan-id-1",
-			mockSetup: func(mockLoanPaymentRepo *MockLoanPaymentRepo) {
+			name:       "Success with loan payments",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-1",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-1"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
 				loanPayments := []*model.LoanPayment{
 					{
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-1",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    time.Now().UTC().AddDate(0, 0, -7),
 						Status:     constant.LoanPaymentStatusPaid,
 						PaidAt:     func() *time.Time { t := time.Now().UTC().AddDate(0, 0, -5); return &t }(),
@@ -380,54 +981,97 @@ func TestLoanService_GetLoanPaymentsByLoanID(t *testing.T) {
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-1",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    time.Now().UTC().AddDate(0, 0, 7),
 						Status:     constant.LoanPaymentStatusUnpaid,
 					},
 				}
-				mockLoanPaymentRepo.On("Find", mock.MatchedBy(func(lp model.LoanPayment) bool {
-					return lp.LoanID == "loan-id-1"
-				})).Return(loanPayments, nil)
+				mockLoanPaymentRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanPaymentFilter) bool {
+					return f.LoanID == "loan-id-1"
+				})).Return(loanPayments, (*lib.Cursor)(nil), nil)
 			},
 			expectedError: false,
 			expectedCount: 2,
 		},
 		{
-			name:   "Success with empty list",
-			loanID: "loan-id-2",
-			mockSetup: func(mockLoanPaymentRepo *MockLoanPaymentRepo) {
+			name:       "Success with empty list",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-2",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-2"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
 				loanPayments := []*model.LoanPayment{}
-				mockLoanPaymentRepo.On("Find", mock.MatchedBy(func(lp model.LoanPayment) bool {
-					return lp.LoanID == "loan-id-2"
-				})).Return(loanPayments, nil)
+				mockLoanPaymentRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanPaymentFilter) bool {
+					return f.LoanID == "loan-id-2"
+				})).Return(loanPayments, (*lib.Cursor)(nil), nil)
 			},
 			expectedError: false,
 			expectedCount: 0,
 		},
 		{
-			name:   "Repository Error",
-			loanID: "loan-id-3",
-			mockSetup: func(mockLoanPaymentRepo *MockLoanPaymentRepo) {
-				mockLoanPaymentRepo.On("Find", mock.MatchedBy(func(lp model.LoanPayment) bool {
-					return lp.LoanID == "loan-id-3"
-				})).Return([]*model.LoanPayment{}, errors.New("database error"))
+			name:       "Success with overdue filter",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-5",
+			filter: LoanPaymentListFilter{
+				OverdueOnly: true,
+				Limit:       10,
+			},
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-5"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLoanPaymentRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanPaymentFilter) bool {
+					return f.LoanID == "loan-id-5" && f.OverdueOnly && f.Limit == 10
+				})).Return([]*model.LoanPayment{}, (*lib.Cursor)(nil), nil)
+			},
+			expectedError: false,
+			expectedCount: 0,
+		},
+		{
+			name:       "Repository Error",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-3",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-3"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLoanPaymentRepo.On("List", mock.Anything, mock.MatchedBy(func(f repository.LoanPaymentFilter) bool {
+					return f.LoanID == "loan-id-3"
+				})).Return([]*model.LoanPayment{}, (*lib.Cursor)(nil), errors.New("database error"))
 			},
 			expectedError: true,
 			expectedCount: 0,
 		},
+		{
+			name:       "Loan Owned By Another Borrower",
+			borrowerID: "borrower-id-2",
+			loanID:     "loan-id-4",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-4"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+			expectedCount:   0,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockLoanRepo := new(MockLoanRepo)
 			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
-			tt.mockSetup(mockLoanPaymentRepo)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo)
 
-			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo)
-			loanPayments, err := service.GetLoanPaymentsByLoanID(tt.loanID)
+			service := NewLoanService(mockLoanRepo, mockLoanPaymentRepo, new(MockBorrowerRepo), new(MockLedgerRepo), new(MockHolidayRepo), new(MockTxManager), newTestClock(), LoanConfig{})
+			loanPayments, _, err := service.GetLoanPaymentsByLoanID(context.Background(), tt.borrowerID, tt.loanID, tt.filter)
 
 			if tt.expectedError {
 				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Len(t, loanPayments, tt.expectedCount)
@@ -436,124 +1080,468 @@ func TestLoanService_GetLoanPaymentsByLoanID(t *testing.T) {
 				}
 			}
 
+			mockLoanRepo.AssertExpectations(t)
 			mockLoanPaymentRepo.AssertExpectations(t)
 		})
 	}
 }
 
 func TestLoanService_MakePayment(t *testing.T) {
-	now := time.Now().UTC()
+	now := newTestClock().Now()
 	pastDue := now.AddDate(0, 0, -7)
 	futureDue := now.AddDate(0, 0, 7)
 
 	tests := []struct {
-		name          string
-		loanID        string
-		amount        decimal.Decimal
-		mockSetup     func(mockLoanPaymentRepo *MockLoanPaymentRepo, mockLockManager *MockLockManager)
-		expectedError bool
+		name            string
+		borrowerID      string
+		loanID          string
+		amount          decimal.Decimal
+		currency        string
+		noCurrency      bool
+		version         int64
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
 	}{
 		{
-			name:   "Success - Pay Exact Amount",
-			loanID: "loan-id-1",
-			amount: decimal.NewFromInt(110_000),
-			mockSetup: func(mockLoanPaymentRepo *MockLoanPaymentRepo, mockLockManager *MockLockManager) {
+			name:       "Success - Pay Exact Amount",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-1",
+			amount:     decimal.NewFromInt(110_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				// Mock loan ownership, 5.000.000 over 50 weeks repays 100.000 principal a week
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-1"
+				})).Run(func(args mock.Arguments) {
+					l := args.Get(1).(*model.Loan)
+					l.BorrowerID = "borrower-id-1"
+					l.Principal = idr(5_000_000)
+					l.AnnualInterestRate = decimal.NewFromInt(10)
+					l.InterestMethod = constant.InterestMethodFlat
+					l.TotalRepayment = idr(5_500_000)
+					l.Period = 50
+					l.PeriodUnit = constant.PeriodUnitWeek
+				}).Return(nil)
+
 				// Mock lock
 				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
 
-				// Mock unpaid loan payments
+				// Mock outstanding loan payments
 				loanPayments := []*model.LoanPayment{
 					{
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-1",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    pastDue,
-						Status:     constant.LoanPaymentStatusUnpaid,
+						Status:     constant.LoanPaymentStatusOverdue,
 					},
 					{
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-1",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    futureDue,
 						Status:     constant.LoanPaymentStatusUnpaid,
 					},
 				}
-				mockLoanPaymentRepo.On("Find", mock.MatchedBy(func(lp model.LoanPayment) bool {
-					return lp.LoanID == "loan-id-1" && lp.Status == constant.LoanPaymentStatusUnpaid
-				})).Return(loanPayments, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-1").Return(loanPayments, nil)
+
+				// Mock change status to paid, linking the installment to the repayment entry
+				var paidBy string
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanPaymentRepo.On("ChangeStatusToPaid", mock.Anything, []string{loanPayments[0].ID}, mock.Anything, mock.MatchedBy(func(entryID string) bool {
+					paidBy = entryID
+					return entryID != ""
+				})).Return(nil)
+
+				// Mock balance refresh, the paid installment moves from outstanding to paid
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return([]*model.LoanPayment{
+					{ID: loanPayments[0].ID, Amount: idr(110_000), Principal: idrRef(100_000), DueDate: pastDue, Status: constant.LoanPaymentStatusPaid},
+					{ID: loanPayments[1].ID, Amount: idr(110_000), Principal: idrRef(100_000), DueDate: futureDue, Status: constant.LoanPaymentStatusUnpaid},
+				}, nil)
+				mockLoanRepo.On("UpdateBalances", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.OutstandingTotal.Equal(idr(110_000)) &&
+						l.OutstandingPrincipal.Equal(idr(100_000)) &&
+						l.PaidTotal.Equal(idr(110_000)) &&
+						l.NextDueDate.Equal(futureDue) &&
+						l.InstallmentsOverdue == 0
+				})).Return(true, nil)
 
-				// Mock change status to paid
-				mockLoanPaymentRepo.On("ChangeStatusToPaid", []string{loanPayments[0].ID}, mock.Anything).Return(nil)
+				// Mock repayment posting, split into principal and interest
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeRepayment &&
+						e.ID == paidBy &&
+						e.Validate() == nil &&
+						len(e.Lines) == 3 &&
+						e.Lines[0].Debit.Equal(decimal.NewFromInt(110_000)) &&
+						e.Lines[1].Credit.Equal(decimal.NewFromInt(100_000)) &&
+						e.Lines[2].AccountCode == constant.LedgerAccountInterestReceivable &&
+						e.Lines[2].Credit.Equal(decimal.NewFromInt(10_000))
+				})).Return(nil)
 			},
 			expectedError: false,
 		},
 		{
-			name:   "Error - Payment Less Than Minimum",
-			loanID: "loan-id-2",
-			amount: decimal.NewFromInt(50_000),
-			mockSetup: func(mockLoanPaymentRepo *MockLoanPaymentRepo, mockLockManager *MockLockManager) {
+			name:       "Success - Pay Without Currency In The Loan Currency",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-1",
+			amount:     decimal.NewFromInt(110_000),
+			noCurrency: true,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-1"
+				})).Run(func(args mock.Arguments) {
+					l := args.Get(1).(*model.Loan)
+					l.BorrowerID = "borrower-id-1"
+					l.Currency = "IDR"
+					l.Principal = idr(5_000_000)
+					l.AnnualInterestRate = decimal.NewFromInt(10)
+					l.InterestMethod = constant.InterestMethodFlat
+					l.TotalRepayment = idr(5_500_000)
+					l.Period = 50
+					l.PeriodUnit = constant.PeriodUnitWeek
+				}).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
+
+				loanPayments := []*model.LoanPayment{
+					{ID: "lp-id-1", LoanID: "loan-id-1", Amount: idr(110_000), DueDate: futureDue, Status: constant.LoanPaymentStatusUnpaid},
+				}
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-1").Return(loanPayments, nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanPaymentRepo.On("ChangeStatusToPaid", mock.Anything, []string{"lp-id-1"}, mock.Anything, mock.Anything).Return(nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return([]*model.LoanPayment{
+					{ID: "lp-id-1", Amount: idr(110_000), DueDate: futureDue, Status: constant.LoanPaymentStatusPaid},
+				}, nil)
+				mockLoanRepo.On("UpdateBalances", mock.Anything, mock.Anything).Return(true, nil)
+
+				// the amount is taken to be in the currency of the loan
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Validate() == nil &&
+						e.Lines[0].Currency == "IDR" &&
+						e.Lines[0].Debit.Equal(decimal.NewFromInt(110_000))
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Payment In Another Currency",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-1",
+			amount:     decimal.NewFromInt(110_000),
+			currency:   "USD",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-1"
+				})).Run(func(args mock.Arguments) {
+					l := args.Get(1).(*model.Loan)
+					l.BorrowerID = "borrower-id-1"
+					l.Currency = "IDR"
+				}).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:       "Success - Pay Overdue Installment With Late Fee",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-7",
+			amount:     decimal.NewFromInt(115_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-7"
+				})).Run(func(args mock.Arguments) {
+					l := args.Get(1).(*model.Loan)
+					l.BorrowerID = "borrower-id-1"
+					l.Principal = idr(5_000_000)
+					l.AnnualInterestRate = decimal.NewFromInt(10)
+					l.InterestMethod = constant.InterestMethodFlat
+					l.Period = 50
+					l.PeriodUnit = constant.PeriodUnitWeek
+				}).Return(nil)
+
+				mockLockManager.On("GetLock", "loan-id-7").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-7").Return(false, nil)
+
+				// The overdue installment carries a 5.000 late fee that has to be paid along with it
+				loanPayments := []*model.LoanPayment{
+					{
+						ID:         uuid.Must(uuid.NewV7()).String(),
+						LoanID:     "loan-id-7",
+						BorrowerID: "borrower-id-1",
+						Amount:     idr(110_000),
+						LateFee:    idr(5_000),
+						DueDate:    pastDue,
+						Status:     constant.LoanPaymentStatusOverdue,
+					},
+					{
+						ID:         uuid.Must(uuid.NewV7()).String(),
+						LoanID:     "loan-id-7",
+						BorrowerID: "borrower-id-1",
+						Amount:     idr(110_000),
+						DueDate:    futureDue,
+						Status:     constant.LoanPaymentStatusUnpaid,
+					},
+				}
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-7").Return(loanPayments, nil)
+
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanPaymentRepo.On("ChangeStatusToPaid", mock.Anything, []string{loanPayments[0].ID}, mock.Anything, mock.Anything).Return(nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("Find", mock.Anything, mock.Anything).Return(loanPayments, nil)
+				mockLoanRepo.On("UpdateBalances", mock.Anything, mock.Anything).Return(true, nil)
+
+				// The late fee settles the loan receivable together with the principal
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeRepayment &&
+						e.Validate() == nil &&
+						len(e.Lines) == 3 &&
+						e.Lines[0].Debit.Equal(decimal.NewFromInt(115_000)) &&
+						e.Lines[1].Credit.Equal(decimal.NewFromInt(105_000)) &&
+						e.Lines[2].Credit.Equal(decimal.NewFromInt(10_000))
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Error - Late Fee Not Included",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-8",
+			amount:     decimal.NewFromInt(110_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-8"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+
+				mockLockManager.On("GetLock", "loan-id-8").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-8").Return(false, nil)
+
+				loanPayments := []*model.LoanPayment{
+					{
+						ID:         uuid.Must(uuid.NewV7()).String(),
+						LoanID:     "loan-id-8",
+						BorrowerID: "borrower-id-1",
+						Amount:     idr(110_000),
+						LateFee:    idr(5_000),
+						DueDate:    pastDue,
+						Status:     constant.LoanPaymentStatusOverdue,
+					},
+				}
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-8").Return(loanPayments, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:       "Error - Payment Less Than Minimum",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-2",
+			amount:     decimal.NewFromInt(50_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				// Mock loan ownership
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-2"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+
 				// Mock lock
 				mockLockManager.On("GetLock", "loan-id-2").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-2").Return(false, nil)
 
-				// Mock unpaid loan payments
+				// Mock outstanding loan payments
 				loanPayments := []*model.LoanPayment{
 					{
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-2",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    pastDue,
-						Status:     constant.LoanPaymentStatusUnpaid,
+						Status:     constant.LoanPaymentStatusOverdue,
 					},
 					{
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-2",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    futureDue,
 						Status:     constant.LoanPaymentStatusUnpaid,
 					},
 				}
-				mockLoanPaymentRepo.On("Find", mock.MatchedBy(func(lp model.LoanPayment) bool {
-					return lp.LoanID == "loan-id-2" && lp.Status == constant.LoanPaymentStatusUnpaid
-				})).Return(loanPayments, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-2").Return(loanPayments, nil)
 			},
-			expectedError: true,
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
 		},
 		{
-			name:   "Error - Payment Not In Plan",
-			loanID: "loan-id-3",
-			amount: decimal.NewFromInt(150_000),
-			mockSetup: func(mockLoanPaymentRepo *MockLoanPaymentRepo, mockLockManager *MockLockManager) {
+			name:       "Error - Payment Not In Plan",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-3",
+			amount:     decimal.NewFromInt(150_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				// Mock loan ownership
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-3"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+
 				// Mock lock
 				mockLockManager.On("GetLock", "loan-id-3").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-3").Return(false, nil)
 
-				// Mock unpaid loan payments
+				// Mock outstanding loan payments
 				loanPayments := []*model.LoanPayment{
 					{
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-3",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    pastDue,
-						Status:     constant.LoanPaymentStatusUnpaid,
+						Status:     constant.LoanPaymentStatusOverdue,
 					},
 					{
 						ID:         uuid.Must(uuid.NewV7()).String(),
 						LoanID:     "loan-id-3",
 						BorrowerID: "borrower-id-1",
-						Amount:     decimal.NewFromInt(110_000),
+						Amount:     idr(110_000),
 						DueDate:    futureDue,
 						Status:     constant.LoanPaymentStatusUnpaid,
 					},
 				}
-				mockLoanPaymentRepo.On("Find", mock.MatchedBy(func(lp model.LoanPayment) bool {
-					return lp.LoanID == "loan-id-3" && lp.Status == constant.LoanPaymentStatusUnpaid
-				})).Return(loanPayments, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-3").Return(loanPayments, nil)
 			},
-			expectedError: true,
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:       "Error - No Outstanding Payment",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-4",
+			amount:     decimal.NewFromInt(110_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				// Mock loan ownership
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-4"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+
+				// Mock lock
+				mockLockManager.On("GetLock", "loan-id-4").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-4").Return(false, nil)
+
+				// All loan payments are already paid
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-4").Return([]*model.LoanPayment{}, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:       "Error - Loan Owned By Another Borrower",
+			borrowerID: "borrower-id-2",
+			loanID:     "loan-id-5",
+			amount:     decimal.NewFromInt(110_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-5"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:       "Error - Loan Written Off",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-6",
+			amount:     decimal.NewFromInt(110_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-6"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-6").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-6").Return(true, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:       "Error - Amount Finer Than Currency",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-10",
+			amount:     decimal.RequireFromString("110000.5"),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-10"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-10").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-10").Return(false, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-10").Return([]*model.LoanPayment{
+					{ID: "lp-1", LoanID: "loan-id-10", Amount: idr(110_000), DueDate: now.AddDate(0, 0, 7), Status: constant.LoanPaymentStatusUnpaid},
+				}, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+		},
+		{
+			name:       "Error - Installment In Another Currency",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-9",
+			amount:     decimal.NewFromInt(110_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-9"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-9").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-9").Return(false, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-9").Return([]*model.LoanPayment{
+					{ID: "lp-1", LoanID: "loan-id-9", Currency: "USD", Amount: lib.NewMoneyFromInt(110_000, "USD"), DueDate: now.AddDate(0, 0, 7), Status: constant.LoanPaymentStatusUnpaid},
+				}, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:       "Error - Stale Loan Version",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-7",
+			amount:     decimal.NewFromInt(110_000),
+			version:    2,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-7"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+		{
+			name:       "Error - Loan Changed During Payment",
+			borrowerID: "borrower-id-1",
+			loanID:     "loan-id-8",
+			amount:     decimal.NewFromInt(110_000),
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-8"
+				})).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-8").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-8").Return(false, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-8").Return([]*model.LoanPayment{
+					{ID: "lp-1", LoanID: "loan-id-8", Amount: idr(110_000), DueDate: now.AddDate(0, 0, 7), Status: constant.LoanPaymentStatusUnpaid},
+				}, nil)
+
+				// another change landed between reading the loan and locking it
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					args.Get(1).(*model.Loan).Version = 2
+				}).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
 		},
 	}
 
@@ -561,25 +1549,311 @@ func TestLoanService_MakePayment(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockLoanRepo := new(MockLoanRepo)
 			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			mockLedgerRepo := new(MockLedgerRepo)
 			mockLockManager := new(MockLockManager)
-			tt.mockSetup(mockLoanPaymentRepo, mockLockManager)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo, mockLedgerRepo, mockLockManager)
 
 			// We need to use a type assertion here because LoanService expects lib.LockManager
 			service := &LoanService{
 				loanRepo:        mockLoanRepo,
 				loanPaymentRepo: mockLoanPaymentRepo,
+				ledgerRepo:      mockLedgerRepo,
+				txManager:       new(MockTxManager),
 				lockManager:     mockLockManager,
+				clock:           newTestClock(),
+			}
+			currency := tt.currency
+			if currency == "" && !tt.noCurrency {
+				currency = lib.DefaultCurrency
 			}
-			err := service.MakePayment(tt.loanID, tt.amount)
+			_, err := service.MakePayment(context.Background(), tt.borrowerID, tt.loanID, lib.NewMoney(tt.amount, currency), tt.version)
 
 			if tt.expectedError {
 				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
 			} else {
 				assert.NoError(t, err)
 			}
 
+			mockLoanRepo.AssertExpectations(t)
 			mockLoanPaymentRepo.AssertExpectations(t)
+			mockLedgerRepo.AssertExpectations(t)
 			mockLockManager.AssertExpectations(t)
 		})
 	}
 }
+
+func TestLoanService_WriteOffLoan(t *testing.T) {
+	tests := []struct {
+		name            string
+		loanID          string
+		version         int64
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-id-1",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
+				mockLedgerRepo.On("GetLoanAccountBalance", mock.Anything, "loan-id-1", constant.LedgerAccountLoanReceivable).
+					Return(decimal.NewFromInt(4_900_000), nil)
+				mockLedgerRepo.On("GetLoanAccountBalance", mock.Anything, "loan-id-1", constant.LedgerAccountInterestReceivable).
+					Return(decimal.NewFromInt(5_000), nil)
+				// the write-off moves the loan to its next version
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanRepo.On("BumpVersion", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					args.Get(1).(*model.Loan).Version++
+				}).Return(true, nil)
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeWriteOff &&
+						e.Validate() == nil &&
+						len(e.Lines) == 3 &&
+						e.Lines[0].AccountCode == constant.LedgerAccountLoanLossExpense &&
+						e.Lines[0].Debit.Equal(decimal.NewFromInt(4_905_000))
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-id-2",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:   "Already Written Off",
+			loanID: "loan-id-3",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-3").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-3").Return(true, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+		},
+		{
+			name:   "Nothing To Write Off",
+			loanID: "loan-id-4",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanBorrower("borrower-id-1")).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-4").Return(&sync.Mutex{})
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-4").Return(false, nil)
+				mockLedgerRepo.On("GetLoanAccountBalance", mock.Anything, "loan-id-4", constant.LedgerAccountLoanReceivable).
+					Return(decimal.Zero, nil)
+				mockLedgerRepo.On("GetLoanAccountBalance", mock.Anything, "loan-id-4", constant.LedgerAccountInterestReceivable).
+					Return(decimal.NewFromInt(-10_000), nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			mockLedgerRepo := new(MockLedgerRepo)
+			mockLockManager := new(MockLockManager)
+			tt.mockSetup(mockLoanRepo, mockLedgerRepo, mockLockManager)
+
+			service := &LoanService{
+				loanRepo:    mockLoanRepo,
+				ledgerRepo:  mockLedgerRepo,
+				txManager:   new(MockTxManager),
+				lockManager: mockLockManager,
+				clock:       newTestClock(),
+			}
+			entry, loan, err := service.WriteOffLoan(context.Background(), tt.loanID, tt.version)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, entry)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, entry)
+				assert.Equal(t, int64(2), loan.Version)
+			}
+
+			mockLoanRepo.AssertExpectations(t)
+			mockLedgerRepo.AssertExpectations(t)
+			mockLockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoanService_WaiveInstallment(t *testing.T) {
+	// MockLoanRepo.Get returns 5.000.000 at 10% over 50 weeks; the last two installments are outstanding
+	loan := model.Loan{
+		Principal:          idr(5_000_000),
+		AnnualInterestRate: decimal.NewFromInt(10),
+		Period:             50,
+		PeriodUnit:         constant.PeriodUnitWeek,
+	}
+	installment := loan.Installments()[48]
+	setLoanPayment := func(loanID string, status constant.LoanPaymentStatus) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			lp := args.Get(1).(*model.LoanPayment)
+			lp.LoanID = loanID
+			lp.Amount = installment.Amount()
+			lp.LateFee = idr(5_000)
+			lp.Status = status
+		}
+	}
+	outstanding := []*model.LoanPayment{
+		{ID: "lp-id-1", LoanID: "loan-id-1", Amount: installment.Amount(), Status: constant.LoanPaymentStatusOverdue},
+		{ID: "lp-id-2", LoanID: "loan-id-1", Amount: installment.Amount(), Status: constant.LoanPaymentStatusUnpaid},
+	}
+
+	tests := []struct {
+		name            string
+		loanPaymentID   string
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+	}{
+		{
+			name:          "Success",
+			loanPaymentID: "lp-id-1",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLoanPaymentRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanPayment("loan-id-1", constant.LoanPaymentStatusOverdue)).Return(nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-1").Return(outstanding, nil)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				// the installment is linked to the waiver entry that settles it
+				var waivedBy *string
+				mockLoanPaymentRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(lp *model.LoanPayment) bool {
+					waivedBy = lp.SettledByEntryID
+					return lp.ID == "lp-id-1" && lp.Status == constant.LoanPaymentStatusWaived && waivedBy != nil
+				}), constant.LoanPaymentStatus(constant.LoanPaymentStatusOverdue)).Return(true, nil)
+				mockLoanRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return(outstanding, nil)
+				mockLoanRepo.On("UpdateBalances", mock.Anything, mock.Anything).Return(true, nil)
+
+				// the late fee was booked onto the loan receivable, so it is charged off with the principal
+				mockLedgerRepo.On("WithTx", mock.Anything).Return(mockLedgerRepo)
+				mockLedgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *model.JournalEntry) bool {
+					return e.Type == constant.JournalEntryTypeWaiver &&
+						e.ID == *waivedBy &&
+						e.Validate() == nil &&
+						len(e.Lines) == 3 &&
+						e.Lines[0].Debit.Equal(installment.Amount().Add(idr(5_000)).Amount) &&
+						e.Lines[1].AccountCode == constant.LedgerAccountLoanReceivable &&
+						e.Lines[1].Credit.Equal(installment.Principal.Add(idr(5_000)).Amount) &&
+						e.Lines[2].AccountCode == constant.LedgerAccountInterestReceivable &&
+						e.Lines[2].Credit.Equal(installment.Interest.Amount)
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:          "Loan Payment Of Another Loan",
+			loanPaymentID: "lp-id-9",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLoanPaymentRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanPayment("loan-id-2", constant.LoanPaymentStatusOverdue)).Return(nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindNotFound,
+		},
+		{
+			name:          "Already Paid",
+			loanPaymentID: "lp-id-1",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLoanPaymentRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanPayment("loan-id-1", constant.LoanPaymentStatusPaid)).Return(nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:          "Not The Oldest Outstanding Installment",
+			loanPaymentID: "lp-id-2",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLoanPaymentRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanPayment("loan-id-1", constant.LoanPaymentStatusUnpaid)).Return(nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(false, nil)
+				mockLoanPaymentRepo.On("FindOutstanding", mock.Anything, "loan-id-1").Return(outstanding, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+		{
+			name:          "Loan Written Off",
+			loanPaymentID: "lp-id-1",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockLedgerRepo *MockLedgerRepo, mockLockManager *MockLockManager) {
+				mockLoanRepo.On("Get", mock.Anything, mock.Anything).Return(nil)
+				mockLockManager.On("GetLock", "loan-id-1").Return(&sync.Mutex{})
+				mockLoanPaymentRepo.On("Get", mock.Anything, mock.Anything).Run(setLoanPayment("loan-id-1", constant.LoanPaymentStatusOverdue)).Return(nil)
+				mockLedgerRepo.On("IsLoanWrittenOff", mock.Anything, "loan-id-1").Return(true, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindBusinessRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			mockLedgerRepo := new(MockLedgerRepo)
+			mockLockManager := new(MockLockManager)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo, mockLedgerRepo, mockLockManager)
+
+			service := &LoanService{
+				loanRepo:        mockLoanRepo,
+				loanPaymentRepo: mockLoanPaymentRepo,
+				ledgerRepo:      mockLedgerRepo,
+				txManager:       new(MockTxManager),
+				lockManager:     mockLockManager,
+				clock:           newTestClock(),
+			}
+			lp, _, err := service.WaiveInstallment(context.Background(), "loan-id-1", tt.loanPaymentID, 0)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, lp)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, constant.LoanPaymentStatus(constant.LoanPaymentStatusWaived), lp.Status)
+			}
+
+			mockLoanRepo.AssertExpectations(t)
+			mockLoanPaymentRepo.AssertExpectations(t)
+			mockLedgerRepo.AssertExpectations(t)
+			mockLockManager.AssertExpectations(t)
+		})
+	}
+}
+
+// idr returns an amount in the default currency
+func idr(amount int64) lib.Money {
+	return lib.NewMoneyFromInt(amount, lib.DefaultCurrency)
+}
+
+func idrRef(amount int64) *lib.Money {
+	m := idr(amount)
+	return &m
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+type MigrationService struct {
+	migrations    []lib.Migration
+	migrationRepo repository.MigrationRepo
+	txManager     repository.TxManager
+	jobLocker     repository.JobLocker
+	clock         lib.Clock
+}
+
+func NewMigrationService(
+	migrations []lib.Migration,
+	migrationRepo repository.MigrationRepo,
+	txManager repository.TxManager,
+	jobLocker repository.JobLocker,
+	clock lib.Clock,
+) *MigrationService {
+	return &MigrationService{
+		migrations:    migrations,
+		migrationRepo: migrationRepo,
+		txManager:     txManager,
+		jobLocker:     jobLocker,
+		clock:         clock,
+	}
+}
+
+// Up applies the pending migrations, oldest first, each in its own transaction. It stops at the first one that fails,
+// keeping the ones applied before it.
+func (s *MigrationService) Up(ctx context.Context) ([]lib.Migration, error) {
+	applied := make([]lib.Migration, 0)
+	err := s.exclusive(ctx, func(ctx context.Context) error {
+		done, err := s.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range s.migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+				migrationRepo := s.migrationRepo.WithTx(tx)
+				if err := migrationRepo.Exec(ctx, m.Up); err != nil {
+					return err
+				}
+				return migrationRepo.Create(ctx, &model.SchemaMigration{
+					Version:   m.Version,
+					Name:      m.Name,
+					AppliedAt: s.clock.Now(),
+				})
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, each in its own transaction
+func (s *MigrationService) Down(ctx context.Context, steps int) ([]lib.Migration, error) {
+	if steps < 1 {
+		return nil, lib.NewValidationError(constant.ErrCodeInvalidRequest, "at least one migration has to be reverted")
+	}
+
+	reverted := make([]lib.Migration, 0)
+	err := s.exclusive(ctx, func(ctx context.Context) error {
+		done, err := s.applied(ctx)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(done))
+		for version := range done {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if len(versions) > steps {
+			versions = versions[:steps]
+		}
+
+		for _, version := range versions {
+			m, ok := s.migration(version)
+			if !ok {
+				return lib.NewNotFoundError(constant.ErrCodeMigrationNotFound, "migration %d_%s is applied but not part of this build", version, done[version].Name)
+			}
+			err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+				migrationRepo := s.migrationRepo.WithTx(tx)
+				if err := migrationRepo.Exec(ctx, m.Down); err != nil {
+					return err
+				}
+				return migrationRepo.Delete(ctx, m.Version)
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration by version, with when it was applied. Migrations recorded in the database but
+// missing from this build are listed too.
+func (s *MigrationService) Status(ctx context.Context) ([]*model.MigrationStatus, error) {
+	done, err := s.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*model.MigrationStatus, 0, len(s.migrations))
+	for _, m := range s.migrations {
+		status := &model.MigrationStatus{Version: m.Version, Name: m.Name}
+		if applied, ok := done[m.Version]; ok {
+			status.AppliedAt = &applied.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, applied := range done {
+		if _, ok := s.migration(version); !ok {
+			statuses = append(statuses, &model.MigrationStatus{
+				Version:   version,
+				Name:      applied.Name,
+				AppliedAt: &applied.AppliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// exclusive runs fn while no other process is migrating the same database
+func (s *MigrationService) exclusive(ctx context.Context, fn func(ctx context.Context) error) error {
+	acquired, err := s.jobLocker.RunExclusive(ctx, constant.JobNameSchemaMigration, fn)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return lib.NewConflictError(constant.ErrCodeJobAlreadyRunning, "another migration is already running")
+	}
+	return nil
+}
+
+func (s *MigrationService) applied(ctx context.Context) (map[int64]*model.SchemaMigration, error) {
+	err := s.migrationRepo.CreateTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := s.migrationRepo.ListApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int64]*model.SchemaMigration, len(ms))
+	for _, m := range ms {
+		done[m.Version] = m
+	}
+	return done, nil
+}
+
+func (s *MigrationService) migration(version int64) (lib.Migration, bool) {
+	for _, m := range s.migrations {
+		if m.Version == version {
+			return m, true
+		}
+	}
+	return lib.Migration{}, false
+}
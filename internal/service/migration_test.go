@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockMigrationRepo is a mock implementation of repository.MigrationRepo
+type MockMigrationRepo struct {
+	mock.Mock
+}
+
+func (m *MockMigrationRepo) WithTx(tx *gorm.DB) repository.MigrationRepo {
+	args := m.Called(tx)
+	return args.Get(0).(repository.MigrationRepo)
+}
+
+func (m *MockMigrationRepo) CreateTable(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockMigrationRepo) ListApplied(ctx context.Context) ([]*model.SchemaMigration, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.SchemaMigration), args.Error(1)
+}
+
+func (m *MockMigrationRepo) Exec(ctx context.Context, sql string) error {
+	args := m.Called(ctx, sql)
+	return args.Error(0)
+}
+
+func (m *MockMigrationRepo) Create(ctx context.Context, sm *model.SchemaMigration) error {
+	args := m.Called(ctx, sm)
+	return args.Error(0)
+}
+
+func (m *MockMigrationRepo) Delete(ctx context.Context, version int64) error {
+	args := m.Called(ctx, version)
+	return args.Error(0)
+}
+
+var testMigrations = []lib.Migration{
+	{Version: 1, Name: "init", Up: "create table a", Down: "drop table a"},
+	{Version: 2, Name: "seed", Up: "insert into a", Down: "delete from a"},
+	{Version: 3, Name: "add_index", Up: "create index", Down: "drop index"},
+}
+
+func appliedMigrations(versions ...int64) []*model.SchemaMigration {
+	ms := make([]*model.SchemaMigration, len(versions))
+	for i, v := range versions {
+		ms[i] = &model.SchemaMigration{Version: v, Name: testMigrations[v-1].Name, AppliedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)}
+	}
+	return ms
+}
+
+func TestMigrationService_Up(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockSetup        func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker)
+		expectedError    bool
+		expectedErrKind  lib.ErrorKind
+		expectedVersions []int64
+	}{
+		{
+			name: "Applies Pending Migrations In Order",
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, "SCHEMA_MIGRATION").Return(true, nil)
+				mockMigrationRepo.On("CreateTable", mock.Anything).Return(nil)
+				mockMigrationRepo.On("ListApplied", mock.Anything).Return(appliedMigrations(1), nil)
+				mockMigrationRepo.On("WithTx", mock.Anything).Return(mockMigrationRepo)
+				mockMigrationRepo.On("Exec", mock.Anything, "insert into a").Return(nil).Once()
+				mockMigrationRepo.On("Create", mock.Anything, mock.MatchedBy(func(sm *model.SchemaMigration) bool {
+					return sm.Version == 2 && sm.Name == "seed" && sm.AppliedAt.Equal(newTestClock().Now())
+				})).Return(nil).Once()
+				mockMigrationRepo.On("Exec", mock.Anything, "create index").Return(nil).Once()
+				mockMigrationRepo.On("Create", mock.Anything, mock.MatchedBy(func(sm *model.SchemaMigration) bool {
+					return sm.Version == 3
+				})).Return(nil).Once()
+			},
+			expectedVersions: []int64{2, 3},
+		},
+		{
+			name: "Nothing Pending",
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, "SCHEMA_MIGRATION").Return(true, nil)
+				mockMigrationRepo.On("CreateTable", mock.Anything).Return(nil)
+				mockMigrationRepo.On("ListApplied", mock.Anything).Return(appliedMigrations(1, 2, 3), nil)
+			},
+			expectedVersions: []int64{},
+		},
+		{
+			name: "Stops At The Failing Migration",
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, "SCHEMA_MIGRATION").Return(true, nil)
+				mockMigrationRepo.On("CreateTable", mock.Anything).Return(nil)
+				mockMigrationRepo.On("ListApplied", mock.Anything).Return(appliedMigrations(1), nil)
+				mockMigrationRepo.On("WithTx", mock.Anything).Return(mockMigrationRepo)
+				mockMigrationRepo.On("Exec", mock.Anything, "insert into a").Return(nil).Once()
+				mockMigrationRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+				mockMigrationRepo.On("Exec", mock.Anything, "create index").Return(errors.New("syntax error")).Once()
+			},
+			expectedError:    true,
+			expectedVersions: []int64{2},
+		},
+		{
+			name: "Another Migration Is Running",
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, "SCHEMA_MIGRATION").Return(false, nil)
+			},
+			expectedError:    true,
+			expectedErrKind:  lib.ErrorKindConflict,
+			expectedVersions: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMigrationRepo := new(MockMigrationRepo)
+			mockJobLocker := new(MockJobLocker)
+			tt.mockSetup(mockMigrationRepo, mockJobLocker)
+
+			service := NewMigrationService(testMigrations, mockMigrationRepo, new(MockTxManager), mockJobLocker, newTestClock())
+			applied, err := service.Up(context.Background())
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			versions := make([]int64, len(applied))
+			for i, m := range applied {
+				versions[i] = m.Version
+			}
+			assert.Equal(t, tt.expectedVersions, versions)
+			mockMigrationRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMigrationService_Down(t *testing.T) {
+	tests := []struct {
+		name             string
+		steps            int
+		mockSetup        func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker)
+		expectedError    bool
+		expectedErrKind  lib.ErrorKind
+		expectedVersions []int64
+	}{
+		{
+			name:  "Reverts The Newest Migrations",
+			steps: 2,
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, "SCHEMA_MIGRATION").Return(true, nil)
+				mockMigrationRepo.On("CreateTable", mock.Anything).Return(nil)
+				mockMigrationRepo.On("ListApplied", mock.Anything).Return(appliedMigrations(1, 2, 3), nil)
+				mockMigrationRepo.On("WithTx", mock.Anything).Return(mockMigrationRepo)
+				mockMigrationRepo.On("Exec", mock.Anything, "drop index").Return(nil).Once()
+				mockMigrationRepo.On("Delete", mock.Anything, int64(3)).Return(nil).Once()
+				mockMigrationRepo.On("Exec", mock.Anything, "delete from a").Return(nil).Once()
+				mockMigrationRepo.On("Delete", mock.Anything, int64(2)).Return(nil).Once()
+			},
+			expectedVersions: []int64{3, 2},
+		},
+		{
+			name:  "More Steps Than Applied",
+			steps: 5,
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, "SCHEMA_MIGRATION").Return(true, nil)
+				mockMigrationRepo.On("CreateTable", mock.Anything).Return(nil)
+				mockMigrationRepo.On("ListApplied", mock.Anything).Return(appliedMigrations(1), nil)
+				mockMigrationRepo.On("WithTx", mock.Anything).Return(mockMigrationRepo)
+				mockMigrationRepo.On("Exec", mock.Anything, "drop table a").Return(nil).Once()
+				mockMigrationRepo.On("Delete", mock.Anything, int64(1)).Return(nil).Once()
+			},
+			expectedVersions: []int64{1},
+		},
+		{
+			name:  "Applied Migration Not In Build",
+			steps: 1,
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, "SCHEMA_MIGRATION").Return(true, nil)
+				mockMigrationRepo.On("CreateTable", mock.Anything).Return(nil)
+				mockMigrationRepo.On("ListApplied", mock.Anything).Return([]*model.SchemaMigration{
+					{Version: 1, Name: "init"},
+					{Version: 9, Name: "from_a_newer_build"},
+				}, nil)
+			},
+			expectedError:    true,
+			expectedErrKind:  lib.ErrorKindNotFound,
+			expectedVersions: []int64{},
+		},
+		{
+			name:  "No Steps",
+			steps: 0,
+			mockSetup: func(mockMigrationRepo *MockMigrationRepo, mockJobLocker *MockJobLocker) {
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMigrationRepo := new(MockMigrationRepo)
+			mockJobLocker := new(MockJobLocker)
+			tt.mockSetup(mockMigrationRepo, mockJobLocker)
+
+			service := NewMigrationService(testMigrations, mockMigrationRepo, new(MockTxManager), mockJobLocker, newTestClock())
+			reverted, err := service.Down(context.Background(), tt.steps)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectedVersions != nil {
+				versions := make([]int64, len(reverted))
+				for i, m := range reverted {
+					versions[i] = m.Version
+				}
+				assert.Equal(t, tt.expectedVersions, versions)
+			}
+			mockMigrationRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMigrationService_Status(t *testing.T) {
+	mockMigrationRepo := new(MockMigrationRepo)
+	mockMigrationRepo.On("CreateTable", mock.Anything).Return(nil)
+	mockMigrationRepo.On("ListApplied", mock.Anything).Return(append(appliedMigrations(1), &model.SchemaMigration{
+		Version: 9, Name: "from_a_newer_build",
+	}), nil)
+
+	service := NewMigrationService(testMigrations, mockMigrationRepo, new(MockTxManager), new(MockJobLocker), newTestClock())
+	statuses, err := service.Status(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 4)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.Nil(t, statuses[2].AppliedAt)
+	assert.Equal(t, int64(9), statuses[3].Version)
+	assert.True(t, statuses[3].Missing)
+	mockMigrationRepo.AssertExpectations(t)
+}
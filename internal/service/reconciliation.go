@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+const reconciliationBatchSize = 100
+
+type ReconciliationService struct {
+	loanRepo        repository.LoanRepo
+	loanPaymentRepo repository.LoanPaymentRepo
+	txManager       repository.TxManager
+}
+
+func NewReconciliationService(
+	loanRepo repository.LoanRepo,
+	loanPaymentRepo repository.LoanPaymentRepo,
+	txManager repository.TxManager,
+) *ReconciliationService {
+	return &ReconciliationService{
+		loanRepo:        loanRepo,
+		loanPaymentRepo: loanPaymentRepo,
+		txManager:       txManager,
+	}
+}
+
+// LoanBalanceDrift is a loan whose stored running totals did not match its installments. Each field reads
+// "field: stored -> actual".
+type LoanBalanceDrift struct {
+	LoanID string   `json:"loan_id"`
+	Fields []string `json:"fields"`
+}
+
+// ReconciliationResult summarises a reconciliation run. PrincipalRecorded counts the installments that had no principal
+// recorded and were given the one of their schedule.
+type ReconciliationResult struct {
+	Loans             int                 `json:"loans"`
+	Drifted           []*LoanBalanceDrift `json:"drifted"`
+	Fixed             int                 `json:"fixed"`
+	PrincipalRecorded int                 `json:"principal_recorded"`
+}
+
+// ReconcileLoanBalances recomputes the running totals of every loan from its installments and reports the loans whose
+// stored totals drifted from them. Installments created before their principal was recorded get the principal of their
+// schedule first. Drifted totals are corrected and missing principal recorded unless dryRun is set.
+func (s *ReconciliationService) ReconcileLoanBalances(ctx context.Context, dryRun bool) (*ReconciliationResult, error) {
+	result := &ReconciliationResult{
+		Drifted: make([]*LoanBalanceDrift, 0),
+	}
+	afterID := ""
+	for {
+		loans, err := s.loanRepo.FindAfter(ctx, afterID, reconciliationBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range loans {
+			fields, recorded, err := s.reconcileLoan(ctx, l.ID, dryRun)
+			if err != nil {
+				return nil, err
+			}
+			result.Loans++
+			result.PrincipalRecorded += recorded
+			if len(fields) == 0 {
+				continue
+			}
+			result.Drifted = append(result.Drifted, &LoanBalanceDrift{LoanID: l.ID, Fields: fields})
+			if !dryRun {
+				result.Fixed++
+			}
+		}
+		if len(loans) < reconciliationBatchSize {
+			return result, nil
+		}
+		afterID = loans[len(loans)-1].ID
+	}
+}
+
+// reconcileLoan compares the stored totals of a loan with its installments while holding the loan, so payments made
+// meanwhile are not mistaken for drift, and stores the missing principal of its installments and the actual totals
+// unless dryRun is set. It returns the drifted fields and how many installments had their principal recorded.
+func (s *ReconciliationService) reconcileLoan(ctx context.Context, loanID string, dryRun bool) ([]string, int, error) {
+	var drift []string
+	var recorded int
+	err := s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		loanRepo := s.loanRepo.WithTx(tx)
+		loanPaymentRepo := s.loanPaymentRepo.WithTx(tx)
+		l, err := lockLoan(ctx, loanRepo, loanID, 0)
+		if err != nil {
+			return err
+		}
+		lps, err := loanPaymentRepo.Find(ctx, model.LoanPayment{LoanID: loanID})
+		if err != nil {
+			return err
+		}
+
+		filled, err := l.FillInstallmentPrincipal(lps)
+		if err != nil {
+			return err
+		}
+		recorded = len(filled)
+		if !dryRun {
+			if err := recordPrincipal(ctx, loanPaymentRepo, filled); err != nil {
+				return err
+			}
+		}
+
+		actual := *l
+		if err := actual.SetBalances(lps); err != nil {
+			return err
+		}
+		drift = l.BalanceDrift(actual)
+		if len(drift) == 0 || dryRun {
+			return nil
+		}
+		updated, err := loanRepo.UpdateBalances(ctx, &actual)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return lib.NewConflictError(constant.ErrCodeLoanVersionMismatch, "loan changed while it was being reconciled")
+		}
+		return nil
+	})
+	return drift, recorded, err
+}
+
+// recordPrincipal stores the principal filled in on installments that had none
+func recordPrincipal(ctx context.Context, loanPaymentRepo repository.LoanPaymentRepo, lps []*model.LoanPayment) error {
+	for _, lp := range lps {
+		recorded, err := loanPaymentRepo.RecordPrincipal(ctx, lp)
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			return lib.NewConflictError(constant.ErrCodeLoanVersionMismatch, "installment %s changed while it was being reconciled", lp.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReconciliationService_ReconcileLoanBalances(t *testing.T) {
+	dueDate := newTestClock().Now().AddDate(0, 0, 7)
+
+	// loan-id-1 is in step with its installments, loan-id-2 never had its overdue installment and late fee counted and
+	// its installment was created before its principal was recorded
+	setup := func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+		mockLoanRepo.On("FindAfter", mock.Anything, "", reconciliationBatchSize).Return([]*model.Loan{
+			{ID: "loan-id-1"},
+			{ID: "loan-id-2"},
+		}, nil)
+		mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+		mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+		mockLoanRepo.On("GetForUpdate", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+			return l.ID == "loan-id-1"
+		})).Run(func(args mock.Arguments) {
+			l := args.Get(1).(*model.Loan)
+			l.OutstandingPrincipal = idr(100_000)
+			l.OutstandingTotal = idr(110_000)
+			l.PaidTotal = idr(0)
+			l.NextDueDate = &dueDate
+		}).Return(nil)
+		mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-1"}).Return([]*model.LoanPayment{
+			{ID: "lp-id-1", Amount: idr(110_000), Principal: idrRef(100_000), DueDate: dueDate, Status: constant.LoanPaymentStatusUnpaid},
+		}, nil)
+		mockLoanRepo.On("GetForUpdate", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+			return l.ID == "loan-id-2"
+		})).Run(func(args mock.Arguments) {
+			// 5.000.000 over 50 weeks repays 100.000 principal a week
+			l := args.Get(1).(*model.Loan)
+			l.Principal = idr(5_000_000)
+			l.AnnualInterestRate = decimal.NewFromInt(10)
+			l.InterestMethod = constant.InterestMethodFlat
+			l.Period = 50
+			l.PeriodUnit = constant.PeriodUnitWeek
+		}).Return(nil)
+		mockLoanPaymentRepo.On("Find", mock.Anything, model.LoanPayment{LoanID: "loan-id-2"}).Return([]*model.LoanPayment{
+			{ID: "lp-id-2", Amount: idr(110_000), LateFee: idr(25_000), DueDate: dueDate, Status: constant.LoanPaymentStatusOverdue},
+		}, nil)
+	}
+
+	tests := []struct {
+		name              string
+		dryRun            bool
+		mockSetup         func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo)
+		expectedError     bool
+		expectedDrifted   []string
+		expectedFixed     int
+		expectedPrincipal int
+	}{
+		{
+			name: "Corrects Drifted Loans",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				setup(mockLoanRepo, mockLoanPaymentRepo)
+				mockLoanPaymentRepo.On("RecordPrincipal", mock.Anything, mock.MatchedBy(func(lp *model.LoanPayment) bool {
+					return lp.ID == "lp-id-2" && lp.Principal.Equal(idr(100_000))
+				})).Return(true, nil).Once()
+				mockLoanRepo.On("UpdateBalances", mock.Anything, mock.MatchedBy(func(l *model.Loan) bool {
+					return l.ID == "loan-id-2" &&
+						l.OutstandingPrincipal.Equal(idr(100_000)) &&
+						l.OutstandingTotal.Equal(idr(135_000)) &&
+						l.InstallmentsOverdue == 1 &&
+						l.NextDueDate == nil
+				})).Return(true, nil).Once()
+			},
+			expectedDrifted:   []string{"loan-id-2"},
+			expectedFixed:     1,
+			expectedPrincipal: 1,
+		},
+		{
+			name:   "Dry Run Only Reports",
+			dryRun: true,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				setup(mockLoanRepo, mockLoanPaymentRepo)
+			},
+			expectedDrifted:   []string{"loan-id-2"},
+			expectedFixed:     0,
+			expectedPrincipal: 1,
+		},
+		{
+			name: "Error Finding Loans",
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo) {
+				mockLoanRepo.On("FindAfter", mock.Anything, "", reconciliationBatchSize).Return([]*model.Loan{}, errors.New("database error"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo)
+
+			service := NewReconciliationService(mockLoanRepo, mockLoanPaymentRepo, new(MockTxManager))
+			result, err := service.ReconcileLoanBalances(context.Background(), tt.dryRun)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, result.Loans)
+				drifted := make([]string, len(result.Drifted))
+				for i, d := range result.Drifted {
+					drifted[i] = d.LoanID
+				}
+				assert.Equal(t, tt.expectedDrifted, drifted)
+				assert.Len(t, result.Drifted[0].Fields, 3)
+				assert.Equal(t, tt.expectedFixed, result.Fixed)
+				assert.Equal(t, tt.expectedPrincipal, result.PrincipalRecorded)
+			}
+
+			mockLoanRepo.AssertExpectations(t)
+			mockLoanPaymentRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/ramabmtr/billing-engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// SimulationConfig tells the simulation when the daily billing job is scheduled, in UTC
+type SimulationConfig struct {
+	DailyRunHour   int
+	DailyRunMinute int
+}
+
+// SimulationService moves the simulated clock shared by the other services and runs the jobs the worker would have
+// run in the meantime. It only exists when simulation mode is enabled. The clock is read from memory, so only one
+// server may run a simulation at a time, and every move is stored so a restarted server resumes from it.
+type SimulationService struct {
+	clock      *lib.FakeClock
+	clockRepo  repository.SimulationClockRepo
+	jobLocker  repository.JobLocker
+	billingSvc *BillingService
+	cfg        SimulationConfig
+
+	// advances run one at a time so the clock never moves backwards
+	mu sync.Mutex
+}
+
+func NewSimulationService(
+	clock *lib.FakeClock,
+	clockRepo repository.SimulationClockRepo,
+	jobLocker repository.JobLocker,
+	billingSvc *BillingService,
+	cfg SimulationConfig,
+) *SimulationService {
+	return &SimulationService{
+		clock:      clock,
+		clockRepo:  clockRepo,
+		jobLocker:  jobLocker,
+		billingSvc: billingSvc,
+		cfg:        cfg,
+	}
+}
+
+// SimulationAdvanceResult lists the daily billing runs made while the clock was moved forward
+type SimulationAdvanceResult struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Runs        []*model.JobRun `json:"runs"`
+	SkippedRuns int             `json:"skipped_runs"`
+}
+
+func (s *SimulationService) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Serve runs serve for as long as it runs while holding the simulation lock, after putting the clock back to the stored
+// simulated time. A clock that was never stored starts at the time it holds. It fails with SIMULATION_RUNNING when
+// another server is already running a simulation on the same database.
+func (s *SimulationService) Serve(ctx context.Context, serve func(ctx context.Context) error) error {
+	acquired, err := s.jobLocker.RunExclusive(ctx, constant.JobNameSimulation, func(ctx context.Context) error {
+		if err := s.restore(ctx); err != nil {
+			return err
+		}
+		return serve(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return lib.NewConflictError(constant.ErrCodeSimulationRunning, "another server is already running a simulation")
+	}
+	return nil
+}
+
+// restore sets the clock to the stored simulated time, storing the time it holds when there is none
+func (s *SimulationService) restore(ctx context.Context) error {
+	c, err := s.clockRepo.Get(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.clockRepo.Save(ctx, s.clock.Now())
+	}
+	if err != nil {
+		return err
+	}
+	s.clock.Set(c.SimulatedAt)
+	return nil
+}
+
+// moveTo sets the clock and stores the time it was moved to
+func (s *SimulationService) moveTo(ctx context.Context, at time.Time) error {
+	s.clock.Set(at)
+	return s.clockRepo.Save(ctx, at)
+}
+
+// Advance moves the simulated clock forward by the given number of days. The clock stops at every daily billing
+// schedule on the way and runs the job there, so delinquency, late fees and reminders build up day by day as they
+// would in production. Days the job already succeeded on are skipped. When a run fails the clock is left on that
+// day and the error is returned. Every stop is stored before the job runs there.
+func (s *SimulationService) Advance(ctx context.Context, days int) (*SimulationAdvanceResult, error) {
+	if days <= 0 {
+		return nil, lib.NewValidationError(constant.ErrCodeInvalidRequest, "days must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := s.clock.Now()
+	to := from.AddDate(0, 0, days)
+	result := &SimulationAdvanceResult{
+		From: from,
+		To:   to,
+		Runs: []*model.JobRun{},
+	}
+
+	next := lib.NextDailyRun(from, s.cfg.DailyRunHour, s.cfg.DailyRunMinute)
+	for ; !next.After(to); next = next.AddDate(0, 0, 1) {
+		if err := s.moveTo(ctx, next); err != nil {
+			return nil, err
+		}
+		run, err := s.billingSvc.RunDailyBilling(ctx, constant.JobTriggerSimulated)
+		if e, ok := lib.AsError(err); ok && e.Code == constant.ErrCodeJobAlreadyCompleted {
+			result.SkippedRuns++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Runs = append(result.Runs, run)
+	}
+	if err := s.moveTo(ctx, to); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
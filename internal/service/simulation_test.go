@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ramabmtr/billing-engine/internal/constant"
+	"github.com/ramabmtr/billing-engine/internal/lib"
+	"github.com/ramabmtr/billing-engine/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockSimulationClockRepo is a mock implementation of repository.SimulationClockRepo
+type MockSimulationClockRepo struct {
+	mock.Mock
+}
+
+func (m *MockSimulationClockRepo) Get(ctx context.Context) (*model.SimulationClock, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*model.SimulationClock), args.Error(1)
+}
+
+func (m *MockSimulationClockRepo) Save(ctx context.Context, simulatedAt time.Time) error {
+	args := m.Called(ctx, simulatedAt)
+	return args.Error(0)
+}
+
+func TestSimulationService_Advance(t *testing.T) {
+	start := newTestClock().Now()
+	runAt := func(days int) time.Time {
+		return time.Date(start.Year(), start.Month(), start.Day()+days, 1, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name            string
+		days            int
+		mockSetup       func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+		clockSaveErr    error
+		expectedRuns    int
+		expectedSkipped int
+		expectedNow     time.Time
+	}{
+		{
+			name: "Runs Daily Billing On Every Day Passed",
+			days: 3,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+				mockJobRunRepo.On("HasSucceeded", mock.Anything, constant.JobNameDailyBilling, mock.Anything).Return(false, nil)
+				mockJobRunRepo.On("Create", mock.Anything, mock.MatchedBy(func(run *model.JobRun) bool {
+					return run.Trigger == constant.JobTriggerSimulated
+				})).Return(nil)
+				mockJobRunRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("FindUnpaidTimezones", mock.Anything).Return([]string{"UTC"}, nil)
+				mockLoanRepo.On("UpdateOverdueBalances", mock.Anything).Return(int64(0), nil)
+				// every run sees the clock at the scheduled time of its own day
+				for days := 1; days <= 3; days++ {
+					mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, "UTC", lib.TruncateToDate(runAt(days))).Return(int64(0), nil).Once()
+					mockLoanRepo.On("UpdateDaysPastDue", mock.Anything, runAt(days)).Return(int64(0), nil).Once()
+				}
+			},
+			expectedError: false,
+			expectedRuns:  3,
+			expectedNow:   start.AddDate(0, 0, 3),
+		},
+		{
+			name: "Skips Days Already Billed",
+			days: 2,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+				mockJobRunRepo.On("HasSucceeded", mock.Anything, constant.JobNameDailyBilling, lib.TruncateToDate(runAt(1))).Return(true, nil)
+				mockJobRunRepo.On("HasSucceeded", mock.Anything, constant.JobNameDailyBilling, lib.TruncateToDate(runAt(2))).Return(false, nil)
+				mockJobRunRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				mockJobRunRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("FindUnpaidTimezones", mock.Anything).Return([]string{"UTC"}, nil)
+				mockLoanRepo.On("UpdateOverdueBalances", mock.Anything).Return(int64(0), nil)
+				mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, "UTC", lib.TruncateToDate(runAt(2))).Return(int64(0), nil)
+				mockLoanRepo.On("UpdateDaysPastDue", mock.Anything, runAt(2)).Return(int64(0), nil)
+			},
+			expectedError:   false,
+			expectedRuns:    1,
+			expectedSkipped: 1,
+			expectedNow:     start.AddDate(0, 0, 2),
+		},
+		{
+			name: "Stops On The Day A Run Fails",
+			days: 5,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(true, nil)
+				mockJobRunRepo.On("HasSucceeded", mock.Anything, constant.JobNameDailyBilling, mock.Anything).Return(false, nil)
+				mockJobRunRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				mockJobRunRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+				mockLoanPaymentRepo.On("WithTx", mock.Anything).Return(mockLoanPaymentRepo)
+				mockLoanRepo.On("WithTx", mock.Anything).Return(mockLoanRepo)
+				mockLoanRepo.On("FindUnpaidTimezones", mock.Anything).Return([]string{"UTC"}, nil)
+				mockLoanRepo.On("UpdateOverdueBalances", mock.Anything).Return(int64(0), nil)
+				mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, "UTC", lib.TruncateToDate(runAt(1))).Return(int64(0), nil)
+				mockLoanRepo.On("UpdateDaysPastDue", mock.Anything, runAt(1)).Return(int64(0), nil)
+				mockLoanPaymentRepo.On("MarkOverdue", mock.Anything, "UTC", lib.TruncateToDate(runAt(2))).Return(int64(0), errors.New("connection reset"))
+			},
+			expectedError: true,
+			expectedNow:   runAt(2),
+		},
+		{
+			name: "Error Storing The Clock",
+			days: 1,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+			},
+			clockSaveErr:  errors.New("connection reset"),
+			expectedError: true,
+			expectedNow:   runAt(1),
+		},
+		{
+			name: "Daily Billing Already Running",
+			days: 1,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameDailyBilling).Return(false, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+			expectedNow:     runAt(1),
+		},
+		{
+			name: "Days Not Positive",
+			days: 0,
+			mockSetup: func(mockLoanRepo *MockLoanRepo, mockLoanPaymentRepo *MockLoanPaymentRepo, mockJobRunRepo *MockJobRunRepo, mockJobLocker *MockJobLocker) {
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindValidation,
+			expectedNow:     start,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoanRepo := new(MockLoanRepo)
+			mockLoanPaymentRepo := new(MockLoanPaymentRepo)
+			mockJobRunRepo := new(MockJobRunRepo)
+			mockJobLocker := new(MockJobLocker)
+			tt.mockSetup(mockLoanRepo, mockLoanPaymentRepo, mockJobRunRepo, mockJobLocker)
+			mockClockRepo := new(MockSimulationClockRepo)
+			mockClockRepo.On("Save", mock.Anything, mock.Anything).Return(tt.clockSaveErr)
+
+			clock := newTestClock()
+			billingSvc := NewBillingService(mockLoanRepo, mockLoanPaymentRepo, new(MockLedgerRepo), new(MockHolidayRepo), new(MockOutboxRepo), mockJobRunRepo, mockJobLocker, new(MockTxManager), clock, BillingConfig{})
+			service := NewSimulationService(clock, mockClockRepo, mockJobLocker, billingSvc, SimulationConfig{DailyRunHour: 1})
+			result, err := service.Advance(context.Background(), tt.days)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, result.Runs, tt.expectedRuns)
+				assert.Equal(t, tt.expectedSkipped, result.SkippedRuns)
+				assert.Equal(t, start, result.From)
+				assert.Equal(t, tt.expectedNow, result.To)
+			}
+			assert.Equal(t, tt.expectedNow, service.Now())
+			// the clock is stored wherever it stopped
+			if calls := mockClockRepo.Calls; len(calls) > 0 {
+				assert.Equal(t, tt.expectedNow, calls[len(calls)-1].Arguments.Get(1))
+			} else {
+				assert.Equal(t, start, tt.expectedNow)
+			}
+
+			mockLoanRepo.AssertExpectations(t)
+			mockLoanPaymentRepo.AssertExpectations(t)
+			mockJobRunRepo.AssertExpectations(t)
+			mockJobLocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSimulationService_Serve(t *testing.T) {
+	start := newTestClock().Now()
+	stored := start.AddDate(0, 0, 30)
+
+	tests := []struct {
+		name            string
+		mockSetup       func(mockClockRepo *MockSimulationClockRepo, mockJobLocker *MockJobLocker)
+		expectedError   bool
+		expectedErrKind lib.ErrorKind
+		expectedServed  bool
+		expectedNow     time.Time
+	}{
+		{
+			name: "Resumes From The Stored Clock",
+			mockSetup: func(mockClockRepo *MockSimulationClockRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameSimulation).Return(true, nil)
+				mockClockRepo.On("Get", mock.Anything).Return(&model.SimulationClock{ID: model.SimulationClockID, SimulatedAt: stored}, nil)
+			},
+			expectedError:  false,
+			expectedServed: true,
+			expectedNow:    stored,
+		},
+		{
+			name: "Stores The Clock On The First Start",
+			mockSetup: func(mockClockRepo *MockSimulationClockRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameSimulation).Return(true, nil)
+				mockClockRepo.On("Get", mock.Anything).Return(&model.SimulationClock{}, gorm.ErrRecordNotFound)
+				mockClockRepo.On("Save", mock.Anything, start).Return(nil).Once()
+			},
+			expectedError:  false,
+			expectedServed: true,
+			expectedNow:    start,
+		},
+		{
+			name: "Another Server Is Running A Simulation",
+			mockSetup: func(mockClockRepo *MockSimulationClockRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameSimulation).Return(false, nil)
+			},
+			expectedError:   true,
+			expectedErrKind: lib.ErrorKindConflict,
+			expectedServed:  false,
+			expectedNow:     start,
+		},
+		{
+			name: "Error Reading The Clock",
+			mockSetup: func(mockClockRepo *MockSimulationClockRepo, mockJobLocker *MockJobLocker) {
+				mockJobLocker.On("RunExclusive", mock.Anything, constant.JobNameSimulation).Return(true, nil)
+				mockClockRepo.On("Get", mock.Anything).Return(&model.SimulationClock{}, errors.New("database error"))
+			},
+			expectedError:  true,
+			expectedServed: false,
+			expectedNow:    start,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClockRepo := new(MockSimulationClockRepo)
+			mockJobLocker := new(MockJobLocker)
+			tt.mockSetup(mockClockRepo, mockJobLocker)
+
+			service := NewSimulationService(newTestClock(), mockClockRepo, mockJobLocker, nil, SimulationConfig{DailyRunHour: 1})
+			served := false
+			err := service.Serve(context.Background(), func(ctx context.Context) error {
+				served = true
+				return nil
+			})
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				if tt.expectedErrKind != "" {
+					assert.True(t, lib.IsErrorKind(err, tt.expectedErrKind))
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedServed, served)
+			assert.Equal(t, tt.expectedNow, service.Now())
+
+			mockClockRepo.AssertExpectations(t)
+			mockJobLocker.AssertExpectations(t)
+		})
+	}
+}
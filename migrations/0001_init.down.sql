@@ -0,0 +1,10 @@
+drop table if exists holidays;
+drop table if exists outbox_events;
+drop table if exists job_runs;
+drop table if exists interest_accruals;
+drop table if exists journal_lines;
+drop table if exists journal_entries;
+drop table if exists ledger_accounts;
+drop table if exists loan_payments;
+drop table if exists loans;
+drop table if exists borrowers;
@@ -0,0 +1,227 @@
+-- The schema as it was last created by GORM AutoMigrate. Tables that already exist are kept: they get the columns added
+-- since an older AutoMigrate created them and the data of older versions is backfilled as the AutoMigrate-based
+-- migration did, so any database set up before versioned migrations adopts this migration as it is.
+
+create table if not exists borrowers (
+    id            char(36) primary key,
+    name          varchar(100) not null,
+    phone         varchar(20)  not null default '',
+    email         varchar(100) not null default '',
+    national_id   varchar(32)  not null default '',
+    address       text         not null default '',
+    date_of_birth date                  default null,
+    timezone      varchar(64)  not null default '',
+    status        varchar(12)  not null default 'ACTIVE',
+    created_at    timestamp    not null default now(),
+    updated_at    timestamp    not null default now()
+);
+
+create table if not exists loans (
+    id                   char(36) primary key,
+    borrower_id          char(36)      not null,
+    currency             char(3)       not null default 'IDR',
+    principal            decimal(16,4) not null,
+    origination_fee      decimal(16,4) not null default 0,
+    origination_fee_mode varchar(10)   not null default 'DEDUCTED',
+    net_disbursement     decimal(16,4) not null default 0,
+    annual_interest_rate decimal(5,2)  not null,
+    interest_method      varchar(10)   not null default 'FLAT',
+    total_repayment      decimal(16,4) not null,
+    apr                  decimal(7,2)  not null default 0,
+    effective_rate       decimal(7,2)  not null default 0,
+    period               integer       not null,
+    period_unit          varchar(5)    not null,
+    days_past_due        integer       not null default 0,
+    timezone             varchar(64)   not null default 'UTC',
+    created_at           timestamp     not null default now(),
+    constraint fk_loans_borrower foreign key (borrower_id) references borrowers (id)
+);
+
+create table if not exists loan_payments (
+    id             char(36) primary key,
+    loan_id        char(36)      not null,
+    borrower_id    char(36)      not null,
+    currency       char(3)       not null default 'IDR',
+    amount         decimal(16,4) not null,
+    late_fee       decimal(16,4) not null default 0,
+    local_due_date date,
+    due_date       timestamp     not null,
+    status         varchar(10)   not null,
+    paid_at        timestamp              default null,
+    created_at     timestamp     not null default now(),
+    constraint fk_loan_payments_loan foreign key (loan_id) references loans (id),
+    constraint fk_loan_payments_borrower foreign key (borrower_id) references borrowers (id)
+);
+
+create table if not exists ledger_accounts (
+    code       varchar(30) primary key,
+    name       varchar(100) not null,
+    type       varchar(10)  not null,
+    created_at timestamp    not null default now()
+);
+
+create table if not exists journal_entries (
+    id             char(36) primary key,
+    type           varchar(20)  not null,
+    loan_id        char(36)     not null,
+    reversal_of_id char(36),
+    description    varchar(255) not null default '',
+    posted_at      timestamp    not null,
+    created_at     timestamp    not null default now(),
+    constraint fk_journal_entries_loan foreign key (loan_id) references loans (id)
+);
+create index if not exists idx_journal_entries_loan_id on journal_entries (loan_id);
+create unique index if not exists idx_journal_entries_reversal_of_id on journal_entries (reversal_of_id);
+
+create table if not exists journal_lines (
+    id               char(36) primary key,
+    journal_entry_id char(36)      not null,
+    account_code     varchar(30)   not null,
+    debit            decimal(16,4) not null default 0,
+    credit           decimal(16,4) not null default 0,
+    constraint fk_journal_entries_lines foreign key (journal_entry_id) references journal_entries (id),
+    constraint fk_journal_lines_account foreign key (account_code) references ledger_accounts (code)
+);
+create index if not exists idx_journal_lines_journal_entry_id on journal_lines (journal_entry_id);
+create index if not exists idx_journal_lines_account_code on journal_lines (account_code);
+
+create table if not exists interest_accruals (
+    id               char(36) primary key,
+    loan_id          char(36)      not null,
+    accrual_date     date          not null,
+    amount           decimal(16,4) not null,
+    journal_entry_id char(36),
+    created_at       timestamp     not null default now(),
+    constraint fk_interest_accruals_loan foreign key (loan_id) references loans (id)
+);
+create unique index if not exists idx_interest_accruals_loan_date on interest_accruals (loan_id, accrual_date);
+
+create table if not exists job_runs (
+    id            char(36) primary key,
+    job_name      varchar(50) not null,
+    business_date date        not null,
+    "trigger"     varchar(10) not null,
+    status        varchar(10) not null,
+    result        jsonb,
+    error         text        not null default '',
+    started_at    timestamp   not null,
+    finished_at   timestamp            default null
+);
+create index if not exists idx_job_runs_name_date on job_runs (job_name, business_date);
+
+create table if not exists outbox_events (
+    id           char(36) primary key,
+    type         varchar(50)  not null,
+    aggregate_id char(36)     not null,
+    dedup_key    varchar(100) not null,
+    payload      jsonb        not null,
+    created_at   timestamp    not null default now(),
+    published_at timestamp             default null
+);
+create index if not exists idx_outbox_events_aggregate_id on outbox_events (aggregate_id);
+create unique index if not exists idx_outbox_events_dedup_key on outbox_events (dedup_key);
+create index if not exists idx_outbox_events_published_at on outbox_events (published_at);
+
+create table if not exists holidays (
+    id           char(36) primary key,
+    country_code char(2)      not null,
+    date         date         not null,
+    name         varchar(100) not null,
+    created_at   timestamp    not null default now()
+);
+create unique index if not exists idx_holidays_country_date on holidays (country_code, date);
+
+-- Columns added to borrowers, loans and installments after their tables were first created. Rows that predate them get
+-- the defaults: loans in IDR and UTC with a flat rate and no origination fee.
+alter table borrowers
+    add column if not exists phone varchar(20) not null default '',
+    add column if not exists email varchar(100) not null default '',
+    add column if not exists national_id varchar(32) not null default '',
+    add column if not exists address text not null default '',
+    add column if not exists date_of_birth date default null,
+    add column if not exists timezone varchar(64) not null default '',
+    add column if not exists status varchar(12) not null default 'ACTIVE',
+    add column if not exists updated_at timestamp not null default now();
+
+alter table loans
+    add column if not exists currency char(3) not null default 'IDR',
+    add column if not exists origination_fee decimal(16,4) not null default 0,
+    add column if not exists origination_fee_mode varchar(10) not null default 'DEDUCTED',
+    add column if not exists net_disbursement decimal(16,4) not null default 0,
+    add column if not exists interest_method varchar(10) not null default 'FLAT',
+    add column if not exists apr decimal(7,2) not null default 0,
+    add column if not exists effective_rate decimal(7,2) not null default 0,
+    add column if not exists days_past_due integer not null default 0,
+    add column if not exists timezone varchar(64) not null default 'UTC';
+
+alter table loan_payments
+    add column if not exists currency char(3) not null default 'IDR',
+    add column if not exists late_fee decimal(16,4) not null default 0,
+    add column if not exists local_due_date date;
+
+-- loans created before origination fees paid out their whole principal
+update loans
+set net_disbursement = principal - origination_fee
+where net_disbursement = 0;
+
+-- the periodic rate at which the cashflows, one per period, are worth pv at the start, found by bisection like
+-- lib.CalculatePeriodicRate
+create or replace function pg_temp.periodic_rate(pv float8, cashflows float8[]) returns float8
+    language plpgsql
+    immutable as
+$$
+declare
+    lo       float8 := -0.99;
+    hi       float8 := 10;
+    mid      float8;
+    npv      float8;
+    discount float8;
+begin
+    if pv <= 0 or coalesce(array_length(cashflows, 1), 0) = 0 then
+        return 0;
+    end if;
+    for i in 1..200 loop
+        mid := (lo + hi) / 2;
+        npv := -pv;
+        discount := 1;
+        for j in 1..array_length(cashflows, 1) loop
+            discount := discount / (1 + mid);
+            -- later cashflows no longer change the value
+            exit when discount < 1e-300;
+            npv := npv + cashflows[j] * discount;
+        end loop;
+        if npv > 0 then
+            lo := mid;
+        else
+            hi := mid;
+        end if;
+    end loop;
+    return (lo + hi) / 2;
+end
+$$;
+
+-- loans created before the APR was stored get it from the amount received and their installments
+update loans l
+set apr            = round((r.rate * r.periods_per_year * 100)::numeric, 2),
+    effective_rate = round(((power(1 + r.rate, r.periods_per_year) - 1) * 100)::numeric, 2)
+from (
+    select l.id,
+           case l.period_unit when 'WEEK' then 52 else 12 end as periods_per_year,
+           pg_temp.periodic_rate((l.principal - l.origination_fee)::float8,
+                                 array_agg(lp.amount::float8 order by lp.due_date, lp.id)) as rate
+    from loans l
+             join loan_payments lp on lp.loan_id = l.id
+    where l.apr = 0
+      and l.annual_interest_rate > 0
+    group by l.id
+) r
+where r.id = l.id;
+
+drop function pg_temp.periodic_rate(float8, float8[]);
+
+-- installments created before local due dates fell due at the exact moment of disbursement; their loans are in UTC,
+-- so they are due by the end of that UTC day
+update loan_payments
+set local_due_date = due_date::date,
+    due_date       = due_date::date + interval '1 day'
+where local_due_date is null;
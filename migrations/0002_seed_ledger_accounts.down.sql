@@ -0,0 +1,4 @@
+-- Fails while journal lines still post to the accounts
+delete from ledger_accounts
+where code in ('LOAN_RECEIVABLE', 'INTEREST_RECEIVABLE', 'CASH', 'INTEREST_INCOME', 'FEE_INCOME', 'SUSPENSE',
+               'LOAN_LOSS_EXPENSE');
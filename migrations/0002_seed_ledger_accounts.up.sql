@@ -0,0 +1,10 @@
+-- The chart of accounts. Accounts that already exist are kept as they are.
+insert into ledger_accounts (code, name, type)
+values ('LOAN_RECEIVABLE', 'Loan Receivable', 'ASSET'),
+       ('INTEREST_RECEIVABLE', 'Interest Receivable', 'ASSET'),
+       ('CASH', 'Cash', 'ASSET'),
+       ('INTEREST_INCOME', 'Interest Income', 'INCOME'),
+       ('FEE_INCOME', 'Fee Income', 'INCOME'),
+       ('SUSPENSE', 'Suspense', 'LIABILITY'),
+       ('LOAN_LOSS_EXPENSE', 'Loan Loss Expense', 'EXPENSE')
+on conflict (code) do nothing;
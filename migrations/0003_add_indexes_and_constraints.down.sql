@@ -0,0 +1,35 @@
+alter table interest_accruals drop constraint if exists chk_interest_accruals_amount;
+alter table journal_lines drop constraint if exists chk_journal_lines_side;
+alter table ledger_accounts drop constraint if exists chk_ledger_accounts_type;
+
+alter table loan_payments
+    drop constraint if exists chk_loan_payments_currency,
+    drop constraint if exists chk_loan_payments_amount,
+    drop constraint if exists chk_loan_payments_late_fee,
+    drop constraint if exists chk_loan_payments_status,
+    drop constraint if exists chk_loan_payments_paid_at;
+
+alter table loans
+    drop constraint if exists chk_loans_currency,
+    drop constraint if exists chk_loans_principal,
+    drop constraint if exists chk_loans_origination_fee,
+    drop constraint if exists chk_loans_net_disbursement,
+    drop constraint if exists chk_loans_total_repayment,
+    drop constraint if exists chk_loans_annual_interest_rate,
+    drop constraint if exists chk_loans_period,
+    drop constraint if exists chk_loans_period_unit,
+    drop constraint if exists chk_loans_interest_method,
+    drop constraint if exists chk_loans_origination_fee_mode,
+    drop constraint if exists chk_loans_days_past_due;
+
+alter table borrowers drop constraint if exists chk_borrowers_status;
+
+alter table interest_accruals drop constraint if exists fk_interest_accruals_journal_entry;
+alter table journal_entries drop constraint if exists fk_journal_entries_reversal_of;
+
+drop index if exists idx_borrowers_created_at;
+drop index if exists idx_borrowers_name_id;
+drop index if exists idx_loans_borrower_id;
+drop index if exists idx_loan_payments_status_due_date;
+drop index if exists idx_loan_payments_borrower_status_due_date;
+drop index if exists idx_loan_payments_loan_status_due_date;
@@ -0,0 +1,50 @@
+-- Indexes for the installment lookups by loan, by borrower and by the daily billing run
+create index if not exists idx_loan_payments_loan_status_due_date on loan_payments (loan_id, status, due_date);
+create index if not exists idx_loan_payments_borrower_status_due_date on loan_payments (borrower_id, status, due_date);
+create index if not exists idx_loan_payments_status_due_date on loan_payments (status, due_date);
+
+-- Loans of a borrower in id order, and the borrower list in name and creation order
+create index if not exists idx_loans_borrower_id on loans (borrower_id, id);
+create index if not exists idx_borrowers_name_id on borrowers (name, id);
+create index if not exists idx_borrowers_created_at on borrowers (created_at);
+
+-- References GORM did not declare. An accrual is stored before its journal entry in the same transaction, so its
+-- reference is checked on commit.
+alter table journal_entries
+    add constraint fk_journal_entries_reversal_of foreign key (reversal_of_id) references journal_entries (id);
+alter table interest_accruals
+    add constraint fk_interest_accruals_journal_entry foreign key (journal_entry_id) references journal_entries (id)
+        deferrable initially deferred;
+
+alter table borrowers
+    add constraint chk_borrowers_status check (status in ('ACTIVE', 'INACTIVE', 'BLACKLISTED'));
+
+alter table loans
+    add constraint chk_loans_currency check (currency ~ '^[A-Z]{3}$'),
+    add constraint chk_loans_principal check (principal > 0),
+    add constraint chk_loans_origination_fee check (origination_fee >= 0),
+    add constraint chk_loans_net_disbursement check (net_disbursement > 0 and net_disbursement <= principal),
+    add constraint chk_loans_total_repayment check (total_repayment > 0),
+    add constraint chk_loans_annual_interest_rate check (annual_interest_rate >= 0),
+    add constraint chk_loans_period check (period > 0),
+    add constraint chk_loans_period_unit check (period_unit in ('WEEK', 'MONTH')),
+    add constraint chk_loans_interest_method check (interest_method in ('FLAT', 'ANNUITY')),
+    add constraint chk_loans_origination_fee_mode check (origination_fee_mode in ('DEDUCTED', 'FINANCED')),
+    add constraint chk_loans_days_past_due check (days_past_due >= 0);
+
+alter table loan_payments
+    add constraint chk_loan_payments_currency check (currency ~ '^[A-Z]{3}$'),
+    add constraint chk_loan_payments_amount check (amount > 0),
+    add constraint chk_loan_payments_late_fee check (late_fee >= 0),
+    add constraint chk_loan_payments_status check (status in ('UNPAID', 'OVERDUE', 'PAID', 'WAIVED', 'CANCELLED')),
+    add constraint chk_loan_payments_paid_at check (status <> 'PAID' or paid_at is not null);
+
+alter table ledger_accounts
+    add constraint chk_ledger_accounts_type check (type in ('ASSET', 'LIABILITY', 'INCOME', 'EXPENSE'));
+
+-- A line is either a debit or a credit, never both or neither
+alter table journal_lines
+    add constraint chk_journal_lines_side check ((debit > 0 and credit = 0) or (debit = 0 and credit > 0));
+
+alter table interest_accruals
+    add constraint chk_interest_accruals_amount check (amount >= 0);
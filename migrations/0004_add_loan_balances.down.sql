@@ -0,0 +1,13 @@
+alter table loans
+    drop constraint if exists chk_loans_outstanding_principal,
+    drop constraint if exists chk_loans_outstanding_total,
+    drop constraint if exists chk_loans_paid_total,
+    drop constraint if exists chk_loans_installments_overdue,
+    drop column if exists outstanding_principal,
+    drop column if exists outstanding_total,
+    drop column if exists paid_total,
+    drop column if exists next_due_date,
+    drop column if exists installments_overdue;
+
+alter table loan_payments
+    drop column if exists principal;
@@ -0,0 +1,36 @@
+-- Running totals of the installments of each loan, kept on the loan so reads do not add the installments up
+alter table loans
+    add column if not exists outstanding_principal decimal(16,4) not null default 0,
+    add column if not exists outstanding_total decimal(16,4) not null default 0,
+    add column if not exists paid_total decimal(16,4) not null default 0,
+    add column if not exists next_due_date timestamp default null,
+    add column if not exists installments_overdue integer not null default 0;
+
+-- the part of each installment that repays principal, null for installments created before it was recorded until the
+-- reconcile command records the principal of their schedule
+alter table loan_payments
+    add column if not exists principal decimal(16,4) default null;
+
+update loans l
+set outstanding_principal = b.outstanding_principal,
+    outstanding_total     = b.outstanding_total,
+    paid_total            = b.paid_total,
+    next_due_date         = b.next_due_date,
+    installments_overdue  = b.installments_overdue
+from (
+    select loan_id,
+           coalesce(sum(principal) filter (where status in ('UNPAID', 'OVERDUE')), 0)         as outstanding_principal,
+           coalesce(sum(amount + late_fee) filter (where status in ('UNPAID', 'OVERDUE')), 0) as outstanding_total,
+           coalesce(sum(amount + late_fee) filter (where status = 'PAID'), 0)                 as paid_total,
+           min(due_date) filter (where status = 'UNPAID')                                      as next_due_date,
+           count(*) filter (where status = 'OVERDUE')                                          as installments_overdue
+    from loan_payments
+    group by loan_id
+) b
+where b.loan_id = l.id;
+
+alter table loans
+    add constraint chk_loans_outstanding_principal check (outstanding_principal >= 0),
+    add constraint chk_loans_outstanding_total check (outstanding_total >= 0),
+    add constraint chk_loans_paid_total check (paid_total >= 0),
+    add constraint chk_loans_installments_overdue check (installments_overdue >= 0);
@@ -0,0 +1,3 @@
+alter table loans
+    drop constraint if exists chk_loans_version,
+    drop column if exists version;
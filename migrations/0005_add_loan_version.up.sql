@@ -0,0 +1,6 @@
+-- Every change to a loan moves it to the next version, so concurrent changes can be detected
+alter table loans
+    add column if not exists version bigint not null default 1;
+
+alter table loans
+    add constraint chk_loans_version check (version >= 1);
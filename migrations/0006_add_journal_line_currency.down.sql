@@ -0,0 +1,3 @@
+alter table journal_lines
+    drop constraint if exists chk_journal_lines_currency,
+    drop column if exists currency;
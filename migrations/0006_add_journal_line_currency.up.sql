@@ -0,0 +1,14 @@
+-- Journal lines carry the currency of their amounts, so the trial balance never adds amounts of different currencies
+alter table journal_lines
+    add column if not exists currency char(3) not null default 'IDR';
+
+-- every entry so far was posted for a loan, in the currency of that loan
+update journal_lines jl
+set currency = l.currency
+from journal_entries je
+         join loans l on l.id = je.loan_id
+where je.id = jl.journal_entry_id
+  and jl.currency <> l.currency;
+
+alter table journal_lines
+    add constraint chk_journal_lines_currency check (currency ~ '^[A-Z]{3}$');
@@ -0,0 +1,5 @@
+alter table loan_payments
+    drop constraint if exists fk_loan_payments_settled_by_entry,
+    drop constraint if exists fk_loan_payments_late_fee_entry,
+    drop column if exists settled_by_entry_id,
+    drop column if exists late_fee_entry_id;
@@ -0,0 +1,79 @@
+-- Installments keep the entry that settled them and the entry that charged their late fee, so reversing either entry
+-- can restore the installment
+alter table loan_payments
+    add column if not exists settled_by_entry_id char(36),
+    add column if not exists late_fee_entry_id char(36);
+
+create index if not exists idx_loan_payments_settled_by_entry_id on loan_payments (settled_by_entry_id);
+create index if not exists idx_loan_payments_late_fee_entry_id on loan_payments (late_fee_entry_id);
+
+-- An installment is updated before its entry is posted in the same transaction, so the references are checked on commit
+alter table loan_payments
+    add constraint fk_loan_payments_settled_by_entry foreign key (settled_by_entry_id) references journal_entries (id)
+        deferrable initially deferred,
+    add constraint fk_loan_payments_late_fee_entry foreign key (late_fee_entry_id) references journal_entries (id)
+        deferrable initially deferred;
+
+-- Existing installments are only linked to an entry that has not been reversed, and only when nothing else could have
+-- settled them or charged their fee. Installments left unlinked keep their entries from being reversed.
+
+-- a repayment is posted at the moment its installments were paid and may settle several of them
+with candidates as (
+    select lp.id as loan_payment_id,
+           je.id as entry_id,
+           count(*) over (partition by lp.id) as entries
+    from loan_payments lp
+             join journal_entries je on je.loan_id = lp.loan_id and je.posted_at = lp.paid_at
+    where je.type = 'REPAYMENT'
+      and not exists (select 1 from journal_entries r where r.reversal_of_id = je.id)
+      and lp.status = 'PAID'
+      and lp.settled_by_entry_id is null
+)
+update loan_payments lp
+set settled_by_entry_id = c.entry_id
+from candidates c
+where c.loan_payment_id = lp.id
+  and c.entries = 1;
+
+-- a waiver settles one installment but does not say which, so only a loan with a single waiver and a single waived
+-- installment is linked
+with candidates as (
+    select lp.id as loan_payment_id,
+           je.id as entry_id,
+           count(*) over (partition by lp.id) as entries,
+           count(*) over (partition by je.id) as installments
+    from loan_payments lp
+             join journal_entries je on je.loan_id = lp.loan_id
+    where je.type = 'WAIVER'
+      and not exists (select 1 from journal_entries r where r.reversal_of_id = je.id)
+      and lp.status = 'WAIVED'
+      and lp.settled_by_entry_id is null
+)
+update loan_payments lp
+set settled_by_entry_id = c.entry_id
+from candidates c
+where c.loan_payment_id = lp.id
+  and c.entries = 1
+  and c.installments = 1;
+
+-- a fee accrual names the due date of its installment, the local one or, for older entries, the UTC one
+with candidates as (
+    select lp.id as loan_payment_id,
+           je.id as entry_id,
+           count(*) over (partition by lp.id) as entries,
+           count(*) over (partition by je.id) as installments
+    from loan_payments lp
+             join journal_entries je on je.loan_id = lp.loan_id
+    where je.type = 'FEE_ACCRUAL'
+      and je.description like 'late fee for installment due %'
+      and substring(je.description from '\d{4}-\d{2}-\d{2}$')::date in (lp.local_due_date, lp.due_date::date)
+      and not exists (select 1 from journal_entries r where r.reversal_of_id = je.id)
+      and lp.late_fee > 0
+      and lp.late_fee_entry_id is null
+)
+update loan_payments lp
+set late_fee_entry_id = c.entry_id
+from candidates c
+where c.loan_payment_id = lp.id
+  and c.entries = 1
+  and c.installments = 1;
@@ -0,0 +1,2 @@
+drop index if exists idx_loans_timezone;
+drop index if exists idx_loan_payments_status_local_due_date;
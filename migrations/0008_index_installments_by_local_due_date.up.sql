@@ -0,0 +1,3 @@
+-- Daily billing marks installments overdue by their local due date in the timezone of their loan
+create index if not exists idx_loan_payments_status_local_due_date on loan_payments (status, local_due_date);
+create index if not exists idx_loans_timezone on loans (timezone);
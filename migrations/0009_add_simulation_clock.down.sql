@@ -0,0 +1 @@
+drop table if exists simulation_clocks;
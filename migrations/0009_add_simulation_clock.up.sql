@@ -0,0 +1,7 @@
+-- The time of the simulated clock, kept so a server in simulation mode resumes where it stopped. There is one clock.
+create table if not exists simulation_clocks (
+    id           integer primary key,
+    simulated_at timestamp not null,
+    updated_at   timestamp not null default now(),
+    constraint chk_simulation_clocks_id check (id = 1)
+);
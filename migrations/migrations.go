@@ -0,0 +1,8 @@
+// Package migrations holds the versioned SQL migrations of the database schema. They are embedded in the migration
+// command, so it applies the migrations of the build it belongs to.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS